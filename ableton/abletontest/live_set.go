@@ -0,0 +1,215 @@
+package abletontest
+
+// A minimal Live Set containing one MIDI track with a device and a session clip,
+// and a master track with a tempo of 120 BPM and a 4/4 time signature.
+var OriginalLiveSetXml = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="10.0_377" Creator="Ableton Live 10.1.9">
+	<LiveSet>
+		<Tracks>
+			<MidiTrack Id="8">
+				<Name>
+					<EffectiveName Value="1-MIDI" />
+					<UserName Value="" />
+				</Name>
+				<DeviceChain>
+					<MainSequencer>
+						<ClipSlotList>
+							<ClipSlot Id="0">
+								<ClipSlot>
+									<Value>
+										<MidiClip Id="0" Time="0">
+											<Name Value="Intro" />
+											<Notes>
+												<KeyTracks>
+													<KeyTrack Id="0">
+														<MidiKey Value="60" />
+													</KeyTrack>
+												</KeyTracks>
+											</Notes>
+										</MidiClip>
+									</Value>
+								</ClipSlot>
+							</ClipSlot>
+						</ClipSlotList>
+					</MainSequencer>
+					<DeviceChain>
+						<Devices>
+							<Eq8 Id="1">
+								<UserName Value="" />
+							</Eq8>
+						</Devices>
+					</DeviceChain>
+				</DeviceChain>
+			</MidiTrack>
+		</Tracks>
+		<MasterTrack>
+			<Name>
+				<EffectiveName Value="Master" />
+				<UserName Value="" />
+			</Name>
+			<DeviceChain>
+				<Mixer>
+					<Tempo>
+						<Manual Value="120" />
+					</Tempo>
+					<TimeSignature>
+						<Manual Value="201" />
+					</TimeSignature>
+				</Mixer>
+			</DeviceChain>
+		</MasterTrack>
+	</LiveSet>
+</Ableton>
+`
+
+// The same Live Set as OriginalLiveSetXml, but with the MIDI track renamed, a compressor
+// inserted, the clip edited, an audio track added, and the tempo and time signature changed.
+var ModifiedLiveSetXml = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="10.0_377" Creator="Ableton Live 10.1.9">
+	<LiveSet>
+		<Tracks>
+			<MidiTrack Id="8">
+				<Name>
+					<EffectiveName Value="Bass" />
+					<UserName Value="Bass" />
+				</Name>
+				<DeviceChain>
+					<MainSequencer>
+						<ClipSlotList>
+							<ClipSlot Id="0">
+								<ClipSlot>
+									<Value>
+										<MidiClip Id="0" Time="0">
+											<Name Value="Intro" />
+											<Notes>
+												<KeyTracks>
+													<KeyTrack Id="0">
+														<MidiKey Value="62" />
+													</KeyTrack>
+												</KeyTracks>
+											</Notes>
+										</MidiClip>
+									</Value>
+								</ClipSlot>
+							</ClipSlot>
+						</ClipSlotList>
+					</MainSequencer>
+					<DeviceChain>
+						<Devices>
+							<Eq8 Id="1">
+								<UserName Value="" />
+							</Eq8>
+							<Compressor2 Id="2">
+								<UserName Value="" />
+							</Compressor2>
+						</Devices>
+					</DeviceChain>
+				</DeviceChain>
+			</MidiTrack>
+			<AudioTrack Id="9">
+				<Name>
+					<EffectiveName Value="Vocals" />
+					<UserName Value="Vocals" />
+				</Name>
+			</AudioTrack>
+		</Tracks>
+		<MasterTrack>
+			<Name>
+				<EffectiveName Value="Master" />
+				<UserName Value="" />
+			</Name>
+			<DeviceChain>
+				<Mixer>
+					<Tempo>
+						<Manual Value="128" />
+					</Tempo>
+					<TimeSignature>
+						<Manual Value="200" />
+					</TimeSignature>
+				</Mixer>
+			</DeviceChain>
+		</MasterTrack>
+	</LiveSet>
+</Ableton>
+`
+
+// The expected changes when comparing OriginalLiveSetXml to ModifiedLiveSetXml.
+var OriginalToModifiedLiveSetChanges = []string{
+	"Tempo changed from 120 to 128 BPM",
+	"Time signature changed from 4/4 to 3/4",
+	"Renamed track \"1-MIDI\" to \"Bass\"",
+	"Inserted device Compressor2 on track \"Bass\" (MidiTrack)",
+	"Edited clip \"Intro\" (session slot 1) on track \"Bass\" (MidiTrack)",
+	"Added track \"Vocals\" (AudioTrack)",
+}
+
+// The global settings of OriginalLiveSetXml after it is split into separate files for each track.
+var SplitOriginalLiveSetXml = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="10.0_377" Creator="Ableton Live 10.1.9">
+	<LiveSet>
+		<Tracks>
+			<MppmPart File="tracks/1-MIDI.xml" />
+		</Tracks>
+		<MppmPart File="master.xml" />
+	</LiveSet>
+</Ableton>
+`
+
+// The MIDI track of OriginalLiveSetXml, as the part "tracks/1-MIDI.xml".
+var SplitOriginalLiveSetMidiTrackXml = `<?xml version="1.0" encoding="UTF-8"?>
+<MidiTrack Id="8">
+	<Name>
+		<EffectiveName Value="1-MIDI" />
+		<UserName Value="" />
+	</Name>
+	<DeviceChain>
+		<MainSequencer>
+			<ClipSlotList>
+				<ClipSlot Id="0">
+					<ClipSlot>
+						<Value>
+							<MidiClip Id="0" Time="0">
+								<Name Value="Intro" />
+								<Notes>
+									<KeyTracks>
+										<KeyTrack Id="0">
+											<MidiKey Value="60" />
+										</KeyTrack>
+									</KeyTracks>
+								</Notes>
+							</MidiClip>
+						</Value>
+					</ClipSlot>
+				</ClipSlot>
+			</ClipSlotList>
+		</MainSequencer>
+		<DeviceChain>
+			<Devices>
+				<Eq8 Id="1">
+					<UserName Value="" />
+				</Eq8>
+			</Devices>
+		</DeviceChain>
+	</DeviceChain>
+</MidiTrack>
+`
+
+// The master track of OriginalLiveSetXml, as the part "master.xml".
+var SplitOriginalLiveSetMasterTrackXml = `<?xml version="1.0" encoding="UTF-8"?>
+<MasterTrack>
+	<Name>
+		<EffectiveName Value="Master" />
+		<UserName Value="" />
+	</Name>
+	<DeviceChain>
+		<Mixer>
+			<Tempo>
+				<Manual Value="120" />
+			</Tempo>
+			<TimeSignature>
+				<Manual Value="201" />
+			</TimeSignature>
+		</Mixer>
+	</DeviceChain>
+</MasterTrack>
+`
@@ -0,0 +1,124 @@
+package ableton
+
+import (
+	"fmt"
+)
+
+// Compares two versions of a Live Set and returns a human-readable description of each
+// musical change, such as added tracks, inserted devices, edited clips, or tempo changes.
+func DiffLiveSets(oldLiveSet *LiveSet, newLiveSet *LiveSet) (changes []string) {
+
+	changes = make([]string, 0)
+
+	if oldLiveSet.Tempo != newLiveSet.Tempo {
+		changes = append(changes, fmt.Sprintf("Tempo changed from %s to %s BPM", oldLiveSet.Tempo, newLiveSet.Tempo))
+	}
+
+	if oldLiveSet.TimeSignature != newLiveSet.TimeSignature {
+		changes = append(changes, fmt.Sprintf("Time signature changed from %s to %s", oldLiveSet.TimeSignature, newLiveSet.TimeSignature))
+	}
+
+	oldTracksById := make(map[string]*Track)
+	for _, oldTrack := range oldLiveSet.Tracks {
+		oldTracksById[oldTrack.Id] = oldTrack
+	}
+
+	newTracksById := make(map[string]*Track)
+	for _, newTrack := range newLiveSet.Tracks {
+		newTracksById[newTrack.Id] = newTrack
+	}
+
+	for _, oldTrack := range oldLiveSet.Tracks {
+		if _, ok := newTracksById[oldTrack.Id]; !ok {
+			changes = append(changes, "Removed track "+oldTrack.String())
+		}
+	}
+
+	for _, newTrack := range newLiveSet.Tracks {
+		oldTrack, ok := oldTracksById[newTrack.Id]
+		if !ok {
+			changes = append(changes, "Added track "+newTrack.String())
+			continue
+		}
+		changes = append(changes, diffTracks(oldTrack, newTrack)...)
+	}
+
+	return
+
+}
+
+func diffTracks(oldTrack *Track, newTrack *Track) (changes []string) {
+
+	changes = make([]string, 0)
+	trackDescription := newTrack.String()
+
+	if oldTrack.Name != newTrack.Name {
+		changes = append(changes, fmt.Sprintf("Renamed track \"%s\" to \"%s\"", oldTrack.Name, newTrack.Name))
+	}
+
+	oldDevicesById := make(map[string]*Device)
+	for _, oldDevice := range oldTrack.Devices {
+		oldDevicesById[oldDevice.Id] = oldDevice
+	}
+
+	newDevicesById := make(map[string]*Device)
+	for _, newDevice := range newTrack.Devices {
+		newDevicesById[newDevice.Id] = newDevice
+	}
+
+	// A device whose type changed under the same Id was replaced, so it is reported as removed and inserted.
+	for _, oldDevice := range oldTrack.Devices {
+		if newDevice, ok := newDevicesById[oldDevice.Id]; !ok || newDevice.Type != oldDevice.Type {
+			changes = append(changes, fmt.Sprintf("Removed device %s from track %s", oldDevice.String(), trackDescription))
+		}
+	}
+
+	for _, newDevice := range newTrack.Devices {
+		oldDevice, ok := oldDevicesById[newDevice.Id]
+		if !ok || oldDevice.Type != newDevice.Type {
+			changes = append(changes, fmt.Sprintf("Inserted device %s on track %s", newDevice.String(), trackDescription))
+			continue
+		}
+		// A device can be both renamed and changed, so both are reported.
+		if oldDevice.Name != newDevice.Name {
+			changes = append(changes, fmt.Sprintf("Renamed device %s to \"%s\" on track %s", oldDevice.String(), newDevice.Name, trackDescription))
+		}
+		if oldDevice.ContentHash != newDevice.ContentHash {
+			changes = append(changes, fmt.Sprintf("Changed the settings of device %s on track %s", newDevice.String(), trackDescription))
+		}
+	}
+
+	oldClipsByKey := make(map[string]*Clip)
+	for _, oldClip := range oldTrack.Clips {
+		oldClipsByKey[oldClip.Key] = oldClip
+	}
+
+	newClipsByKey := make(map[string]*Clip)
+	for _, newClip := range newTrack.Clips {
+		newClipsByKey[newClip.Key] = newClip
+	}
+
+	for _, oldClip := range oldTrack.Clips {
+		if _, ok := newClipsByKey[oldClip.Key]; !ok {
+			changes = append(changes, fmt.Sprintf("Removed clip %s from track %s", oldClip.String(), trackDescription))
+		}
+	}
+
+	for _, newClip := range newTrack.Clips {
+		oldClip, ok := oldClipsByKey[newClip.Key]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("Added clip %s to track %s", newClip.String(), trackDescription))
+			continue
+		}
+		// A clip can be both renamed and edited, so both are reported.
+		if oldClip.Name != newClip.Name {
+			changes = append(changes, fmt.Sprintf("Renamed clip %s to \"%s\" on track %s", oldClip.String(), newClip.Name, trackDescription))
+		}
+		if oldClip.ContentHash != newClip.ContentHash {
+			changes = append(changes, fmt.Sprintf("Edited clip %s on track %s", newClip.String(), trackDescription))
+		}
+	}
+
+	return
+
+}
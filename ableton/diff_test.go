@@ -0,0 +1,115 @@
+package ableton_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/ableton/abletontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLiveSets(t *testing.T) {
+
+	testCases := []*DiffLiveSetsTestCase{
+
+		&DiffLiveSetsTestCase{
+			description:     "Test that tempo, time signature, track, device, and clip changes are all reported.",
+			oldLiveSetXml:   abletontest.OriginalLiveSetXml,
+			newLiveSetXml:   abletontest.ModifiedLiveSetXml,
+			expectedChanges: abletontest.OriginalToModifiedLiveSetChanges,
+		},
+
+		&DiffLiveSetsTestCase{
+			description:     "Test that removed tracks are reported.",
+			oldLiveSetXml:   abletontest.ModifiedLiveSetXml,
+			newLiveSetXml:   strings.Replace(abletontest.ModifiedLiveSetXml, "<AudioTrack Id=\"9\">", "<AudioTrack Id=\"10\">", 1),
+			expectedChanges: []string{"Removed track \"Vocals\" (AudioTrack)", "Added track \"Vocals\" (AudioTrack)"},
+		},
+
+		&DiffLiveSetsTestCase{
+			description:   "Test that a device replaced by a device of another type with the same Id is reported as removed and inserted.",
+			oldLiveSetXml: abletontest.ModifiedLiveSetXml,
+			newLiveSetXml: strings.Replace(abletontest.ModifiedLiveSetXml, "Compressor2", "GlueCompressor", 2),
+			expectedChanges: []string{
+				"Removed device Compressor2 from track \"Bass\" (MidiTrack)",
+				"Inserted device GlueCompressor on track \"Bass\" (MidiTrack)",
+			},
+		},
+
+		&DiffLiveSetsTestCase{
+			description:   "Test that devices and clips that were both renamed and changed are reported as both.",
+			oldLiveSetXml: abletontest.OriginalLiveSetXml,
+			newLiveSetXml: strings.NewReplacer(
+				`<UserName Value="" />
+							</Eq8>`,
+				`<UserName Value="Low Cut" />
+								<On Value="false" />
+							</Eq8>`,
+				`<Name Value="Intro" />`, `<Name Value="Verse" />`,
+				`<MidiKey Value="60" />`, `<MidiKey Value="62" />`,
+			).Replace(abletontest.OriginalLiveSetXml),
+			expectedChanges: []string{
+				"Renamed device Eq8 to \"Low Cut\" on track \"1-MIDI\" (MidiTrack)",
+				"Changed the settings of device Low Cut (Eq8) on track \"1-MIDI\" (MidiTrack)",
+				"Renamed clip \"Intro\" (session slot 1) to \"Verse\" on track \"1-MIDI\" (MidiTrack)",
+				"Edited clip \"Verse\" (session slot 1) on track \"1-MIDI\" (MidiTrack)",
+			},
+		},
+
+		&DiffLiveSetsTestCase{
+			description:     "Test that renaming a clip isn't also reported as editing it.",
+			oldLiveSetXml:   abletontest.OriginalLiveSetXml,
+			newLiveSetXml:   strings.Replace(abletontest.OriginalLiveSetXml, `<Name Value="Intro" />`, `<Name Value="Verse" />`, 1),
+			expectedChanges: []string{"Renamed clip \"Intro\" (session slot 1) to \"Verse\" on track \"1-MIDI\" (MidiTrack)"},
+		},
+
+		&DiffLiveSetsTestCase{
+			description:     "Test that no changes are reported for identical Live Sets.",
+			oldLiveSetXml:   abletontest.OriginalLiveSetXml,
+			newLiveSetXml:   abletontest.OriginalLiveSetXml,
+			expectedChanges: []string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestParseLiveSet(t *testing.T) {
+
+	liveSet, err := ableton.ParseLiveSet(strings.NewReader(abletontest.OriginalLiveSetXml))
+	assert.Nil(t, err)
+	assert.Exactly(t, "120", liveSet.Tempo)
+	assert.Exactly(t, "4/4", liveSet.TimeSignature)
+	assert.Exactly(t, "Ableton Live 10.1.9", liveSet.Creator)
+	assert.Len(t, liveSet.Tracks, 2)
+
+	_, err = ableton.ParseLiveSet(strings.NewReader("<Ableton><GroupDevicePreset /></Ableton>"))
+	assert.NotNil(t, err, "Test that documents without a LiveSet element are rejected.")
+
+}
+
+// ------------------------------------------------------------------------------
+
+type DiffLiveSetsTestCase struct {
+	description     string
+	oldLiveSetXml   string
+	newLiveSetXml   string
+	expectedChanges []string
+}
+
+func (testCase *DiffLiveSetsTestCase) Run(t *testing.T) {
+
+	oldLiveSet, err := ableton.ParseLiveSet(strings.NewReader(testCase.oldLiveSetXml))
+	assert.Nilf(t, err, testCase.description)
+
+	newLiveSet, err := ableton.ParseLiveSet(strings.NewReader(testCase.newLiveSetXml))
+	assert.Nilf(t, err, testCase.description)
+
+	actualChanges := ableton.DiffLiveSets(oldLiveSet, newLiveSet)
+	assert.Exactlyf(t, testCase.expectedChanges, actualChanges, testCase.description)
+
+}
@@ -0,0 +1,267 @@
+package ableton
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+var XmlHeader = `<?xml version="1.0" encoding="UTF-8"?>`
+
+// Parses an XML document, such as an extracted Ableton Live Set, and returns its root element.
+func ParseElement(xmlReader io.Reader) (root *Element, err error) {
+
+	decoder := xml.NewDecoder(xmlReader)
+	openElements := make([]*Element, 0)
+
+	for {
+
+		token, tokenErr := decoder.Token()
+		if tokenErr == io.EOF {
+			break
+		} else if tokenErr != nil {
+			err = tokenErr
+			return nil, err
+		}
+
+		switch token := token.(type) {
+
+		case xml.StartElement:
+			element := &Element{
+				Name:     token.Name.Local,
+				Attrs:    make([]*Attr, 0, len(token.Attr)),
+				Children: make([]*Element, 0),
+			}
+			for _, attr := range token.Attr {
+				element.Attrs = append(element.Attrs, &Attr{Name: attr.Name.Local, Value: attr.Value})
+			}
+			if len(openElements) > 0 {
+				parent := openElements[len(openElements)-1]
+				parent.Children = append(parent.Children, element)
+			} else if root == nil {
+				root = element
+			}
+			openElements = append(openElements, element)
+
+		case xml.EndElement:
+			openElements = openElements[:len(openElements)-1]
+
+		case xml.CharData:
+			if len(openElements) > 0 {
+				openElements[len(openElements)-1].Text += string(token)
+			}
+
+		}
+
+	}
+
+	if root == nil {
+		err = errors.New("The XML document does not contain any elements.")
+		return
+	}
+
+	root.trimText()
+	return
+
+}
+
+func ParseElementFromBytes(xmlAsBytes []byte) (root *Element, err error) {
+	return ParseElement(bytes.NewReader(xmlAsBytes))
+}
+
+// ------------------------------------------------------------------------------
+
+type Attr struct {
+	Name  string
+	Value string
+}
+
+// A minimal, order-preserving representation of an XML element.
+type Element struct {
+	Name     string
+	Attrs    []*Attr
+	Children []*Element
+	Text     string
+}
+
+func NewElement(name string) *Element {
+	return &Element{
+		Name:     name,
+		Attrs:    make([]*Attr, 0),
+		Children: make([]*Element, 0),
+	}
+}
+
+func (element *Element) GetAttr(name string) (value string, ok bool) {
+	for _, attr := range element.Attrs {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+func (element *Element) SetAttr(name string, value string) {
+	for _, attr := range element.Attrs {
+		if attr.Name == name {
+			attr.Value = value
+			return
+		}
+	}
+	element.Attrs = append(element.Attrs, &Attr{Name: name, Value: value})
+}
+
+// Returns the first child element with the given name, or nil if there is none.
+func (element *Element) Child(name string) *Element {
+	for _, child := range element.Children {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// Follows the given element names from this element, returning nil if any of them are missing.
+func (element *Element) ChildAtPath(names ...string) *Element {
+	current := element
+	for _, name := range names {
+		if current = current.Child(name); current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+// Returns the "Value" attribute of the child element at the given path, which is
+// how most Ableton settings are stored, or an empty string if it doesn't exist.
+func (element *Element) ValueAtPath(names ...string) string {
+	child := element.ChildAtPath(names...)
+	if child == nil {
+		return ""
+	}
+	value, _ := child.GetAttr("Value")
+	return value
+}
+
+// Calls walkFn for this element and all of its descendants, depth-first.
+// Descendants of an element are skipped if walkFn returns false for it.
+func (element *Element) Walk(walkFn func(element *Element) bool) {
+	if !walkFn(element) {
+		return
+	}
+	for _, child := range element.Children {
+		child.Walk(walkFn)
+	}
+}
+
+// Returns a deep copy of this element.
+func (element *Element) Copy() *Element {
+	elementCopy := &Element{
+		Name:     element.Name,
+		Attrs:    make([]*Attr, 0, len(element.Attrs)),
+		Children: make([]*Element, 0, len(element.Children)),
+		Text:     element.Text,
+	}
+	for _, attr := range element.Attrs {
+		elementCopy.Attrs = append(elementCopy.Attrs, &Attr{Name: attr.Name, Value: attr.Value})
+	}
+	for _, child := range element.Children {
+		elementCopy.Children = append(elementCopy.Children, child.Copy())
+	}
+	return elementCopy
+}
+
+// Writes this element and all of its descendants, indented with tabs in the same style used by Ableton.
+func (element *Element) Write(writer io.Writer) (err error) {
+	buffer := &bytes.Buffer{}
+	element.writeIndented(buffer, 0)
+	_, err = io.Copy(writer, buffer)
+	return
+}
+
+// Returns this element as a complete XML document, including the XML header.
+func (element *Element) AsXmlDocument() []byte {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString(XmlHeader + "\n")
+	element.writeIndented(buffer, 0)
+	return buffer.Bytes()
+}
+
+func (element *Element) writeIndented(buffer *bytes.Buffer, depth int) {
+	element.writeStartTag(buffer, depth)
+	if len(element.Children) == 0 {
+		return
+	}
+	for _, child := range element.Children {
+		child.writeIndented(buffer, depth+1)
+	}
+	element.writeEndTag(buffer, depth)
+}
+
+// Writes the line that opens this element, followed by its text, if any. Elements without children are written completely.
+func (element *Element) writeStartTag(buffer *bytes.Buffer, depth int) {
+
+	indentation := strings.Repeat("\t", depth)
+
+	buffer.WriteString(indentation + "<" + element.Name)
+	for _, attr := range element.Attrs {
+		buffer.WriteString(" " + attr.Name + "=\"" + escapeXmlAttr(attr.Value) + "\"")
+	}
+
+	if len(element.Children) == 0 && element.Text == "" {
+		buffer.WriteString(" />\n")
+		return
+	}
+
+	buffer.WriteString(">")
+
+	if len(element.Children) == 0 {
+		buffer.WriteString(escapeXmlText(element.Text) + "</" + element.Name + ">\n")
+		return
+	}
+
+	buffer.WriteString("\n")
+	if element.Text != "" {
+		buffer.WriteString(indentation + "\t" + escapeXmlText(element.Text) + "\n")
+	}
+
+}
+
+func (element *Element) writeEndTag(buffer *bytes.Buffer, depth int) {
+	buffer.WriteString(strings.Repeat("\t", depth) + "</" + element.Name + ">\n")
+}
+
+func (element *Element) trimText() {
+	element.Text = strings.TrimSpace(element.Text)
+	for _, child := range element.Children {
+		child.trimText()
+	}
+}
+
+// ------------------------------------------------------------------------------
+
+var xmlAttrEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	"\"", "&quot;",
+	"\n", "&#10;",
+	"\r", "&#13;",
+	"\t", "&#9;",
+)
+
+var xmlTextEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+func escapeXmlAttr(value string) string {
+	return xmlAttrEscaper.Replace(value)
+}
+
+func escapeXmlText(text string) string {
+	return xmlTextEscaper.Replace(text)
+}
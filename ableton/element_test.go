@@ -0,0 +1,50 @@
+package ableton_test
+
+import (
+	"testing"
+
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/ableton/abletontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElementAsXmlDocument(t *testing.T) {
+
+	testCases := []*ElementAsXmlDocumentTestCase{
+
+		&ElementAsXmlDocumentTestCase{
+			description: "Test that Ableton-formatted XML is written back out unchanged.",
+			xml:         abletontest.OriginalLiveSetXml,
+			expectedXml: abletontest.OriginalLiveSetXml,
+		},
+
+		&ElementAsXmlDocumentTestCase{
+			description: "Test that text content and special characters are preserved.",
+			xml:         "<Ableton><Buffer>\n\t\t00FF\n\t</Buffer><Name Value=\"Drums &amp; &quot;Bass&quot;\"/></Ableton>",
+			expectedXml: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Ableton>\n\t<Buffer>00FF</Buffer>\n\t<Name Value=\"Drums &amp; &quot;Bass&quot;\" />\n</Ableton>\n",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+// ------------------------------------------------------------------------------
+
+type ElementAsXmlDocumentTestCase struct {
+	description string
+	xml         string
+	expectedXml string
+}
+
+func (testCase *ElementAsXmlDocumentTestCase) Run(t *testing.T) {
+
+	root, err := ableton.ParseElementFromBytes([]byte(testCase.xml))
+	assert.Nilf(t, err, testCase.description)
+
+	actualXml := string(root.AsXmlDocument())
+	assert.Exactlyf(t, testCase.expectedXml, actualXml, testCase.description)
+
+}
@@ -0,0 +1,204 @@
+package ableton
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// The kinds of files that a Live Set can refer to.
+const (
+	SampleFileRefKind    = "sample"
+	PresetFileRefKind    = "preset"
+	MaxDeviceFileRefKind = "Max device"
+)
+
+// Element names that contain the FileRef of a file the Live Set depends on, indexed by the kind of file.
+// Other FileRefs, such as the browser locations that devices were originally loaded from, are ignored.
+var FileRefParentElementNames = map[string][]string{
+	SampleFileRefKind:    []string{"SampleRef"},
+	PresetFileRefKind:    []string{"FilePresetRef"},
+	MaxDeviceFileRefKind: []string{"MxPatchRef"},
+}
+
+// The RelativePathType of files that are stored relative to the Live Set's project folder.
+var ProjectRelativePathType = "3"
+
+// The RelativePathType of files that are only stored by their absolute paths.
+var NoRelativePathType = "0"
+
+// ------------------------------------------------------------------------------
+
+// A reference from a Live Set to an external file, such as an audio sample.
+type FileRef struct {
+	Kind             string
+	Path             string // The absolute path of the file when the Live Set was saved.
+	RelativePath     string // The slash-separated path of the file relative to the project folder, or an empty string.
+	OriginalFileSize int64  // Zero if unknown.
+	OriginalCrc      string // An empty string if unknown. Live's CRC algorithm isn't documented, so this isn't checked by relink.
+	Element          *Element
+}
+
+// Returns every reference to an external file in the given element and its descendants,
+// in document order. Both the format of Live 11 and later, where paths are stored as
+// strings, and the older format, where paths are stored as lists of directories, are supported.
+func GetFileRefs(root *Element) (fileRefs []*FileRef) {
+
+	fileRefs = make([]*FileRef, 0)
+
+	root.Walk(func(element *Element) bool {
+		kind := getFileRefKind(element.Name)
+		if kind == "" {
+			return true
+		}
+		fileRefElement := element.Child("FileRef")
+		if fileRefElement == nil {
+			return true
+		}
+		fileRef := newFileRefFromElement(kind, fileRefElement)
+		if fileRef.Path != "" || fileRef.RelativePath != "" {
+			fileRefs = append(fileRefs, fileRef)
+		}
+		return true
+	})
+
+	return
+
+}
+
+// Returns true if the file's RelativePath is relative to the Live Set's project folder.
+func (fileRef *FileRef) IsRelativeToProject() bool {
+	if fileRef.RelativePath == "" {
+		return false
+	}
+	relativePathType := fileRef.Element.ValueAtPath("RelativePathType")
+	return relativePathType == "" || relativePathType == ProjectRelativePathType
+}
+
+// Returns the name of the file, without any directories.
+func (fileRef *FileRef) FileName() string {
+	filePath := fileRef.Path
+	if filePath == "" {
+		filePath = fileRef.RelativePath
+	}
+	filePath = strings.ReplaceAll(filePath, "\\", "/")
+	return filePath[strings.LastIndex(filePath, "/")+1:]
+}
+
+// Changes the file that this reference refers to. The relative path should be slash-separated and relative
+// to the Live Set's project folder, or an empty string if the file isn't inside it. Only references in Live Sets
+// saved by Live 11 and later can be changed, since older versions also store the path in a binary format.
+func (fileRef *FileRef) SetPath(filePath string, relativePath string) (err error) {
+
+	pathElement := fileRef.Element.Child("Path")
+	if pathElement == nil {
+		err = errors.New("References to files in Live Sets saved by Live 10 and earlier can't be changed.")
+		return
+	}
+
+	relativePathType := NoRelativePathType
+	if relativePath != "" {
+		relativePathType = ProjectRelativePathType
+	}
+
+	pathElement.SetAttr("Value", filePath)
+	setChildValue(fileRef.Element, "RelativePath", relativePath)
+	setChildValue(fileRef.Element, "RelativePathType", relativePathType)
+
+	fileRef.Path = filePath
+	fileRef.RelativePath = relativePath
+	return
+
+}
+
+// Changes the absolute path of the file that this reference refers to, and keeps its relative path as it is.
+// Like SetPath, this can only be used for references in Live Sets saved by Live 11 and later.
+func (fileRef *FileRef) SetAbsolutePath(filePath string) (err error) {
+
+	pathElement := fileRef.Element.Child("Path")
+	if pathElement == nil {
+		err = errors.New("References to files in Live Sets saved by Live 10 and earlier can't be changed.")
+		return
+	}
+
+	pathElement.SetAttr("Value", filePath)
+	fileRef.Path = filePath
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+// Sets the "Value" attribute of the first child element with the given name, adding the child if it doesn't exist.
+func setChildValue(element *Element, name string, value string) {
+	child := element.Child(name)
+	if child == nil {
+		child = NewElement(name)
+		element.Children = append(element.Children, child)
+	}
+	child.SetAttr("Value", value)
+}
+
+func getFileRefKind(elementName string) string {
+	for kind, parentElementNames := range FileRefParentElementNames {
+		if isOneOf(elementName, parentElementNames) {
+			return kind
+		}
+	}
+	return ""
+}
+
+func newFileRefFromElement(kind string, fileRefElement *Element) (fileRef *FileRef) {
+
+	fileRef = &FileRef{
+		Kind:    kind,
+		Element: fileRefElement,
+	}
+
+	// Live 11 and later.
+	if pathElement := fileRefElement.Child("Path"); pathElement != nil {
+		fileRef.Path, _ = pathElement.GetAttr("Value")
+		fileRef.RelativePath = fileRefElement.ValueAtPath("RelativePath")
+		fileRef.OriginalFileSize, _ = strconv.ParseInt(fileRefElement.ValueAtPath("OriginalFileSize"), 10, 64)
+		fileRef.OriginalCrc = fileRefElement.ValueAtPath("OriginalCrc")
+		return
+	}
+
+	// Live 10 and earlier.
+	fileName := fileRefElement.ValueAtPath("Name")
+	if fileName == "" {
+		return
+	}
+	if fileRefElement.ValueAtPath("HasRelativePath") == "true" {
+		fileRef.RelativePath = joinRelativePathElements(fileRefElement.Child("RelativePath"), fileName)
+	}
+	if pathHintElement := fileRefElement.ChildAtPath("SearchHint", "PathHint"); pathHintElement != nil {
+		fileRef.Path = joinRelativePathElements(pathHintElement, fileName)
+		// Windows paths already start with a drive, such as "C:", but other paths need a leading slash.
+		if !strings.HasSuffix(strings.SplitN(fileRef.Path, "/", 2)[0], ":") {
+			fileRef.Path = "/" + fileRef.Path
+		}
+	}
+	fileRef.OriginalFileSize, _ = strconv.ParseInt(fileRefElement.ValueAtPath("SearchHint", "FileSize"), 10, 64)
+	fileRef.OriginalCrc = fileRefElement.ValueAtPath("SearchHint", "Crc")
+
+	return
+
+}
+
+// Joins the Dir attributes of the RelativePathElement children of the given element
+// and the given file name into a slash-separated path.
+func joinRelativePathElements(element *Element, fileName string) string {
+	directoryNames := make([]string, 0)
+	if element != nil {
+		for _, child := range element.Children {
+			if child.Name != "RelativePathElement" {
+				continue
+			}
+			if directoryName, ok := child.GetAttr("Dir"); ok && directoryName != "" {
+				directoryNames = append(directoryNames, directoryName)
+			}
+		}
+	}
+	return strings.Join(append(directoryNames, fileName), "/")
+}
@@ -0,0 +1,273 @@
+package ableton_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetFileRefs(t *testing.T) {
+
+	testCases := []*GetFileRefsTestCase{
+
+		&GetFileRefsTestCase{
+			description: "Test that samples, presets, and Max devices are found in Live Sets saved by Live 11 and later.",
+			xml: `<Ableton>
+	<LiveSet>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="3" />
+					<RelativePath Value="Samples/Imported/kick.wav" />
+					<Path Value="/Users/test/Music/Song Project/Samples/Imported/kick.wav" />
+					<OriginalFileSize Value="12345" />
+					<OriginalCrc Value="4321" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<LastPresetRef>
+			<Value>
+				<FilePresetRef Id="0">
+					<FileRef>
+						<RelativePathType Value="6" />
+						<RelativePath Value="Presets/Bass.adv" />
+						<Path Value="/Users/test/Music/Ableton/User Library/Presets/Bass.adv" />
+						<OriginalFileSize Value="0" />
+						<OriginalCrc Value="0" />
+					</FileRef>
+				</FilePresetRef>
+			</Value>
+		</LastPresetRef>
+		<SourceContext>
+			<OriginalFileRef>
+				<FileRef>
+					<RelativePath Value="" />
+					<Path Value="/Users/test/Music/Ableton/User Library/Presets/Bass.adv" />
+				</FileRef>
+			</OriginalFileRef>
+		</SourceContext>
+		<MxPatchRef Id="1">
+			<FileRef>
+				<RelativePathType Value="0" />
+				<RelativePath Value="" />
+				<Path Value="C:/Max Devices/LFO.amxd" />
+			</FileRef>
+		</MxPatchRef>
+		<SampleRef>
+			<FileRef>
+				<RelativePath Value="" />
+				<Path Value="" />
+			</FileRef>
+		</SampleRef>
+	</LiveSet>
+</Ableton>`,
+			expectedFileRefs: []*ableton.FileRef{
+				&ableton.FileRef{
+					Kind:             ableton.SampleFileRefKind,
+					Path:             "/Users/test/Music/Song Project/Samples/Imported/kick.wav",
+					RelativePath:     "Samples/Imported/kick.wav",
+					OriginalFileSize: 12345,
+					OriginalCrc:      "4321",
+				},
+				&ableton.FileRef{
+					Kind:             ableton.PresetFileRefKind,
+					Path:             "/Users/test/Music/Ableton/User Library/Presets/Bass.adv",
+					RelativePath:     "Presets/Bass.adv",
+					OriginalFileSize: 0,
+					OriginalCrc:      "0",
+				},
+				&ableton.FileRef{
+					Kind: ableton.MaxDeviceFileRefKind,
+					Path: "C:/Max Devices/LFO.amxd",
+				},
+			},
+			expectedIsRelativeToProject: []bool{true, false, false},
+			expectedFileNames:           []string{"kick.wav", "Bass.adv", "LFO.amxd"},
+		},
+
+		&GetFileRefsTestCase{
+			description: "Test that samples are found in Live Sets saved by Live 10 and earlier.",
+			xml: `<Ableton>
+	<LiveSet>
+		<SampleRef>
+			<FileRef>
+				<HasRelativePath Value="true" />
+				<RelativePathType Value="3" />
+				<RelativePath>
+					<RelativePathElement Id="0" Dir="Samples" />
+					<RelativePathElement Id="1" Dir="Recorded" />
+				</RelativePath>
+				<Name Value="vocals.wav" />
+				<SearchHint>
+					<PathHint>
+						<RelativePathElement Id="0" Dir="Users" />
+						<RelativePathElement Id="1" Dir="test" />
+						<RelativePathElement Id="2" Dir="Song Project" />
+						<RelativePathElement Id="3" Dir="Samples" />
+						<RelativePathElement Id="4" Dir="Recorded" />
+					</PathHint>
+					<FileSize Value="987" />
+					<Crc Value="65" />
+				</SearchHint>
+			</FileRef>
+		</SampleRef>
+		<SampleRef>
+			<FileRef>
+				<HasRelativePath Value="false" />
+				<RelativePath />
+				<Name Value="snare.aif" />
+				<SearchHint>
+					<PathHint>
+						<RelativePathElement Id="0" Dir="D:" />
+						<RelativePathElement Id="1" Dir="Drums" />
+					</PathHint>
+				</SearchHint>
+			</FileRef>
+		</SampleRef>
+	</LiveSet>
+</Ableton>`,
+			expectedFileRefs: []*ableton.FileRef{
+				&ableton.FileRef{
+					Kind:             ableton.SampleFileRefKind,
+					Path:             "/Users/test/Song Project/Samples/Recorded/vocals.wav",
+					RelativePath:     "Samples/Recorded/vocals.wav",
+					OriginalFileSize: 987,
+					OriginalCrc:      "65",
+				},
+				&ableton.FileRef{
+					Kind: ableton.SampleFileRefKind,
+					Path: "D:/Drums/snare.aif",
+				},
+			},
+			expectedIsRelativeToProject: []bool{true, false},
+			expectedFileNames:           []string{"vocals.wav", "snare.aif"},
+		},
+
+		&GetFileRefsTestCase{
+			description:                 "Test that no files are found in Live Sets without any samples, presets, or Max devices.",
+			xml:                         `<Ableton><LiveSet><Tracks /></LiveSet></Ableton>`,
+			expectedFileRefs:            []*ableton.FileRef{},
+			expectedIsRelativeToProject: []bool{},
+			expectedFileNames:           []string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestFileRefSetPath(t *testing.T) {
+
+	testCases := []*FileRefSetPathTestCase{
+
+		&FileRefSetPathTestCase{
+			description:  "Test that the path of a file inside the project is stored relative to the project folder.",
+			xml:          `<SampleRef><FileRef><RelativePathType Value="0" /><RelativePath Value="" /><Path Value="/old/kick.wav" /></FileRef></SampleRef>`,
+			filePath:     "/Users/test/Song Project/Samples/kick.wav",
+			relativePath: "Samples/kick.wav",
+			expectedXml: `<?xml version="1.0" encoding="UTF-8"?>
+<SampleRef>
+	<FileRef>
+		<RelativePathType Value="3" />
+		<RelativePath Value="Samples/kick.wav" />
+		<Path Value="/Users/test/Song Project/Samples/kick.wav" />
+	</FileRef>
+</SampleRef>
+`,
+		},
+
+		&FileRefSetPathTestCase{
+			description:  "Test that only the absolute path is stored for files outside of the project, and that missing elements are added.",
+			xml:          `<SampleRef><FileRef><Path Value="Samples/kick.wav" /></FileRef></SampleRef>`,
+			filePath:     "/home/test/library/kick.wav",
+			relativePath: "",
+			expectedXml: `<?xml version="1.0" encoding="UTF-8"?>
+<SampleRef>
+	<FileRef>
+		<Path Value="/home/test/library/kick.wav" />
+		<RelativePath Value="" />
+		<RelativePathType Value="0" />
+	</FileRef>
+</SampleRef>
+`,
+		},
+
+		&FileRefSetPathTestCase{
+			description:   "Test that an error is raised for Live Sets saved by Live 10 and earlier.",
+			xml:           `<SampleRef><FileRef><HasRelativePath Value="true" /><RelativePath /><Name Value="kick.wav" /></FileRef></SampleRef>`,
+			filePath:      "/home/test/library/kick.wav",
+			relativePath:  "",
+			expectedError: errors.New("References to files in Live Sets saved by Live 10 and earlier can't be changed."),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+// ------------------------------------------------------------------------------
+
+type GetFileRefsTestCase struct {
+	description                 string
+	xml                         string
+	expectedFileRefs            []*ableton.FileRef // The Element of each FileRef is not checked.
+	expectedIsRelativeToProject []bool
+	expectedFileNames           []string
+}
+
+func (testCase *GetFileRefsTestCase) Run(t *testing.T) {
+
+	root, err := ableton.ParseElementFromBytes([]byte(testCase.xml))
+	assert.Nilf(t, err, testCase.description)
+
+	actualFileRefs := ableton.GetFileRefs(root)
+
+	actualIsRelativeToProject := make([]bool, 0, len(actualFileRefs))
+	actualFileNames := make([]string, 0, len(actualFileRefs))
+	for _, fileRef := range actualFileRefs {
+		assert.Equalf(t, "FileRef", fileRef.Element.Name, testCase.description)
+		actualIsRelativeToProject = append(actualIsRelativeToProject, fileRef.IsRelativeToProject())
+		actualFileNames = append(actualFileNames, fileRef.FileName())
+		fileRef.Element = nil
+	}
+
+	assert.Exactlyf(t, testCase.expectedFileRefs, actualFileRefs, testCase.description)
+	assert.Exactlyf(t, testCase.expectedIsRelativeToProject, actualIsRelativeToProject, testCase.description)
+	assert.Exactlyf(t, testCase.expectedFileNames, actualFileNames, testCase.description)
+
+}
+
+type FileRefSetPathTestCase struct {
+	description   string
+	xml           string
+	filePath      string
+	relativePath  string
+	expectedXml   string
+	expectedError error
+}
+
+func (testCase *FileRefSetPathTestCase) Run(t *testing.T) {
+
+	root, err := ableton.ParseElementFromBytes([]byte(testCase.xml))
+	assert.Nilf(t, err, testCase.description)
+
+	fileRefs := ableton.GetFileRefs(root)
+	assert.Lenf(t, fileRefs, 1, testCase.description)
+
+	err = fileRefs[0].SetPath(testCase.filePath, testCase.relativePath)
+	assert.Equalf(t, testCase.expectedError, err, testCase.description)
+	if testCase.expectedError != nil {
+		return
+	}
+
+	assert.Exactlyf(t, testCase.filePath, fileRefs[0].Path, testCase.description)
+	assert.Exactlyf(t, testCase.relativePath, fileRefs[0].RelativePath, testCase.description)
+	assert.Exactlyf(t, testCase.expectedXml, string(root.AsXmlDocument()), testCase.description)
+
+}
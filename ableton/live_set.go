@@ -0,0 +1,304 @@
+package ableton
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Element names of the different kinds of tracks found in a Live Set.
+var TrackElementNames = []string{
+	"AudioTrack",
+	"MidiTrack",
+	"GroupTrack",
+	"ReturnTrack",
+}
+
+// Element names of the master track, which was renamed to the "main" track in Live 12.
+var MasterTrackElementNames = []string{
+	"MasterTrack",
+	"MainTrack",
+}
+
+var ClipElementNames = []string{
+	"AudioClip",
+	"MidiClip",
+}
+
+// ------------------------------------------------------------------------------
+
+// Parses an extracted Ableton Live Set and returns a summary of its musical contents.
+func ParseLiveSet(xmlReader io.Reader) (liveSet *LiveSet, err error) {
+
+	root, err := ParseElement(xmlReader)
+	if err != nil {
+		return
+	}
+
+	liveSet, err = NewLiveSetFromElement(root)
+	return
+
+}
+
+func NewLiveSetFromElement(root *Element) (liveSet *LiveSet, err error) {
+
+	liveSetElement, err := getLiveSetElement(root)
+	if err != nil {
+		return
+	}
+
+	liveSet = &LiveSet{
+		Tracks: make([]*Track, 0),
+	}
+	liveSet.Creator, _ = root.GetAttr("Creator")
+	liveSet.MinorVersion, _ = root.GetAttr("MinorVersion")
+
+	if tracksElement := liveSetElement.Child("Tracks"); tracksElement != nil {
+		for _, trackElement := range tracksElement.Children {
+			if isOneOf(trackElement.Name, TrackElementNames) {
+				liveSet.Tracks = append(liveSet.Tracks, newTrackFromElement(trackElement))
+			}
+		}
+	}
+
+	for _, masterTrackElementName := range MasterTrackElementNames {
+		if masterTrackElement := liveSetElement.Child(masterTrackElementName); masterTrackElement != nil {
+			masterTrack := newTrackFromElement(masterTrackElement)
+			masterTrack.Id = masterTrackElementName
+			masterTrack.Name = "Master"
+			liveSet.Tracks = append(liveSet.Tracks, masterTrack)
+			liveSet.Tempo = masterTrackElement.ValueAtPath("DeviceChain", "Mixer", "Tempo", "Manual")
+			liveSet.TimeSignature = decodeTimeSignature(
+				masterTrackElement.ValueAtPath("DeviceChain", "Mixer", "TimeSignature", "Manual"),
+			)
+		}
+	}
+
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+type LiveSet struct {
+	Creator       string
+	MinorVersion  string
+	Tempo         string
+	TimeSignature string
+	Tracks        []*Track
+}
+
+type Track struct {
+	Id      string
+	Type    string
+	Name    string
+	Devices []*Device
+	Clips   []*Clip
+}
+
+type Device struct {
+	Id          string
+	Type        string
+	Name        string
+	ContentHash string // A hash of the device's settings, which doesn't change when it is renamed.
+}
+
+type Clip struct {
+	Key         string // Uniquely identifies the clip's location within its track.
+	Type        string
+	Name        string
+	ContentHash string // A hash of the clip's contents, which doesn't change when it is renamed.
+}
+
+func (track *Track) String() string {
+	return fmt.Sprintf("\"%s\" (%s)", track.Name, track.Type)
+}
+
+func (device *Device) String() string {
+	if device.Name == device.Type {
+		return device.Type
+	}
+	return fmt.Sprintf("%s (%s)", device.Name, device.Type)
+}
+
+func (clip *Clip) String() string {
+	return fmt.Sprintf("\"%s\" (%s)", clip.Name, clip.Key)
+}
+
+// ------------------------------------------------------------------------------
+
+func newTrackFromElement(trackElement *Element) (track *Track) {
+
+	track = &Track{
+		Type:    trackElement.Name,
+		Devices: make([]*Device, 0),
+		Clips:   make([]*Clip, 0),
+	}
+	track.Id, _ = trackElement.GetAttr("Id")
+
+	track.Name = getTrackName(trackElement)
+
+	if devicesElement := trackElement.ChildAtPath("DeviceChain", "DeviceChain", "Devices"); devicesElement != nil {
+		for _, deviceElement := range devicesElement.Children {
+			track.Devices = append(track.Devices, newDeviceFromElement(deviceElement))
+		}
+	}
+
+	if mainSequencerElement := trackElement.ChildAtPath("DeviceChain", "MainSequencer"); mainSequencerElement != nil {
+		track.Clips = getClipsFromMainSequencerElement(mainSequencerElement)
+	}
+
+	return
+
+}
+
+// Returns the name given to the track by the user, or else the name shown for it by Live.
+func getTrackName(trackElement *Element) (name string) {
+	name = trackElement.ValueAtPath("Name", "UserName")
+	if name == "" {
+		name = trackElement.ValueAtPath("Name", "EffectiveName")
+	}
+	return
+}
+
+func newDeviceFromElement(deviceElement *Element) (device *Device) {
+
+	device = &Device{
+		Type: deviceElement.Name,
+	}
+	device.Id, _ = deviceElement.GetAttr("Id")
+
+	device.Name = deviceElement.ValueAtPath("UserName")
+	if device.Name == "" {
+		device.Name = deviceElement.ValueAtPath("PluginDesc", "VstPluginInfo", "PlugName")
+	}
+	if device.Name == "" {
+		device.Name = deviceElement.ValueAtPath("PluginDesc", "Vst3PluginInfo", "Name")
+	}
+	if device.Name == "" {
+		device.Name = deviceElement.ValueAtPath("PluginDesc", "AuPluginInfo", "Name")
+	}
+	if device.Name == "" {
+		device.Name = device.Type
+	}
+
+	device.ContentHash = getContentHash(deviceElement, "UserName")
+
+	return
+
+}
+
+func getClipsFromMainSequencerElement(mainSequencerElement *Element) (clips []*Clip) {
+
+	clips = make([]*Clip, 0)
+
+	if clipSlotListElement := mainSequencerElement.Child("ClipSlotList"); clipSlotListElement != nil {
+		for slotIndex, clipSlotElement := range clipSlotListElement.Children {
+			clipSlotElement.Walk(func(element *Element) bool {
+				if isOneOf(element.Name, ClipElementNames) {
+					clip := newClipFromElement(element)
+					clip.Key = fmt.Sprintf("session slot %d", slotIndex+1)
+					clips = append(clips, clip)
+					return false
+				}
+				return true
+			})
+		}
+	}
+
+	for _, child := range mainSequencerElement.Children {
+		if child.Name == "ClipSlotList" {
+			continue
+		}
+		child.Walk(func(element *Element) bool {
+			if isOneOf(element.Name, ClipElementNames) {
+				clip := newClipFromElement(element)
+				clipTime, _ := element.GetAttr("Time")
+				clip.Key = "arrangement at beat " + clipTime
+				clips = append(clips, clip)
+				return false
+			}
+			return true
+		})
+	}
+
+	return
+
+}
+
+func newClipFromElement(clipElement *Element) (clip *Clip) {
+
+	clip = &Clip{
+		Type: clipElement.Name,
+		Name: clipElement.ValueAtPath("Name"),
+	}
+
+	clip.ContentHash = getContentHash(clipElement, "Name")
+
+	return
+
+}
+
+// Returns a hash of the given element, without the children with the given names, such as the one containing
+// its name, so that renaming a device or clip isn't also reported as changing its contents.
+func getContentHash(element *Element, ignoredChildNames ...string) string {
+
+	contentElement := &Element{
+		Name:     element.Name,
+		Attrs:    element.Attrs,
+		Children: make([]*Element, 0, len(element.Children)),
+		Text:     element.Text,
+	}
+	for _, child := range element.Children {
+		if !isOneOf(child.Name, ignoredChildNames) {
+			contentElement.Children = append(contentElement.Children, child)
+		}
+	}
+
+	hash := sha1.New()
+	contentElement.Write(hash)
+	return hex.EncodeToString(hash.Sum(nil))
+
+}
+
+// Ableton encodes time signatures as a single integer, where the numerator is stored
+// in the remainder after dividing by 99, and the power-of-two denominator in the quotient.
+func decodeTimeSignature(encodedTimeSignature string) string {
+
+	if encodedTimeSignature == "" {
+		return ""
+	}
+
+	encodedValue, err := strconv.Atoi(encodedTimeSignature)
+	if err != nil || encodedValue < 0 {
+		return encodedTimeSignature
+	}
+
+	numerator := encodedValue%99 + 1
+	denominator := int(math.Pow(2, float64(encodedValue/99)))
+
+	return fmt.Sprintf("%d/%d", numerator, denominator)
+
+}
+
+// Returns the LiveSet element of the given root element of an extracted Ableton Live Set.
+func getLiveSetElement(root *Element) (liveSetElement *Element, err error) {
+	liveSetElement = root.Child("LiveSet")
+	if root.Name != "Ableton" || liveSetElement == nil {
+		err = errors.New("The XML document is not an Ableton Live Set.")
+	}
+	return
+}
+
+func isOneOf(value string, list []string) bool {
+	for _, listValue := range list {
+		if value == listValue {
+			return true
+		}
+	}
+	return false
+}
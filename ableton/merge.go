@@ -0,0 +1,406 @@
+package ableton
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// Names of elements whose "Value" attribute is a counter that only ever increases,
+// such as the next available ID. These are merged by taking the largest value.
+var CounterElementNames = []string{
+	"NextPointeeId",
+}
+
+// Performs a structure-aware three-way merge of two versions of an Ableton XML document,
+// using their common ancestor as the base. Child elements are matched by their name and
+// "Id" attribute (or by their position among siblings with the same name if they have no "Id"),
+// so that changes made to different tracks, devices, or clips can be merged automatically.
+//
+// If the same element was changed differently on both sides, or if elements with the same "Id" were
+// added on both sides, which Live can't load, a description of the conflict is returned, and both
+// versions of the element are written to the merged document between git-style conflict markers.
+func MergeElements(base *Element, ours *Element, theirs *Element) (result *MergeResult) {
+	result = &MergeResult{
+		Conflicts:       make([]string, 0),
+		conflictMarkers: make(map[*Element]*conflictMarker),
+	}
+	result.root = result.mergeElements(base, ours, theirs, ours.Name)
+	return
+}
+
+// ------------------------------------------------------------------------------
+
+type MergeResult struct {
+	Conflicts       []string
+	root            *Element
+	conflictMarkers map[*Element]*conflictMarker // Indexed by the element in the merged document that the conflict replaces.
+}
+
+// Both versions of an element that was changed differently on both sides.
+type conflictMarker struct {
+	ours           *Element // nil if the element was removed on our side.
+	theirs         *Element // nil if the element was removed on their side.
+	isStartTagOnly bool     // True if only the attributes or text of the element conflict, and its children were merged.
+}
+
+// Returns the merged document, including the XML header. If there are conflicts, then the
+// document contains conflict markers, and must be edited before it is valid XML.
+func (result *MergeResult) AsXmlDocument() []byte {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString(XmlHeader + "\n")
+	result.writeIndented(buffer, result.root, 0)
+	return buffer.Bytes()
+}
+
+func (result *MergeResult) writeIndented(buffer *bytes.Buffer, element *Element, depth int) {
+
+	marker, isConflict := result.conflictMarkers[element]
+
+	if isConflict && !marker.isStartTagOnly {
+		buffer.WriteString("<<<<<<< ours\n")
+		if marker.ours != nil {
+			marker.ours.writeIndented(buffer, depth)
+		}
+		buffer.WriteString("=======\n")
+		if marker.theirs != nil {
+			marker.theirs.writeIndented(buffer, depth)
+		}
+		buffer.WriteString(">>>>>>> theirs\n")
+		return
+	}
+
+	if isConflict {
+		buffer.WriteString("<<<<<<< ours\n")
+		marker.ours.writeStartTag(buffer, depth)
+		buffer.WriteString("=======\n")
+		marker.theirs.writeStartTag(buffer, depth)
+		buffer.WriteString(">>>>>>> theirs\n")
+	} else {
+		element.writeStartTag(buffer, depth)
+	}
+
+	if len(element.Children) == 0 {
+		return
+	}
+	for _, child := range element.Children {
+		result.writeIndented(buffer, child, depth+1)
+	}
+	element.writeEndTag(buffer, depth)
+
+}
+
+func (result *MergeResult) mergeElements(base *Element, ours *Element, theirs *Element, path string) *Element {
+
+	if equalElements(ours, theirs) {
+		return ours.Copy()
+	} else if equalElements(base, ours) {
+		return theirs.Copy()
+	} else if equalElements(base, theirs) {
+		return ours.Copy()
+	}
+
+	if ours.Name != theirs.Name {
+		return result.addElementConflict(path, "was replaced with different elements on both sides", ours, theirs)
+	}
+
+	if base == nil {
+		base = NewElement(ours.Name)
+	}
+
+	merged := NewElement(ours.Name)
+
+	var theirAttrs []*Attr
+	var isAttrConflict, isTextConflict bool
+	merged.Attrs, theirAttrs, isAttrConflict = result.mergeAttrs(base, ours, theirs, path)
+	merged.Text, isTextConflict = result.mergeText(base.Text, ours.Text, theirs.Text, path)
+	merged.Children = result.mergeChildren(base, ours, theirs, path)
+
+	if isAttrConflict || isTextConflict {
+		theirStartTag := &Element{Name: merged.Name, Attrs: theirAttrs, Children: merged.Children, Text: merged.Text}
+		if isTextConflict {
+			theirStartTag.Text = theirs.Text
+		}
+		result.conflictMarkers[merged] = &conflictMarker{ours: merged, theirs: theirStartTag, isStartTagOnly: true}
+	}
+
+	return merged
+
+}
+
+// Returns the merged attributes, and the same attributes with their side's values for any that conflict.
+func (result *MergeResult) mergeAttrs(base *Element, ours *Element, theirs *Element, path string) (mergedAttrs []*Attr, theirAttrs []*Attr, isConflict bool) {
+
+	mergedAttrs = make([]*Attr, 0)
+	theirAttrs = make([]*Attr, 0)
+
+	attrNames := make([]string, 0)
+	for _, attr := range ours.Attrs {
+		attrNames = append(attrNames, attr.Name)
+	}
+	for _, attr := range theirs.Attrs {
+		if _, ok := ours.GetAttr(attr.Name); !ok {
+			attrNames = append(attrNames, attr.Name)
+		}
+	}
+
+	for _, attrName := range attrNames {
+
+		baseValue, isInBase := base.GetAttr(attrName)
+		ourValue, isInOurs := ours.GetAttr(attrName)
+		theirValue, isInTheirs := theirs.GetAttr(attrName)
+
+		mergedValue, isInMerged := ourValue, isInOurs
+		theirMergedValue, isInTheirMerged := ourValue, isInOurs
+
+		if isInOurs == isInTheirs && ourValue == theirValue {
+			// Both sides agree.
+		} else if isInOurs == isInBase && ourValue == baseValue {
+			mergedValue, isInMerged = theirValue, isInTheirs
+			theirMergedValue, isInTheirMerged = theirValue, isInTheirs
+		} else if isInTheirs == isInBase && theirValue == baseValue {
+			// Only our side changed.
+		} else if isOneOf(ours.Name, CounterElementNames) {
+			mergedValue = maxNumericString(ourValue, theirValue)
+			theirMergedValue = mergedValue
+		} else {
+			result.addConflict(path+"@"+attrName, fmt.Sprintf("was changed to \"%s\" and \"%s\"", ourValue, theirValue))
+			theirMergedValue, isInTheirMerged = theirValue, isInTheirs
+			isConflict = true
+		}
+
+		if isInMerged {
+			mergedAttrs = append(mergedAttrs, &Attr{Name: attrName, Value: mergedValue})
+		}
+		if isInTheirMerged {
+			theirAttrs = append(theirAttrs, &Attr{Name: attrName, Value: theirMergedValue})
+		}
+
+	}
+
+	return
+
+}
+
+func (result *MergeResult) mergeText(baseText string, ourText string, theirText string, path string) (mergedText string, isConflict bool) {
+	if ourText == theirText || theirText == baseText {
+		return ourText, false
+	} else if ourText == baseText {
+		return theirText, false
+	}
+	result.addConflict(path, "has text content that was changed on both sides")
+	return ourText, true
+}
+
+func (result *MergeResult) mergeChildren(base *Element, ours *Element, theirs *Element, path string) []*Element {
+
+	baseChildren := indexChildrenByKey(base)
+	ourChildren := indexChildrenByKey(ours)
+	theirChildren := indexChildrenByKey(theirs)
+
+	// Elements added on both sides with different names but the same "Id", such as a MIDI track and
+	// an audio track created from the same next available ID, can't both be kept.
+	collidingTheirKeysIndexedByOurKey := make(map[string]string)
+	isCollidingTheirKey := make(map[string]bool)
+	addedOurKeysIndexedById := make(map[string]string)
+	for _, ourKey := range ourChildren.keys {
+		if id, ok := ourChildren.elements[ourKey].GetAttr("Id"); ok && baseChildren.elements[ourKey] == nil {
+			addedOurKeysIndexedById[id] = ourKey
+		}
+	}
+	for _, theirKey := range theirChildren.keys {
+		if baseChildren.elements[theirKey] != nil || ourChildren.elements[theirKey] != nil {
+			continue
+		}
+		if id, ok := theirChildren.elements[theirKey].GetAttr("Id"); ok {
+			if ourKey, isColliding := addedOurKeysIndexedById[id]; isColliding {
+				if _, isAlreadyColliding := collidingTheirKeysIndexedByOurKey[ourKey]; !isAlreadyColliding {
+					collidingTheirKeysIndexedByOurKey[ourKey] = theirKey
+					isCollidingTheirKey[theirKey] = true
+				}
+			}
+		}
+	}
+
+	mergedChildren := make([]*Element, 0)
+
+	appendMergedChild := func(key string) {
+
+		baseChild := baseChildren.elements[key]
+		ourChild := ourChildren.elements[key]
+		theirChild := theirChildren.elements[key]
+		childPath := path + "/" + key
+
+		switch {
+
+		case isCollidingTheirKey[key] && ourChild == nil:
+			// Added to the merged children with the element it collides with.
+
+		case ourChild != nil && theirChild != nil && baseChild == nil && !equalElements(ourChild, theirChild):
+			mergedChildren = append(mergedChildren, result.addElementConflict(childPath, "was added differently on both sides", ourChild, theirChild))
+
+		case ourChild != nil && theirChild != nil:
+			mergedChildren = append(mergedChildren, result.mergeElements(baseChild, ourChild, theirChild, childPath))
+
+		case ourChild != nil && baseChild == nil:
+			if theirKey, isColliding := collidingTheirKeysIndexedByOurKey[key]; isColliding {
+				mergedChildren = append(
+					mergedChildren,
+					result.addElementConflict(childPath, "was added with the same Id as "+theirKey+" on the other side", ourChild, theirChildren.elements[theirKey]),
+				)
+			} else {
+				mergedChildren = append(mergedChildren, ourChild.Copy())
+			}
+
+		case theirChild != nil && baseChild == nil:
+			mergedChildren = append(mergedChildren, theirChild.Copy())
+
+		case ourChild != nil:
+			// Removed on their side.
+			if !equalElements(baseChild, ourChild) {
+				mergedChildren = append(mergedChildren, result.addElementConflict(childPath, "was changed on one side and removed on the other", ourChild, nil))
+			}
+
+		case theirChild != nil:
+			// Removed on our side.
+			if !equalElements(baseChild, theirChild) {
+				mergedChildren = append(mergedChildren, result.addElementConflict(childPath, "was changed on one side and removed on the other", nil, theirChild))
+			}
+
+		}
+
+	}
+
+	// Keep our ordering, inserting elements only found on their side after the element that preceded them.
+	// Elements that only exist in the base were removed on both sides, so they are never visited.
+	isMerged := make(map[string]bool)
+	theirKeysIndexedByPrecedingKey := make(map[string][]string)
+	precedingKey := ""
+	for _, theirKey := range theirChildren.keys {
+		if ourChildren.elements[theirKey] == nil {
+			theirKeysIndexedByPrecedingKey[precedingKey] = append(theirKeysIndexedByPrecedingKey[precedingKey], theirKey)
+		} else {
+			precedingKey = theirKey
+		}
+	}
+
+	appendTheirKeysFollowing := func(precedingKey string) {
+		for _, theirKey := range theirKeysIndexedByPrecedingKey[precedingKey] {
+			if !isMerged[theirKey] {
+				isMerged[theirKey] = true
+				appendMergedChild(theirKey)
+			}
+		}
+	}
+
+	appendTheirKeysFollowing("")
+	for _, ourKey := range ourChildren.keys {
+		isMerged[ourKey] = true
+		appendMergedChild(ourKey)
+		appendTheirKeysFollowing(ourKey)
+	}
+
+	return mergedChildren
+
+}
+
+// Records a conflict between two versions of an element, either of which can be nil if it was removed,
+// and returns the element that stands in for the conflict in the merged document.
+func (result *MergeResult) addElementConflict(path string, description string, ours *Element, theirs *Element) (merged *Element) {
+
+	result.addConflict(path, description)
+
+	marker := &conflictMarker{}
+	if ours != nil {
+		marker.ours = ours.Copy()
+		merged = marker.ours
+	}
+	if theirs != nil {
+		marker.theirs = theirs.Copy()
+		if merged == nil {
+			merged = marker.theirs
+		}
+	}
+
+	result.conflictMarkers[merged] = marker
+	return
+
+}
+
+func (result *MergeResult) addConflict(path string, description string) {
+	result.Conflicts = append(result.Conflicts, path+" "+description)
+}
+
+// ------------------------------------------------------------------------------
+
+type indexedChildren struct {
+	keys     []string
+	elements map[string]*Element
+}
+
+func indexChildrenByKey(element *Element) (children *indexedChildren) {
+
+	children = &indexedChildren{
+		keys:     make([]string, 0),
+		elements: make(map[string]*Element),
+	}
+
+	if element == nil {
+		return
+	}
+
+	occurrencesByName := make(map[string]int)
+	for _, child := range element.Children {
+		var key string
+		if id, ok := child.GetAttr("Id"); ok {
+			key = fmt.Sprintf("%s[Id=%s]", child.Name, id)
+		}
+		if _, isDuplicateKey := children.elements[key]; key == "" || isDuplicateKey {
+			key = fmt.Sprintf("%s[%d]", child.Name, occurrencesByName[child.Name])
+		}
+		occurrencesByName[child.Name]++
+		children.keys = append(children.keys, key)
+		children.elements[key] = child
+	}
+
+	return
+
+}
+
+func equalElements(element1 *Element, element2 *Element) bool {
+
+	if element1 == nil || element2 == nil {
+		return element1 == element2
+	}
+
+	if element1.Name != element2.Name ||
+		element1.Text != element2.Text ||
+		len(element1.Attrs) != len(element2.Attrs) ||
+		len(element1.Children) != len(element2.Children) {
+		return false
+	}
+
+	for i, attr := range element1.Attrs {
+		if *attr != *element2.Attrs[i] {
+			return false
+		}
+	}
+
+	for i, child := range element1.Children {
+		if !equalElements(child, element2.Children[i]) {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+func maxNumericString(value1 string, value2 string) string {
+	number1, err1 := strconv.Atoi(value1)
+	number2, err2 := strconv.Atoi(value2)
+	if err1 != nil || (err2 == nil && number2 > number1) {
+		return value2
+	}
+	return value1
+}
@@ -0,0 +1,132 @@
+package ableton_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/ableton/abletontest"
+	"github.com/stretchr/testify/assert"
+)
+
+var audioTrackXml = `
+			<AudioTrack Id="9">
+				<Name>
+					<EffectiveName Value="Vocals" />
+					<UserName Value="Vocals" />
+				</Name>
+			</AudioTrack>
+		</Tracks>`
+
+func TestMergeElements(t *testing.T) {
+
+	testCases := []*MergeElementsTestCase{
+
+		&MergeElementsTestCase{
+			description: "Test that changes to different tracks on both sides are merged without conflicts.",
+			baseXml:     abletontest.OriginalLiveSetXml,
+			oursXml:     strings.Replace(abletontest.OriginalLiveSetXml, "<UserName Value=\"\" />", "<UserName Value=\"Bass\" />", 1),
+			theirsXml:   strings.Replace(abletontest.OriginalLiveSetXml, "\n\t\t</Tracks>", audioTrackXml, 1),
+			expectedXml: strings.Replace(
+				strings.Replace(abletontest.OriginalLiveSetXml, "<UserName Value=\"\" />", "<UserName Value=\"Bass\" />", 1),
+				"\n\t\t</Tracks>", audioTrackXml, 1,
+			),
+			expectedConflicts: []string{},
+		},
+
+		&MergeElementsTestCase{
+			description: "Test that the same setting changed on both sides is reported as a conflict, and both versions are kept between conflict markers.",
+			baseXml:     abletontest.OriginalLiveSetXml,
+			oursXml:     strings.Replace(abletontest.OriginalLiveSetXml, "<Manual Value=\"120\" />", "<Manual Value=\"128\" />", 1),
+			theirsXml:   strings.Replace(abletontest.OriginalLiveSetXml, "<Manual Value=\"120\" />", "<Manual Value=\"90\" />", 1),
+			expectedXml: strings.Replace(
+				abletontest.OriginalLiveSetXml,
+				"\t\t\t\t\t\t<Manual Value=\"120\" />\n",
+				"<<<<<<< ours\n\t\t\t\t\t\t<Manual Value=\"128\" />\n=======\n\t\t\t\t\t\t<Manual Value=\"90\" />\n>>>>>>> theirs\n",
+				1,
+			),
+			expectedConflicts: []string{"Ableton/LiveSet[0]/MasterTrack[0]/DeviceChain[0]/Mixer[0]/Tempo[0]/Manual[0]@Value was changed to \"128\" and \"90\""},
+		},
+
+		&MergeElementsTestCase{
+			description: "Test that a track changed on one side and removed on the other is reported as a conflict.",
+			baseXml:     strings.Replace(abletontest.OriginalLiveSetXml, "\n\t\t</Tracks>", audioTrackXml, 1),
+			oursXml:     strings.Replace(abletontest.OriginalLiveSetXml, "\n\t\t</Tracks>", strings.Replace(audioTrackXml, "Vocals\" />", "Vox\" />", 1), 1),
+			theirsXml:   abletontest.OriginalLiveSetXml,
+			expectedXml: strings.Replace(
+				abletontest.OriginalLiveSetXml,
+				"\n\t\t</Tracks>",
+				strings.NewReplacer(
+					"\n\t\t\t<AudioTrack", "\n<<<<<<< ours\n\t\t\t<AudioTrack",
+					"</AudioTrack>\n", "</AudioTrack>\n=======\n>>>>>>> theirs\n",
+				).Replace(strings.Replace(audioTrackXml, "Vocals\" />", "Vox\" />", 1)),
+				1,
+			),
+			expectedConflicts: []string{"Ableton/LiveSet[0]/Tracks[0]/AudioTrack[Id=9] was changed on one side and removed on the other"},
+		},
+
+		&MergeElementsTestCase{
+			description: "Test that tracks added on both sides with the same ID are reported as a conflict instead of being merged together.",
+			baseXml:     "<Tracks><MidiTrack Id=\"1\" /></Tracks>",
+			oursXml:     "<Tracks><MidiTrack Id=\"1\" /><MidiTrack Id=\"2\"><Name Value=\"Bass\" /></MidiTrack></Tracks>",
+			theirsXml:   "<Tracks><MidiTrack Id=\"1\" /><MidiTrack Id=\"2\"><Name Value=\"Lead\" /></MidiTrack></Tracks>",
+			expectedXml: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Tracks>\n\t<MidiTrack Id=\"1\" />\n" +
+				"<<<<<<< ours\n\t<MidiTrack Id=\"2\">\n\t\t<Name Value=\"Bass\" />\n\t</MidiTrack>\n" +
+				"=======\n\t<MidiTrack Id=\"2\">\n\t\t<Name Value=\"Lead\" />\n\t</MidiTrack>\n>>>>>>> theirs\n</Tracks>\n",
+			expectedConflicts: []string{"Tracks/MidiTrack[Id=2] was added differently on both sides"},
+		},
+
+		&MergeElementsTestCase{
+			description: "Test that different types of tracks added on both sides with the same ID are reported as a conflict instead of both being kept.",
+			baseXml:     "<Tracks><MidiTrack Id=\"1\" /></Tracks>",
+			oursXml:     "<Tracks><MidiTrack Id=\"1\" /><MidiTrack Id=\"2\" /></Tracks>",
+			theirsXml:   "<Tracks><MidiTrack Id=\"1\" /><AudioTrack Id=\"2\" /></Tracks>",
+			expectedXml: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Tracks>\n\t<MidiTrack Id=\"1\" />\n" +
+				"<<<<<<< ours\n\t<MidiTrack Id=\"2\" />\n=======\n\t<AudioTrack Id=\"2\" />\n>>>>>>> theirs\n</Tracks>\n",
+			expectedConflicts: []string{"Tracks/MidiTrack[Id=2] was added with the same Id as AudioTrack[Id=2] on the other side"},
+		},
+
+		&MergeElementsTestCase{
+			description:       "Test that counters, such as the next available ID, are merged by taking the largest value.",
+			baseXml:           "<Ableton><NextPointeeId Value=\"10\" /></Ableton>",
+			oursXml:           "<Ableton><NextPointeeId Value=\"12\" /></Ableton>",
+			theirsXml:         "<Ableton><NextPointeeId Value=\"15\" /></Ableton>",
+			expectedXml:       "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Ableton>\n\t<NextPointeeId Value=\"15\" />\n</Ableton>\n",
+			expectedConflicts: []string{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+// ------------------------------------------------------------------------------
+
+type MergeElementsTestCase struct {
+	description       string
+	baseXml           string
+	oursXml           string
+	theirsXml         string
+	expectedXml       string
+	expectedConflicts []string
+}
+
+func (testCase *MergeElementsTestCase) Run(t *testing.T) {
+
+	base, err := ableton.ParseElementFromBytes([]byte(testCase.baseXml))
+	assert.Nilf(t, err, testCase.description)
+
+	ours, err := ableton.ParseElementFromBytes([]byte(testCase.oursXml))
+	assert.Nilf(t, err, testCase.description)
+
+	theirs, err := ableton.ParseElementFromBytes([]byte(testCase.theirsXml))
+	assert.Nilf(t, err, testCase.description)
+
+	result := ableton.MergeElements(base, ours, theirs)
+
+	assert.Exactlyf(t, testCase.expectedXml, string(result.AsXmlDocument()), testCase.description)
+	assert.Exactlyf(t, testCase.expectedConflicts, result.Conflicts, testCase.description)
+
+}
@@ -0,0 +1,167 @@
+package ableton
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// The name of the element that replaces each part of a split Live Set.
+var PartReferenceElementName = "MppmPart"
+
+// ------------------------------------------------------------------------------
+
+// A track, return track, or master track that was moved out of a Live Set into a separate file.
+type LiveSetPart struct {
+	FileName string // The slash-separated path of the part's file, relative to the directory of parts.
+	Element  *Element
+}
+
+// Moves each track, return track, and the master track of a Live Set into a separate part,
+// and replaces it with a reference element, so that the history of each track can be followed
+// separately and merge conflicts are limited to the tracks that were changed.
+//
+// Tracks are named "tracks/<track name>.xml", return tracks are named "returns/<track name>.xml",
+// and the master track is named "master.xml". Returns the remaining global settings of the Live Set,
+// which also keep the order of its tracks, and the parts in the same order. The given element is not changed.
+// The original Live Set can be reassembled with JoinLiveSet.
+func SplitLiveSet(root *Element) (globalSettings *Element, parts []*LiveSetPart, err error) {
+
+	globalSettings = root.Copy()
+	liveSetElement, err := getLiveSetElement(globalSettings)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parts = make([]*LiveSetPart, 0)
+	usedFileNames := make(map[string]bool)
+
+	splitPart := func(parent *Element, childIndex int, fileName string) {
+		parts = append(parts, &LiveSetPart{FileName: fileName, Element: parent.Children[childIndex]})
+		reference := NewElement(PartReferenceElementName)
+		reference.SetAttr("File", fileName)
+		parent.Children[childIndex] = reference
+	}
+
+	if tracksElement := liveSetElement.Child("Tracks"); tracksElement != nil {
+		for i, trackElement := range tracksElement.Children {
+			if !isOneOf(trackElement.Name, TrackElementNames) {
+				continue
+			}
+			directoryName := "tracks"
+			if trackElement.Name == "ReturnTrack" {
+				directoryName = "returns"
+			}
+			trackId, _ := trackElement.GetAttr("Id")
+			fileName := getUniquePartFileName(directoryName, getTrackName(trackElement), trackId, usedFileNames)
+			splitPart(tracksElement, i, fileName)
+		}
+	}
+
+	for i, child := range liveSetElement.Children {
+		if isOneOf(child.Name, MasterTrackElementNames) {
+			splitPart(liveSetElement, i, "master.xml")
+		}
+	}
+
+	return
+
+}
+
+// Replaces each part reference in a Live Set that was split by SplitLiveSet with the part
+// returned by readPart, and returns the reassembled Live Set. The given element is not changed.
+func JoinLiveSet(globalSettings *Element, readPart func(fileName string) (*Element, error)) (root *Element, err error) {
+	root = globalSettings.Copy()
+	err = joinParts(root, readPart)
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Returns true if the given XML document contains any part references.
+func HasPartReferences(contents []byte) bool {
+	return bytes.Contains(contents, []byte("<"+PartReferenceElementName+" "))
+}
+
+// ------------------------------------------------------------------------------
+
+func joinParts(element *Element, readPart func(fileName string) (*Element, error)) (err error) {
+
+	for i, child := range element.Children {
+
+		if child.Name != PartReferenceElementName {
+			err = joinParts(child, readPart)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		fileName, _ := child.GetAttr("File")
+		if !isValidPartFileName(fileName) {
+			err = errors.New("The Live Set refers to an invalid part file: '" + fileName + "'.")
+			return
+		}
+
+		var part *Element
+		part, err = readPart(fileName)
+		if err != nil {
+			return
+		}
+		element.Children[i] = part
+
+	}
+
+	return
+
+}
+
+// Returns a file name for the given track that is different from all of the given used file names,
+// and marks it as used. If more than one track has the same name, then the later tracks are numbered.
+func getUniquePartFileName(directoryName string, trackName string, trackId string, usedFileNames map[string]bool) (fileName string) {
+
+	baseName := sanitizePartFileName(trackName)
+	if baseName == "" {
+		baseName = "Track " + trackId
+	}
+
+	fileName = directoryName + "/" + baseName + ".xml"
+	for i := 2; usedFileNames[strings.ToLower(fileName)]; i++ {
+		fileName = fmt.Sprintf("%s/%s (%d).xml", directoryName, baseName, i)
+	}
+
+	// File names are compared case-insensitively, since some file systems are case-insensitive.
+	usedFileNames[strings.ToLower(fileName)] = true
+	return
+
+}
+
+// Replaces all characters in the given track name that aren't safe to use in a file name.
+// Periods are also replaced, so that parts can't be mistaken for files of other types, such as "Bass.als.xml".
+func sanitizePartFileName(trackName string) string {
+	return strings.TrimSpace(
+		strings.Map(
+			func(character rune) rune {
+				if unicode.IsLetter(character) || unicode.IsDigit(character) || strings.ContainsRune(" -_()", character) {
+					return character
+				}
+				return '_'
+			},
+			trackName,
+		),
+	)
+}
+
+// Returns false if the given part file name is absolute or refers to a file outside of the directory of parts.
+func isValidPartFileName(fileName string) bool {
+	cleanFileName := path.Clean(fileName)
+	return fileName != "" &&
+		!path.IsAbs(cleanFileName) &&
+		!strings.Contains(cleanFileName, "\\") &&
+		cleanFileName != ".." &&
+		!strings.HasPrefix(cleanFileName, "../")
+}
@@ -0,0 +1,196 @@
+package ableton_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/ableton/abletontest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitLiveSet(t *testing.T) {
+
+	testCases := []*SplitLiveSetTestCase{
+
+		&SplitLiveSetTestCase{
+			description:               "Test that each track and the master track are moved into separate parts.",
+			xml:                       abletontest.OriginalLiveSetXml,
+			expectedGlobalSettingsXml: abletontest.SplitOriginalLiveSetXml,
+			expectedPartFileNames:     []string{"tracks/1-MIDI.xml", "master.xml"},
+			expectedPartsXml: map[string]string{
+				"tracks/1-MIDI.xml": abletontest.SplitOriginalLiveSetMidiTrackXml,
+				"master.xml":        abletontest.SplitOriginalLiveSetMasterTrackXml,
+			},
+		},
+
+		&SplitLiveSetTestCase{
+			description: "Test that return tracks are moved into their own directory, and that track names are made into unique and safe file names.",
+			xml: `<Ableton>
+	<LiveSet>
+		<Tracks>
+			<AudioTrack Id="1"><Name><EffectiveName Value="Bass" /><UserName Value="" /></Name></AudioTrack>
+			<MidiTrack Id="2"><Name><EffectiveName Value="2-MIDI" /><UserName Value="bass" /></Name></MidiTrack>
+			<GroupTrack Id="3"><Name><EffectiveName Value="Vox/Lead.als" /><UserName Value="" /></Name></GroupTrack>
+			<AudioTrack Id="4"><Name><EffectiveName Value="" /><UserName Value="" /></Name></AudioTrack>
+			<ReturnTrack Id="5"><Name><EffectiveName Value="A-Reverb" /><UserName Value="" /></Name></ReturnTrack>
+		</Tracks>
+		<MainTrack />
+	</LiveSet>
+</Ableton>`,
+			expectedGlobalSettingsXml: `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton>
+	<LiveSet>
+		<Tracks>
+			<MppmPart File="tracks/Bass.xml" />
+			<MppmPart File="tracks/bass (2).xml" />
+			<MppmPart File="tracks/Vox_Lead_als.xml" />
+			<MppmPart File="tracks/Track 4.xml" />
+			<MppmPart File="returns/A-Reverb.xml" />
+		</Tracks>
+		<MppmPart File="master.xml" />
+	</LiveSet>
+</Ableton>
+`,
+			expectedPartFileNames: []string{
+				"tracks/Bass.xml",
+				"tracks/bass (2).xml",
+				"tracks/Vox_Lead_als.xml",
+				"tracks/Track 4.xml",
+				"returns/A-Reverb.xml",
+				"master.xml",
+			},
+		},
+
+		&SplitLiveSetTestCase{
+			description:   "Test that an error is raised if the XML document is not a Live Set.",
+			xml:           "<Song />",
+			expectedError: errors.New("The XML document is not an Ableton Live Set."),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestJoinLiveSet(t *testing.T) {
+
+	testCases := []*JoinLiveSetTestCase{
+
+		&JoinLiveSetTestCase{
+			description:       "Test that a split Live Set is joined back into the original Live Set.",
+			globalSettingsXml: abletontest.SplitOriginalLiveSetXml,
+			partsXml: map[string]string{
+				"tracks/1-MIDI.xml": abletontest.SplitOriginalLiveSetMidiTrackXml,
+				"master.xml":        abletontest.SplitOriginalLiveSetMasterTrackXml,
+			},
+			expectedXml:               abletontest.OriginalLiveSetXml,
+			expectedHasPartReferences: true,
+		},
+
+		&JoinLiveSetTestCase{
+			description:               "Test that an error is raised for part file names outside of the directory of parts.",
+			globalSettingsXml:         `<Ableton><LiveSet><MppmPart File="../secret.xml" /></LiveSet></Ableton>`,
+			partsXml:                  map[string]string{},
+			expectedError:             errors.New("The Live Set refers to an invalid part file: '../secret.xml'."),
+			expectedHasPartReferences: true,
+		},
+
+		&JoinLiveSetTestCase{
+			description:               "Test that any error from reading a part is properly raised.",
+			globalSettingsXml:         abletontest.SplitOriginalLiveSetXml,
+			partsXml:                  map[string]string{},
+			expectedError:             errors.New("Part tracks/1-MIDI.xml does not exist."),
+			expectedHasPartReferences: true,
+		},
+
+		&JoinLiveSetTestCase{
+			description:               "Test that Live Sets that weren't split are not changed.",
+			globalSettingsXml:         abletontest.OriginalLiveSetXml,
+			partsXml:                  map[string]string{},
+			expectedXml:               abletontest.OriginalLiveSetXml,
+			expectedHasPartReferences: false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+// ------------------------------------------------------------------------------
+
+type SplitLiveSetTestCase struct {
+	description               string
+	xml                       string
+	expectedGlobalSettingsXml string
+	expectedPartFileNames     []string
+	expectedPartsXml          map[string]string // Not checked if nil.
+	expectedError             error
+}
+
+func (testCase *SplitLiveSetTestCase) Run(t *testing.T) {
+
+	root, err := ableton.ParseElementFromBytes([]byte(testCase.xml))
+	assert.Nilf(t, err, testCase.description)
+	originalXml := string(root.AsXmlDocument())
+
+	globalSettings, parts, err := ableton.SplitLiveSet(root)
+	assert.Equalf(t, testCase.expectedError, err, testCase.description)
+	assert.Exactlyf(t, originalXml, string(root.AsXmlDocument()), testCase.description)
+	if testCase.expectedError != nil {
+		return
+	}
+
+	assert.Exactlyf(t, testCase.expectedGlobalSettingsXml, string(globalSettings.AsXmlDocument()), testCase.description)
+
+	actualPartFileNames := make([]string, 0, len(parts))
+	actualPartsXml := make(map[string]string)
+	for _, part := range parts {
+		actualPartFileNames = append(actualPartFileNames, part.FileName)
+		actualPartsXml[part.FileName] = string(part.Element.AsXmlDocument())
+	}
+	assert.Exactlyf(t, testCase.expectedPartFileNames, actualPartFileNames, testCase.description)
+	if testCase.expectedPartsXml != nil {
+		assert.Exactlyf(t, testCase.expectedPartsXml, actualPartsXml, testCase.description)
+	}
+
+}
+
+type JoinLiveSetTestCase struct {
+	description               string
+	globalSettingsXml         string
+	partsXml                  map[string]string
+	expectedXml               string
+	expectedHasPartReferences bool
+	expectedError             error
+}
+
+func (testCase *JoinLiveSetTestCase) Run(t *testing.T) {
+
+	assert.Exactlyf(t, testCase.expectedHasPartReferences, ableton.HasPartReferences([]byte(testCase.globalSettingsXml)), testCase.description)
+
+	globalSettings, err := ableton.ParseElementFromBytes([]byte(testCase.globalSettingsXml))
+	assert.Nilf(t, err, testCase.description)
+
+	root, err := ableton.JoinLiveSet(
+		globalSettings,
+		func(fileName string) (*ableton.Element, error) {
+			partXml, ok := testCase.partsXml[fileName]
+			if !ok {
+				return nil, errors.New("Part " + fileName + " does not exist.")
+			}
+			return ableton.ParseElementFromBytes([]byte(partXml))
+		},
+	)
+	assert.Equalf(t, testCase.expectedError, err, testCase.description)
+	if testCase.expectedError != nil {
+		return
+	}
+
+	assert.Exactlyf(t, testCase.expectedXml, string(root.AsXmlDocument()), testCase.description)
+
+}
@@ -0,0 +1,78 @@
+package ableton
+
+import (
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var creatorVersionRegexp = regexp.MustCompile(`^Ableton Live (\d+)`)
+
+// Returns the major version of Ableton Live that saved the given document, such as "11".
+//
+// The version is read from the root element's "MinorVersion" attribute (e.g. "11.0_433"),
+// falling back to its "Creator" attribute (e.g. "Ableton Live 11.3.4").
+// An empty string is returned if neither attribute contains a version.
+func GetMajorVersion(root *Element) (majorVersion string) {
+
+	if root == nil || root.Name != "Ableton" {
+		return
+	}
+
+	if minorVersion, ok := root.GetAttr("MinorVersion"); ok {
+		majorVersion = strings.SplitN(minorVersion, ".", 2)[0]
+		if isNumeric(majorVersion) {
+			return
+		}
+		majorVersion = ""
+	}
+
+	if creator, ok := root.GetAttr("Creator"); ok {
+		if match := creatorVersionRegexp.FindStringSubmatch(creator); match != nil {
+			majorVersion = match[1]
+		}
+	}
+
+	return
+
+}
+
+// Returns the major version of Ableton Live that saved the given XML document, like GetMajorVersion,
+// but only reads up to the document's root element, instead of parsing the whole document.
+func ReadMajorVersion(xmlReader io.Reader) (majorVersion string, err error) {
+
+	decoder := xml.NewDecoder(xmlReader)
+
+	for {
+
+		var token xml.Token
+		token, err = decoder.Token()
+		if err != nil {
+			return
+		}
+
+		if startElement, ok := token.(xml.StartElement); ok {
+			root := NewElement(startElement.Name.Local)
+			for _, attr := range startElement.Attr {
+				root.Attrs = append(root.Attrs, &Attr{Name: attr.Name.Local, Value: attr.Value})
+			}
+			majorVersion = GetMajorVersion(root)
+			return
+		}
+
+	}
+
+}
+
+func isNumeric(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, character := range value {
+		if character < '0' || character > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package ableton_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMajorVersion(t *testing.T) {
+
+	testCases := []*GetMajorVersionTestCase{
+
+		&GetMajorVersionTestCase{
+			description:          "Test that the major version is read from the MinorVersion attribute.",
+			xml:                  "<Ableton MajorVersion=\"5\" MinorVersion=\"11.0_433\" Creator=\"Ableton Live 10.1.9\" />",
+			expectedMajorVersion: "11",
+		},
+
+		&GetMajorVersionTestCase{
+			description:          "Test that the Creator attribute is used if MinorVersion is missing.",
+			xml:                  "<Ableton MajorVersion=\"5\" Creator=\"Ableton Live 12.0.5\" />",
+			expectedMajorVersion: "12",
+		},
+
+		&GetMajorVersionTestCase{
+			description:          "Test that the Creator attribute is used if MinorVersion is not formatted as expected.",
+			xml:                  "<Ableton MinorVersion=\"unknown\" Creator=\"Ableton Live 11.3.4\" />",
+			expectedMajorVersion: "11",
+		},
+
+		&GetMajorVersionTestCase{
+			description:          "Test that an empty string is returned if no version can be found.",
+			xml:                  "<Ableton Creator=\"Some Other Program\" />",
+			expectedMajorVersion: "",
+		},
+
+		&GetMajorVersionTestCase{
+			description:          "Test that an empty string is returned for documents not saved by Ableton.",
+			xml:                  "<Project MinorVersion=\"11.0_433\" />",
+			expectedMajorVersion: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestReadMajorVersion(t *testing.T) {
+
+	// Everything after the root element's start tag is invalid, so reading it would fail.
+	majorVersion, err := ableton.ReadMajorVersion(strings.NewReader("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Ableton MinorVersion=\"12.0_12049\"><</Ableton>"))
+	assert.Nil(t, err)
+	assert.Exactly(t, "12", majorVersion, "Test that the version is read from the root element without reading the rest of the document.")
+
+	_, err = ableton.ReadMajorVersion(strings.NewReader(""))
+	assert.NotNil(t, err, "Test that an error is returned for documents without a root element.")
+
+}
+
+// ------------------------------------------------------------------------------
+
+type GetMajorVersionTestCase struct {
+	description          string
+	xml                  string
+	expectedMajorVersion string
+}
+
+func (testCase *GetMajorVersionTestCase) Run(t *testing.T) {
+
+	root, err := ableton.ParseElementFromBytes([]byte(testCase.xml))
+	assert.Nilf(t, err, testCase.description)
+
+	actualMajorVersion := ableton.GetMajorVersion(root)
+	assert.Exactlyf(t, testCase.expectedMajorVersion, actualMajorVersion, testCase.description)
+
+}
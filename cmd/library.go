@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 	"github.com/stevengt/mppm/config"
 	"github.com/stevengt/mppm/util"
@@ -66,7 +68,7 @@ Libraries can be any collection of audio samples, plugins, presets, etc. that:
 		if isListAllLibrariesCommand {
 			err = listAllLibraries()
 		} else if isCommitAllLibrariesCommand {
-			err = commitAllLibraries()
+			err = snapshotLibraries(nil, DefaultSnapshotMessage, "")
 		} else {
 			cmd.Help()
 		}
@@ -82,6 +84,14 @@ Libraries can be any collection of audio samples, plugins, presets, etc. that:
 		RootCmd.PersistentPostRun(cmd, args)
 		isListAllLibrariesCommand = false
 		isCommitAllLibrariesCommand = false
+		isCheckoutMostRecentLibrariesCommand = false
+		isCheckoutProjectSpecifiedLibrariesCommand = false
+		isCheckoutWithSnapshotCommand = false
+		isCheckoutWithStashCommand = false
+		checkoutTag = ""
+		isCheckoutIntoWorktreesCommand = false
+		snapshotMessage = DefaultSnapshotMessage
+		snapshotTag = ""
 	},
 }
 
@@ -103,43 +113,11 @@ func listAllLibraries() (err error) {
 
 }
 
-func commitAllLibraries() (err error) {
-
-	globalConfig, err := configManager.GetGlobalConfig()
-	if err != nil {
-		return
+// Returns the library in the global config file with the given location, or an error if there isn't one.
+func getGlobalLibrary(globalConfig *config.MppmConfigInfo, libraryFilePath string) (libraryConfig *config.LibraryConfig, err error) {
+	libraryConfig = getLibraryWithFilePath(libraryFilePath, globalConfig.Libraries)
+	if libraryConfig == nil {
+		err = errors.New("'" + libraryFilePath + "' is not a library. To add it, run 'mppm library add " + libraryFilePath + "'.")
 	}
-
-	for _, libraryConfig := range globalConfig.Libraries {
-		err = commitLibrary(libraryConfig)
-		if err != nil {
-			return
-		}
-	}
-
 	return
 }
-
-func commitLibrary(libraryConfig *config.LibraryConfig) (err error) {
-
-	gitManager := util.NewGitManager(libraryConfig.FilePath)
-
-	err = gitManager.AddAllAndCommit("Committed all changes.")
-	if err != nil {
-		return
-	}
-
-	err = libraryConfig.UpdateCurrentGitCommitId()
-	if err != nil {
-		return
-	}
-	libraryConfig.MostRecentGitCommitId = libraryConfig.CurrentGitCommitId
-
-	err = configManager.SaveGlobalConfig()
-	if err != nil {
-		return
-	}
-
-	return
-
-}
@@ -1,7 +1,12 @@
 package cmd
 
 import (
+	"errors"
+	"path/filepath"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
 	"github.com/stevengt/mppm/util"
 )
 
@@ -11,6 +16,10 @@ func init() {
 		func() {
 			isCheckoutMostRecentLibrariesCommand, _ = LibraryCheckoutCmd.Flags().GetBool("recent")
 			isCheckoutProjectSpecifiedLibrariesCommand, _ = LibraryCheckoutCmd.Flags().GetBool("project")
+			isCheckoutWithSnapshotCommand, _ = LibraryCheckoutCmd.Flags().GetBool("snapshot")
+			isCheckoutWithStashCommand, _ = LibraryCheckoutCmd.Flags().GetBool("stash")
+			checkoutTag, _ = LibraryCheckoutCmd.Flags().GetString("tag")
+			isCheckoutIntoWorktreesCommand, _ = LibraryCheckoutCmd.Flags().GetBool("worktree")
 		},
 	)
 
@@ -28,27 +37,93 @@ func init() {
 		"Converts all libraries to the versions specified in the current project's config file.",
 	)
 
+	LibraryCheckoutCmd.Flags().BoolVar(
+		&isCheckoutWithSnapshotCommand,
+		"snapshot",
+		false,
+		"Takes a snapshot of any uncommitted changes to the libraries before checking them out.",
+	)
+
+	LibraryCheckoutCmd.Flags().BoolVar(
+		&isCheckoutWithStashCommand,
+		"stash",
+		false,
+		"Sets aside any uncommitted changes to the libraries with 'git stash' before checking them out.",
+	)
+
+	LibraryCheckoutCmd.Flags().StringVar(
+		&checkoutTag,
+		"tag",
+		"",
+		"Converts all libraries with a snapshot with the given tag to that snapshot.",
+	)
+
+	LibraryCheckoutCmd.Flags().BoolVar(
+		&isCheckoutIntoWorktreesCommand,
+		"worktree",
+		false,
+		`With --project, checks out the project's versions of the libraries into separate worktrees, and changes the
+project to use them, without changing the libraries' own folders.`,
+	)
+
 	LibraryCmd.AddCommand(LibraryCheckoutCmd)
 
 }
 
 var LibraryCheckoutCmd = &cobra.Command{
 
-	Use: "checkout",
+	Use: "checkout [<library> <version> [file...]]",
 
-	Short: "Checks out the latest version of all libraries, or the versions specified for a particular project.",
+	Short: "Checks out the latest version of all libraries, the versions specified for a particular project, or a version of a single library.",
 
-	Long: "Checks out the latest version of all libraries, or the versions specified for a particular project.",
+	Long: `Checks out the latest version of all libraries, the versions specified for a particular project, or a version of a single library.
 
-	Args: cobra.OnlyValidArgs,
+Versions in project config files can either be commit IDs, or the tags of snapshots taken with
+'mppm library snapshot --tag'.
+
+To check out a version of a single library, give its location and the version, which can be a commit ID,
+a tag, or anything else understood by git. To see the versions of a library, run 'mppm library log'.
+
+To restore some files from an older version without checking out the whole library, also give the files'
+locations, either relative to the library's folder or as absolute paths. The restored files replace the
+current ones, and are kept in the library's next snapshot. If the files have changes that haven't been
+snapshotted, they aren't restored, unless --snapshot or --stash is given.
+
+Libraries with changes that haven't been snapshotted aren't checked out, unless --snapshot or --stash is given.
+If any library can't be checked out, the others are returned to the versions they had checked out before.
+Any changes that were already snapshotted or stashed stay that way, and the error says where they are.
+
+Checking out a project's versions of libraries changes the libraries' folders for every other project too.
+To use different versions of a library in different projects at the same time, give --worktree with --project.
+The project's version of each library is then checked out into a worktree in the '` + LibraryWorktreesDirectoryName + `'
+folder in your home folder, and the project's Live Sets are changed to refer to the files in the worktrees.
+Checking out with --project again, without --worktree, changes the Live Sets back to the libraries' own folders.
+Worktrees are only used by this copy of the project. They are recorded in its '.mppm' folder, which isn't committed,
+and the extracted Live Sets keep referring to the libraries' own folders. To remove worktrees that are no longer
+used by any project, run 'mppm library prune'.
+
+The latest version of a library is the one on its branch, which is 'main' or 'master', whichever exists.
+To use a different branch, set the library's "branch" in the global config file.`,
+
+	Args: cobra.ArbitraryArgs,
 
 	Run: func(cmd *cobra.Command, args []string) {
-		if isCheckoutMostRecentLibrariesCommand || isCheckoutProjectSpecifiedLibrariesCommand {
+		if len(args) > 0 || isCheckoutMostRecentLibrariesCommand || isCheckoutProjectSpecifiedLibrariesCommand || checkoutTag != "" || isCheckoutIntoWorktreesCommand {
 			var err error
-			if isCheckoutMostRecentLibrariesCommand {
+			if isCheckoutIntoWorktreesCommand && !isCheckoutProjectSpecifiedLibrariesCommand {
+				err = errors.New("--worktree can only be given with --project.")
+			} else if len(args) == 1 {
+				err = errors.New("A version of the library '" + args[0] + "' must be given. To see the versions of the library, run 'mppm library log " + args[0] + "'.")
+			} else if len(args) > 1 {
+				err = checkoutLibrary(args[0], args[1], args[2:]...)
+			} else if isCheckoutMostRecentLibrariesCommand {
 				err = checkoutMostRecentLibraries()
+			} else if isCheckoutProjectSpecifiedLibrariesCommand && isCheckoutIntoWorktreesCommand {
+				err = checkoutProjectSpecifiedLibraryWorktrees()
 			} else if isCheckoutProjectSpecifiedLibrariesCommand {
 				err = checkoutProjectSpecifiedLibraries()
+			} else if checkoutTag != "" {
+				err = checkoutTaggedLibraries(checkoutTag)
 			}
 			if err != nil {
 				util.ExitWithError(err)
@@ -61,6 +136,13 @@ var LibraryCheckoutCmd = &cobra.Command{
 
 var isCheckoutMostRecentLibrariesCommand bool
 var isCheckoutProjectSpecifiedLibrariesCommand bool
+var isCheckoutWithSnapshotCommand bool
+var isCheckoutWithStashCommand bool
+var checkoutTag string
+var isCheckoutIntoWorktreesCommand bool
+
+var checkoutSnapshotMessage = "Committed all changes before checking out another version."
+var checkoutStashMessage = "Changes set aside by 'mppm library checkout'."
 
 func checkoutMostRecentLibraries() (err error) {
 
@@ -70,68 +152,431 @@ func checkoutMostRecentLibraries() (err error) {
 	}
 	libraryConfigList := globalConfig.Libraries
 
+	checkouts := make([]*libraryCheckout, 0, len(libraryConfigList))
 	for _, libraryConfig := range libraryConfigList {
+		var branchName string
+		branchName, err = libraryConfig.GetBranchName()
+		if err != nil {
+			return
+		}
+		checkouts = append(checkouts, &libraryCheckout{LibraryConfig: libraryConfig, Version: branchName})
+	}
+
+	if len(checkouts) == 0 {
+		return
+	}
+
+	err = checkoutLibraries(checkouts)
+	if err != nil {
+		return
+	}
+
+	for _, libraryConfig := range libraryConfigList {
+		libraryConfig.CurrentGitCommitId = libraryConfig.MostRecentGitCommitId
+	}
+
+	err = configManager.SaveGlobalConfig()
+	return
+
+}
+
+func checkoutProjectSpecifiedLibraries() (err error) {
+
+	projectConfig, globalConfig, err := configManager.GetProjectAndGlobalConfigs()
+	if err != nil {
+		return
+	}
+	libraryProjectConfigList := projectConfig.Libraries
+	libraryGlobalConfigList := globalConfig.Libraries
+
+	checkouts := make([]*libraryCheckout, 0, len(libraryProjectConfigList))
+	checkedOutLibraryProjectConfigs := make([]*config.LibraryConfig, 0, len(libraryProjectConfigList))
+
+	for _, libraryProjectConfig := range libraryProjectConfigList {
+		for _, libraryGlobalConfig := range libraryGlobalConfigList {
+			if libraryProjectConfig.FilePath == libraryGlobalConfig.FilePath {
+				checkouts = append(checkouts, &libraryCheckout{LibraryConfig: libraryGlobalConfig, Version: libraryProjectConfig.CurrentGitCommitId})
+				checkedOutLibraryProjectConfigs = append(checkedOutLibraryProjectConfigs, libraryProjectConfig)
+			}
+		}
+	}
+
+	if len(checkouts) == 0 {
+		return
+	}
+
+	// Check that the project's Live Sets can be changed back from any worktrees before checking anything out,
+	// so that the libraries aren't left checked out if they can't be.
+	worktrees, err := config.LoadProjectLibraryWorktrees()
+	if err != nil {
+		return
+	}
+	newWorktrees := worktrees.Copy()
+	for _, libraryProjectConfig := range checkedOutLibraryProjectConfigs {
+		newWorktrees.Set(libraryProjectConfig.FilePath, "")
+	}
+
+	worktreesRelink, err := prepareProjectLibraryWorktreesRelink(worktrees, newWorktrees)
+	if err != nil {
+		return
+	}
+
+	err = checkoutLibraries(checkouts)
+	if err != nil {
+		return
+	}
+
+	if relinkErr := worktreesRelink.Apply(); relinkErr != nil {
+		err = rollBackLibraryCheckouts(
+			checkouts,
+			len(checkouts),
+			"Unable to change the project's Live Sets to refer to the libraries' own folders: "+strings.TrimSpace(relinkErr.Error()),
+		)
+		return
+	}
+
+	for i, checkout := range checkouts {
+		libraryProjectConfig := checkedOutLibraryProjectConfigs[i]
+		libraryProjectConfig.MostRecentGitCommitId = checkout.LibraryConfig.MostRecentGitCommitId
+		checkout.LibraryConfig.CurrentGitCommitId = checkout.LibraryConfig.GetGitCommitId(libraryProjectConfig.CurrentGitCommitId)
+	}
+
+	err = configManager.SaveGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	err = configManager.SaveProjectConfig()
+	return
+
+}
+
+func checkoutTaggedLibraries(tag string) (err error) {
+
+	globalConfig, err := configManager.GetGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	checkouts := make([]*libraryCheckout, 0, len(globalConfig.Libraries))
+	for _, libraryConfig := range globalConfig.Libraries {
+		if _, ok := libraryConfig.Tags[tag]; ok {
+			checkouts = append(checkouts, &libraryCheckout{LibraryConfig: libraryConfig, Version: tag})
+		}
+	}
+
+	if len(checkouts) == 0 {
+		err = errors.New("None of the libraries have a snapshot tagged '" + tag + "'. To see the tags of each library, run 'mppm library --list'.")
+		return
+	}
+
+	err = checkoutLibraries(checkouts)
+	if err != nil {
+		return
+	}
+
+	for _, checkout := range checkouts {
+		checkout.LibraryConfig.CurrentGitCommitId = checkout.LibraryConfig.Tags[tag]
+	}
+
+	err = configManager.SaveGlobalConfig()
+	return
+
+}
+
+// Checks out the given version of a single library. If any files are given, only those files are restored
+// from the version, and the rest of the library is left as it is.
+func checkoutLibrary(libraryFilePath string, version string, fileNames ...string) (err error) {
+
+	globalConfig, err := configManager.GetGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	libraryConfig, err := getGlobalLibrary(globalConfig, libraryFilePath)
+	if err != nil {
+		return
+	}
+
+	libraryFileNames := make([]string, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		var libraryFileName string
+		libraryFileName, err = getLibraryFileName(libraryConfig, fileName)
+		if err != nil {
+			return
+		}
+		libraryFileNames = append(libraryFileNames, libraryFileName)
+	}
+
+	if len(libraryFileNames) > 0 {
 
 		gitManager := util.NewGitManager(libraryConfig.FilePath)
 
-		err = gitManager.Checkout("master")
+		// Restoring the files would overwrite any uncommitted changes to them, so they are set aside first,
+		// the same way as when checking out the whole library.
+		var statusStdout string
+		statusStdout, err = gitManager.Status(append([]string{"--porcelain", "-z", "--"}, libraryFileNames...)...)
 		if err != nil {
 			return
 		}
 
-		libraryConfig.CurrentGitCommitId = libraryConfig.MostRecentGitCommitId
-		err = configManager.SaveGlobalConfig()
+		if statusStdout != "" {
+			checkout := &libraryCheckout{LibraryConfig: libraryConfig, Version: version}
+			checkout.PreviousRevision, checkout.IsPreviousRevisionBranch, err = getCheckedOutLibraryRevision(gitManager)
+			if err != nil {
+				return
+			}
+			err = setAsideUncommittedLibraryChanges([]*libraryCheckout{checkout})
+			if err != nil {
+				return
+			}
+		}
+
+		err = gitManager.Checkout(append([]string{version, "--"}, libraryFileNames...)...)
 		if err != nil {
 			return
 		}
 
+		for _, libraryFileName := range libraryFileNames {
+			util.Println("Restored " + libraryFileName + " from version " + version + " of " + libraryConfig.FilePath + ".")
+		}
+		util.Println("To keep the restored files, run 'mppm library snapshot " + libraryConfig.FilePath + "'.")
+
+		return
+
+	}
+
+	err = checkoutLibraries([]*libraryCheckout{&libraryCheckout{LibraryConfig: libraryConfig, Version: version}})
+	if err != nil {
+		return
 	}
 
+	err = libraryConfig.UpdateCurrentGitCommitId()
+	if err != nil {
+		return
+	}
+
+	err = configManager.SaveGlobalConfig()
 	return
 
 }
 
-func checkoutProjectSpecifiedLibraries() (err error) {
+// Returns the location of the given file relative to the library's folder. Relative locations are assumed to
+// already be relative to the library's folder.
+func getLibraryFileName(libraryConfig *config.LibraryConfig, fileName string) (libraryFileName string, err error) {
 
-	projectConfig, globalConfig, err := configManager.GetProjectAndGlobalConfigs()
+	if !filepath.IsAbs(fileName) {
+		libraryFileName = filepath.ToSlash(filepath.Clean(fileName))
+		return
+	}
+
+	libraryFileName, err = filepath.Rel(filepath.Clean(libraryConfig.FilePath), filepath.Clean(fileName))
+	if err != nil || libraryFileName == ".." || strings.HasPrefix(libraryFileName, ".."+string(filepath.Separator)) {
+		err = errors.New("'" + fileName + "' is not in the library '" + libraryConfig.FilePath + "'.")
+		return
+	}
+
+	libraryFileName = filepath.ToSlash(libraryFileName)
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+// A version of a library to check out.
+type libraryCheckout struct {
+	LibraryConfig *config.LibraryConfig
+	Version       string // The version to check out, which can be anything understood by git, such as a commit ID, tag, or branch.
+
+	// The branch or commit ID that was checked out before, and whether it is a branch.
+	PreviousRevision         string
+	IsPreviousRevisionBranch bool
+
+	// How any uncommitted changes were set aside before checking out: stashed, or snapshotted at SnapshotGitCommitId.
+	WereChangesStashed  bool
+	SnapshotGitCommitId string
+}
+
+// Checks out the given versions of libraries, either all of them or none of them.
+//
+// If any of the libraries have uncommitted changes, nothing is checked out, unless --snapshot or --stash
+// was given to snapshot or stash the changes first. If a library can't be checked out, the libraries that
+// were already checked out are returned to the versions they were at before.
+func checkoutLibraries(checkouts []*libraryCheckout) (err error) {
+
+	if isCheckoutWithSnapshotCommand && isCheckoutWithStashCommand {
+		err = errors.New("Only one of --snapshot and --stash can be given.")
+		return
+	}
+
+	changedCheckouts := make([]*libraryCheckout, 0)
+
+	for _, checkout := range checkouts {
+
+		gitManager := util.NewGitManager(checkout.LibraryConfig.FilePath)
+
+		checkout.PreviousRevision, checkout.IsPreviousRevisionBranch, err = getCheckedOutLibraryRevision(gitManager)
+		if err != nil {
+			return
+		}
+
+		var statusStdout string
+		statusStdout, err = gitManager.Status("--porcelain")
+		if err != nil {
+			return
+		}
+
+		if strings.TrimSpace(statusStdout) != "" {
+			changedCheckouts = append(changedCheckouts, checkout)
+		}
+
+	}
+
+	err = setAsideUncommittedLibraryChanges(changedCheckouts)
 	if err != nil {
 		return
 	}
-	libraryProjectConfigList := projectConfig.Libraries
-	libraryGlobalConfigList := globalConfig.Libraries
 
-	for _, libraryProjectConfig := range libraryProjectConfigList {
+	for i, checkout := range checkouts {
+		gitManager := util.NewGitManager(checkout.LibraryConfig.FilePath)
+		if checkoutErr := gitManager.Checkout(checkout.Version); checkoutErr != nil {
+			err = rollBackLibraryCheckouts(
+				checkouts,
+				i,
+				"Unable to check out version "+checkout.Version+" of the library '"+checkout.LibraryConfig.FilePath+"': "+strings.TrimSpace(checkoutErr.Error()),
+			)
+			return
+		}
+	}
 
-		for _, libraryGlobalConfig := range libraryGlobalConfigList {
+	return
 
-			if libraryProjectConfig.FilePath == libraryGlobalConfig.FilePath {
+}
 
-				libraryProjectConfig.MostRecentGitCommitId = libraryGlobalConfig.MostRecentGitCommitId
-				libraryGlobalConfig.CurrentGitCommitId = libraryProjectConfig.CurrentGitCommitId
+// Returns the name of the branch that the library has checked out, or its commit ID if it isn't on a branch.
+func getCheckedOutLibraryRevision(gitManager util.GitManager) (revision string, isBranch bool, err error) {
 
-				gitManager := util.NewGitManager(libraryProjectConfig.FilePath)
+	revision, err = gitManager.RevParse("--abbrev-ref", "HEAD")
+	if err != nil {
+		return
+	}
+	revision = strings.TrimSpace(revision)
+
+	if revision != "HEAD" {
+		isBranch = true
+		return
+	}
+
+	revision, err = gitManager.RevParse("HEAD")
+	revision = strings.TrimSpace(revision)
+	return
+
+}
+
+// Snapshots or stashes the uncommitted changes to the given libraries, depending on whether --snapshot or --stash
+// was given. If neither was given, an error is returned instead.
+func setAsideUncommittedLibraryChanges(checkouts []*libraryCheckout) (err error) {
+
+	if len(checkouts) == 0 {
+		return
+	}
+
+	libraryFilePaths := make([]string, 0, len(checkouts))
+	for _, checkout := range checkouts {
+		libraryFilePaths = append(libraryFilePaths, checkout.LibraryConfig.FilePath)
+	}
 
-				err = gitManager.Checkout(libraryProjectConfig.CurrentGitCommitId)
-				if err != nil {
-					return
-				}
+	if !isCheckoutWithSnapshotCommand && !isCheckoutWithStashCommand {
+		err = errors.New(
+			"Unable to check out because these libraries have changes that haven't been snapshotted: " + strings.Join(libraryFilePaths, ", ") + ". " +
+				"To take a snapshot of the changes first, run again with --snapshot. To set them aside with 'git stash' instead, run again with --stash.",
+		)
+		return
+	}
 
-				err = configManager.SaveGlobalConfig()
-				if err != nil {
-					return
-				}
+	if isCheckoutWithSnapshotCommand {
 
-				err = configManager.SaveProjectConfig()
-				if err != nil {
-					return
-				}
+		// Snapshots taken while an older version is checked out wouldn't be on the library's branch, so they could be lost.
+		for _, checkout := range checkouts {
+			if !checkout.IsPreviousRevisionBranch {
+				err = errors.New(
+					"Unable to take a snapshot of the changes to the library '" + checkout.LibraryConfig.FilePath + "' because it has an older version checked out. " +
+						"To set the changes aside with 'git stash' instead, run again with --stash.",
+				)
+				return
+			}
+		}
 
+		for _, checkout := range checkouts {
+			err = snapshotLibrary(checkout.LibraryConfig, checkoutSnapshotMessage, "")
+			if err != nil {
+				err = errors.New(strings.TrimSpace(err.Error()) + describeSetAsideLibraryChanges(checkouts))
+				return
 			}
+			checkout.SnapshotGitCommitId = checkout.LibraryConfig.CurrentGitCommitId
+		}
+
+		return
 
+	}
+
+	for _, checkout := range checkouts {
+
+		gitManager := util.NewGitManager(checkout.LibraryConfig.FilePath)
+
+		err = gitManager.Stash("push", "--include-untracked", "-m", checkoutStashMessage)
+		if err != nil {
+			err = errors.New(strings.TrimSpace(err.Error()) + describeSetAsideLibraryChanges(checkouts))
+			return
 		}
+		checkout.WereChangesStashed = true
+
+		util.Println("Set aside the changes to " + checkout.LibraryConfig.FilePath + ". To get them back, run 'git -C " + checkout.LibraryConfig.FilePath + " stash pop'.")
 
 	}
 
 	return
 
 }
+
+// Returns the first numberOfCompletedCheckouts of the given checkouts to the versions they had checked out before,
+// after the rest of the checkout failed with the given error message. The returned error describes the failure,
+// any libraries that couldn't be returned to their versions, and where any uncommitted changes were set aside,
+// since they aren't put back.
+func rollBackLibraryCheckouts(checkouts []*libraryCheckout, numberOfCompletedCheckouts int, errorMessage string) (err error) {
+
+	rollBackErrorMessages := make([]string, 0)
+	for i := numberOfCompletedCheckouts - 1; i >= 0; i-- {
+		checkout := checkouts[i]
+		gitManager := util.NewGitManager(checkout.LibraryConfig.FilePath)
+		if rollBackErr := gitManager.Checkout(checkout.PreviousRevision); rollBackErr != nil {
+			rollBackErrorMessages = append(
+				rollBackErrorMessages,
+				"Unable to return the library '"+checkout.LibraryConfig.FilePath+"' to version "+checkout.PreviousRevision+": "+strings.TrimSpace(rollBackErr.Error()),
+			)
+		}
+	}
+
+	if numberOfCompletedCheckouts > 0 && len(rollBackErrorMessages) == 0 {
+		errorMessage += "\nThe libraries that were already checked out were returned to their previous versions."
+	} else if len(rollBackErrorMessages) > 0 {
+		errorMessage += "\n" + strings.Join(rollBackErrorMessages, "\n")
+	}
+
+	err = errors.New(errorMessage + describeSetAsideLibraryChanges(checkouts))
+	return
+
+}
+
+// Returns a line for each library whose changes have already been stashed or snapshotted, so that they can
+// be found again after an error.
+func describeSetAsideLibraryChanges(checkouts []*libraryCheckout) (description string) {
+	for _, checkout := range checkouts {
+		if checkout.WereChangesStashed {
+			description += "\nThe changes to " + checkout.LibraryConfig.FilePath + " are still set aside. To get them back, run 'git -C " + checkout.LibraryConfig.FilePath + " stash pop'."
+		} else if checkout.SnapshotGitCommitId != "" {
+			description += "\nThe changes to " + checkout.LibraryConfig.FilePath + " were kept in a snapshot at version " + checkout.SnapshotGitCommitId + "."
+		}
+	}
+	return
+}
@@ -0,0 +1,330 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	LibraryCmd.AddCommand(LibraryDiffCmd)
+}
+
+var LibraryDiffCmd = &cobra.Command{
+
+	Use: "diff <library> <version-1> <version-2>",
+
+	Short: "Lists the samples, presets, and other files that were added, removed, or modified between two versions of a library.",
+
+	Long: `Lists the samples, presets, and other files that were added, removed, or modified between two versions of a library.
+
+Versions can be commit IDs, the tags of snapshots taken with 'mppm library snapshot --tag',
+or anything else understood by git, such as 'HEAD~1'. To see the versions of a library, run 'mppm library log'.`,
+
+	Args: cobra.ExactArgs(3),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := diffLibraryVersions(args[0], args[1], args[2]); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func diffLibraryVersions(libraryFilePath string, version1 string, version2 string) (err error) {
+
+	globalConfig, err := configManager.GetGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	libraryConfig, err := getGlobalLibrary(globalConfig, libraryFilePath)
+	if err != nil {
+		return
+	}
+
+	gitManager := util.NewGitManager(libraryConfig.FilePath)
+	fileChanges, err := getLibraryFileChanges(
+		gitManager,
+		libraryConfig.GetGitCommitId(version1),
+		libraryConfig.GetGitCommitId(version2),
+	)
+	if err != nil {
+		return
+	}
+
+	if len(fileChanges) == 0 {
+		util.Println("No files changed between versions " + version1 + " and " + version2 + " of " + libraryConfig.FilePath + ".")
+		return
+	}
+
+	addedLines := make([]string, 0)
+	removedLines := make([]string, 0)
+	modifiedLines := make([]string, 0)
+
+	for _, fileChange := range fileChanges {
+		switch fileChange.Status {
+		case "A":
+			addedLines = append(addedLines, fileChange.FileName+" ("+getFileSizeDescription(fileChange.NewSize)+")")
+		case "D":
+			removedLines = append(removedLines, fileChange.FileName+" ("+getFileSizeDescription(fileChange.OldSize)+")")
+		default:
+			modifiedLines = append(modifiedLines, fileChange.FileName+" ("+getFileSizeDeltaDescription(fileChange.GetSizeDelta())+")")
+		}
+	}
+
+	printProjectStatusSection("Added:", addedLines)
+	printProjectStatusSection("Removed:", removedLines)
+	printProjectStatusSection("Modified:", modifiedLines)
+
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+// The largest size of a git lfs pointer file. Files stored in git that are larger than this are read as they are.
+var gitLfsPointerMaxSize int64 = 1024
+
+type libraryFileChange struct {
+	FileName  string
+	Status    string // The type of change, as shown by 'git diff-tree', such as "A" (added), "D" (deleted), or "M" (modified).
+	OldBlobId string
+	NewBlobId string
+	OldSize   int64
+	NewSize   int64
+}
+
+func (fileChange *libraryFileChange) GetSizeDelta() int64 {
+	return fileChange.NewSize - fileChange.OldSize
+}
+
+// Returns the files that were changed between the given revisions of a library, sorted by name.
+// If only one revision is given, it is compared to its parent, or to an empty library if it is the first commit.
+func getLibraryFileChanges(gitManager util.GitManager, revisions ...string) (fileChanges []*libraryFileChange, err error) {
+
+	diffTreeArgs := append([]string{"-r", "-z", "--no-commit-id", "--root"}, revisions...)
+	stdout, err := gitManager.DiffTree(diffTreeArgs...)
+	if err != nil {
+		return
+	}
+
+	fileChanges = make([]*libraryFileChange, 0)
+
+	tokens := strings.Split(stdout, "\x00")
+	for i := 0; i+1 < len(tokens); i += 2 {
+		if fileChange, ok := newLibraryFileChange(tokens[i], tokens[i+1]); ok {
+			fileChanges = append(fileChanges, fileChange)
+		}
+	}
+
+	err = setLibraryFileChangeSizes(gitManager, fileChanges)
+	if err != nil {
+		return
+	}
+
+	sortLibraryFileChanges(fileChanges)
+	return
+
+}
+
+// Returns the change described by a record from 'git diff-tree -z' or 'git log --raw -z', which is written as
+// ":<old mode> <new mode> <old blob> <new blob> <status>", then a NUL, then the file name.
+// Returns false if the record isn't in that form.
+func newLibraryFileChange(record string, fileName string) (fileChange *libraryFileChange, ok bool) {
+
+	fields := strings.Fields(strings.TrimPrefix(record, ":"))
+	if !strings.HasPrefix(record, ":") || len(fields) != 5 {
+		return
+	}
+
+	fileChange = &libraryFileChange{
+		FileName:  fileName,
+		Status:    fields[4][:1],
+		OldBlobId: fields[2],
+		NewBlobId: fields[3],
+	}
+	ok = true
+	return
+
+}
+
+func sortLibraryFileChanges(fileChanges []*libraryFileChange) {
+	sort.Slice(
+		fileChanges,
+		func(i, j int) bool {
+			return fileChanges[i].FileName < fileChanges[j].FileName
+		},
+	)
+}
+
+// Sets the old and new sizes of the given changed files, reading all of their blobs at once.
+func setLibraryFileChangeSizes(gitManager util.GitManager, fileChanges []*libraryFileChange) (err error) {
+
+	blobIds := make([]string, 0)
+	isBlobIdAdded := make(map[string]bool)
+	for _, fileChange := range fileChanges {
+		for _, blobId := range []string{fileChange.OldBlobId, fileChange.NewBlobId} {
+			// Blobs of files that don't exist on one side of a change are all zeros.
+			if strings.Trim(blobId, "0") != "" && !isBlobIdAdded[blobId] {
+				isBlobIdAdded[blobId] = true
+				blobIds = append(blobIds, blobId)
+			}
+		}
+	}
+
+	sizesIndexedByBlobId, err := getLibraryBlobSizes(gitManager, blobIds)
+	if err != nil {
+		return
+	}
+
+	for _, fileChange := range fileChanges {
+		fileChange.OldSize = sizesIndexedByBlobId[fileChange.OldBlobId]
+		fileChange.NewSize = sizesIndexedByBlobId[fileChange.NewBlobId]
+	}
+
+	return
+
+}
+
+// Returns the sizes of the files stored in the given git blobs, indexed by blob ID. For files tracked by git lfs,
+// this is the size of the actual file, rather than the size of its pointer. Libraries can have thousands of files,
+// so the sizes are read by one 'git cat-file --batch-check', and only blobs small enough to be git lfs pointers
+// are read by one 'git cat-file --batch'.
+func getLibraryBlobSizes(gitManager util.GitManager, blobIds []string) (sizesIndexedByBlobId map[string]int64, err error) {
+
+	sizesIndexedByBlobId = make(map[string]int64)
+	if len(blobIds) == 0 {
+		return
+	}
+
+	stdout, err := gitManager.CatFileBatch(blobIds, "--batch-check")
+	if err != nil {
+		return
+	}
+
+	// Each blob is written as "<blob> <type> <size>", or as "<blob> missing" if it doesn't exist.
+	possibleGitLfsPointerBlobIds := make([]string, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(stdout)), "\n") {
+
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			err = errors.New("Unable to read the git object '" + strings.TrimSuffix(line, " missing") + "'.")
+			return
+		}
+
+		var size int64
+		size, err = strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return
+		}
+
+		sizesIndexedByBlobId[fields[0]] = size
+		if size <= gitLfsPointerMaxSize {
+			possibleGitLfsPointerBlobIds = append(possibleGitLfsPointerBlobIds, fields[0])
+		}
+
+	}
+
+	if len(possibleGitLfsPointerBlobIds) == 0 {
+		return
+	}
+
+	stdout, err = gitManager.CatFileBatch(possibleGitLfsPointerBlobIds, "--batch")
+	if err != nil {
+		return
+	}
+
+	// Each blob is written as "<blob> <type> <size>", then a newline, then its contents, then another newline.
+	for len(stdout) > 0 {
+
+		headerLength := bytes.IndexByte(stdout, '\n')
+		if headerLength < 0 {
+			break
+		}
+
+		fields := strings.Fields(string(stdout[:headerLength]))
+		if len(fields) != 3 {
+			err = errors.New("Unable to read the git object '" + string(stdout[:headerLength]) + "'.")
+			return
+		}
+
+		var size int
+		size, err = strconv.Atoi(fields[2])
+		if err != nil {
+			return
+		}
+
+		contentsStart := headerLength + 1
+		contentsEnd := contentsStart + size
+		if contentsEnd > len(stdout) {
+			err = errors.New("Unable to read the git object '" + fields[0] + "'.")
+			return
+		}
+
+		if gitLfsFileSize, ok := getGitLfsPointerFileSize(string(stdout[contentsStart:contentsEnd])); ok {
+			sizesIndexedByBlobId[fields[0]] = gitLfsFileSize
+		}
+
+		stdout = stdout[contentsEnd:]
+		if len(stdout) > 0 {
+			stdout = stdout[1:]
+		}
+
+	}
+
+	return
+
+}
+
+// Returns the size recorded in the given git lfs pointer file, or false if the contents aren't a pointer file.
+func getGitLfsPointerFileSize(contents string) (size int64, ok bool) {
+
+	if !strings.HasPrefix(contents, "version https://git-lfs.github.com/spec/") {
+		return
+	}
+
+	for _, line := range strings.Split(contents, "\n") {
+		if sizeAsString := strings.TrimPrefix(line, "size "); sizeAsString != line {
+			var err error
+			size, err = strconv.ParseInt(strings.TrimSpace(sizeAsString), 10, 64)
+			ok = err == nil
+			return
+		}
+	}
+
+	return
+
+}
+
+// Returns the given number of bytes in a human-readable form, such as "512 B" or "1.5 MB".
+func getFileSizeDescription(size int64) string {
+
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+
+	scaledSize := float64(size)
+	unitIndex := 0
+	for scaledSize >= 1024 && unitIndex < len(units)-1 {
+		scaledSize /= 1024
+		unitIndex++
+	}
+
+	if unitIndex == 0 {
+		return fmt.Sprintf("%d %s", size, units[unitIndex])
+	}
+	return fmt.Sprintf("%.1f %s", scaledSize, units[unitIndex])
+
+}
+
+// Returns the given change in bytes in a human-readable form, such as "+512 B" or "-1.5 MB".
+func getFileSizeDeltaDescription(sizeDelta int64) string {
+	if sizeDelta < 0 {
+		return "-" + getFileSizeDescription(-sizeDelta)
+	}
+	return "+" + getFileSizeDescription(sizeDelta)
+}
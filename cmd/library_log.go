@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	LibraryCmd.AddCommand(LibraryLogCmd)
+}
+
+var LibraryLogCmd = &cobra.Command{
+
+	Use: "log <library>",
+
+	Short: "Lists the snapshots of a library, with their dates, messages, and the files they changed.",
+
+	Long: `Lists the snapshots of a library, newest first, with their dates, messages, and tags,
+the number of files they changed, and how much they changed the size of the library by.
+
+Snapshots are listed up to the library's most recent version, even if an older version is checked out.`,
+
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := logLibrarySnapshots(args[0]); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func logLibrarySnapshots(libraryFilePath string) (err error) {
+
+	globalConfig, err := configManager.GetGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	libraryConfig, err := getGlobalLibrary(globalConfig, libraryFilePath)
+	if err != nil {
+		return
+	}
+
+	mostRecentRevision := libraryConfig.MostRecentGitCommitId
+	if mostRecentRevision == "" {
+		mostRecentRevision = "HEAD"
+	}
+
+	// With --raw and -z, each snapshot is written as "<commit>\t<date>\t<message>", then a NUL, then the files it changed,
+	// as they are written by 'git diff-tree -z'. The first file is preceded by a newline.
+	gitManager := util.NewGitManager(libraryConfig.FilePath)
+	stdout, err := gitManager.Log(
+		"--raw",
+		"-z",
+		"--no-abbrev",
+		"--no-renames",
+		"--root",
+		"--format=%H%x09%ad%x09%s",
+		"--date=format:%Y-%m-%d %H:%M",
+		mostRecentRevision,
+	)
+	if err != nil {
+		return
+	}
+
+	snapshots := make([]*librarySnapshot, 0)
+	allFileChanges := make([]*libraryFileChange, 0)
+
+	tokens := strings.Split(stdout, "\x00")
+	for i := 0; i < len(tokens); i++ {
+
+		token := strings.TrimPrefix(tokens[i], "\n")
+
+		if strings.HasPrefix(token, ":") {
+			if len(snapshots) == 0 || i+1 >= len(tokens) {
+				continue
+			}
+			if fileChange, ok := newLibraryFileChange(token, tokens[i+1]); ok {
+				snapshot := snapshots[len(snapshots)-1]
+				snapshot.FileChanges = append(snapshot.FileChanges, fileChange)
+				allFileChanges = append(allFileChanges, fileChange)
+			}
+			i++
+			continue
+		}
+
+		fields := strings.SplitN(token, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		snapshots = append(
+			snapshots,
+			&librarySnapshot{
+				GitCommitId: fields[0],
+				Date:        fields[1],
+				Message:     fields[2],
+				FileChanges: make([]*libraryFileChange, 0),
+			},
+		)
+
+	}
+
+	err = setLibraryFileChangeSizes(gitManager, allFileChanges)
+	if err != nil {
+		return
+	}
+
+	tagsIndexedByGitCommitId := make(map[string][]string)
+	for _, tag := range libraryConfig.GetSortedTags() {
+		gitCommitId := libraryConfig.Tags[tag]
+		tagsIndexedByGitCommitId[gitCommitId] = append(tagsIndexedByGitCommitId[gitCommitId], tag)
+	}
+
+	for _, snapshot := range snapshots {
+
+		var sizeDelta int64
+		for _, fileChange := range snapshot.FileChanges {
+			sizeDelta += fileChange.GetSizeDelta()
+		}
+
+		heading := snapshot.GitCommitId + "  " + snapshot.Date
+		if tags, ok := tagsIndexedByGitCommitId[snapshot.GitCommitId]; ok {
+			heading += "  (tagged '" + strings.Join(tags, "', '") + "')"
+		}
+
+		util.Println(heading)
+		util.Println("\t" + snapshot.Message)
+		util.Println("\t" + getFileCountDescription(len(snapshot.FileChanges)) + " changed, " + getFileSizeDeltaDescription(sizeDelta))
+
+	}
+
+	return
+
+}
+
+type librarySnapshot struct {
+	GitCommitId string
+	Date        string
+	Message     string
+	FileChanges []*libraryFileChange
+}
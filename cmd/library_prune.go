@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	LibraryCmd.AddCommand(LibraryPruneCmd)
+}
+
+var LibraryPruneCmd = &cobra.Command{
+
+	Use: "prune",
+
+	Short: "Removes the library worktrees that are no longer used by any project.",
+
+	Long: `Removes the library worktrees that are no longer used by any project.
+
+Worktrees are created in the '` + LibraryWorktreesDirectoryName + `' folder in your home folder by
+'mppm library checkout --project --worktree'. A worktree is kept as long as a project that checked it out
+still uses it. Worktrees of projects that have been moved or deleted, or that have checked out a different
+version of the library since, are removed, along with any changes made in them.`,
+
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := pruneLibraryWorktrees(); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func pruneLibraryWorktrees() (err error) {
+
+	registry, err := loadLibraryWorktreeRegistry()
+	if err != nil {
+		return
+	}
+
+	removedWorktreeFilePaths := make([]string, 0)
+	prunedLibraryFilePaths := make([]string, 0)
+
+	for _, worktreeFilePath := range registry.GetSortedWorktreeFilePaths() {
+
+		entry := registry.Worktrees[worktreeFilePath]
+
+		var isUsed bool
+		isUsed, err = isLibraryWorktreeUsed(worktreeFilePath, entry)
+		if err != nil {
+			return
+		}
+		if isUsed {
+			continue
+		}
+
+		if util.DoesFileExist(worktreeFilePath) {
+			// --force is needed since Live writes analysis files next to samples, which git sees as changes.
+			gitManager := util.NewGitManager(entry.LibraryFilePath)
+			err = gitManager.Worktree("remove", "--force", worktreeFilePath)
+			if err != nil {
+				return
+			}
+		}
+
+		delete(registry.Worktrees, worktreeFilePath)
+		removedWorktreeFilePaths = append(removedWorktreeFilePaths, worktreeFilePath)
+		prunedLibraryFilePaths = appendIfMissing(prunedLibraryFilePaths, entry.LibraryFilePath)
+
+	}
+
+	// Cleans up git's records of any worktrees that were deleted without git knowing about it.
+	for _, libraryFilePath := range prunedLibraryFilePaths {
+		if !util.DoesFileExist(libraryFilePath) {
+			continue
+		}
+		gitManager := util.NewGitManager(libraryFilePath)
+		err = gitManager.Worktree("prune")
+		if err != nil {
+			return
+		}
+	}
+
+	if len(removedWorktreeFilePaths) == 0 {
+		util.Println("No worktrees were removed, since they are all used by projects.")
+		return
+	}
+
+	err = registry.Save()
+	if err != nil {
+		return
+	}
+
+	printProjectStatusSection("Removed worktrees:", removedWorktreeFilePaths)
+	return
+
+}
+
+// Returns true if any of the projects that have used the worktree still have the library checked out into it.
+func isLibraryWorktreeUsed(worktreeFilePath string, entry *config.LibraryWorktreeRegistryEntry) (isUsed bool, err error) {
+
+	for _, projectDirectoryName := range entry.ProjectDirectoryNames {
+
+		if !util.DoesFileExist(projectDirectoryName) {
+			continue
+		}
+
+		var worktrees *config.ProjectLibraryWorktrees
+		worktrees, err = config.LoadProjectLibraryWorktreesFromDirectory(projectDirectoryName)
+		if err != nil {
+			return
+		}
+
+		if worktrees.Get(entry.LibraryFilePath) == worktreeFilePath {
+			isUsed = true
+			return
+		}
+
+	}
+
+	return
+
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+
+	cobra.OnInitialize(
+		func() {
+			snapshotMessage, _ = LibrarySnapshotCmd.Flags().GetString("message")
+			snapshotTag, _ = LibrarySnapshotCmd.Flags().GetString("tag")
+		},
+	)
+
+	LibrarySnapshotCmd.Flags().StringVarP(
+		&snapshotMessage,
+		"message",
+		"m",
+		DefaultSnapshotMessage,
+		"The message to commit the changes with.",
+	)
+
+	LibrarySnapshotCmd.Flags().StringVarP(
+		&snapshotTag,
+		"tag",
+		"t",
+		"",
+		`A name for the snapshot, such as 'v2026-10-drums', which can be used in place of its version
+with 'mppm library checkout' and in project config files.`,
+	)
+
+	LibraryCmd.AddCommand(LibrarySnapshotCmd)
+
+}
+
+var DefaultSnapshotMessage = "Committed all changes."
+
+var snapshotMessage string
+var snapshotTag string
+
+var LibrarySnapshotCmd = &cobra.Command{
+
+	Use: "snapshot [library...]",
+
+	Short: "Commits (snapshots) all changes made to libraries, with a message and an optional tag.",
+
+	Long: `Commits (snapshots) all changes made to libraries, with a message and an optional tag.
+
+By default, a snapshot is taken of every library in the global config file. To only take snapshots
+of some libraries, give their locations. Libraries without any changes are not committed again,
+but their current versions are still tagged.
+
+Tags are created in each library's git repository, and recorded in the global config file.`,
+
+	Args: cobra.ArbitraryArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := snapshotLibraries(args, snapshotMessage, snapshotTag); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func snapshotLibraries(libraryFilePaths []string, message string, tag string) (err error) {
+
+	globalConfig, err := configManager.GetGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	libraryConfigs := globalConfig.Libraries
+	if len(libraryFilePaths) > 0 {
+		libraryConfigs = make([]*config.LibraryConfig, 0, len(libraryFilePaths))
+		for _, libraryFilePath := range libraryFilePaths {
+			var libraryConfig *config.LibraryConfig
+			libraryConfig, err = getGlobalLibrary(globalConfig, libraryFilePath)
+			if err != nil {
+				return
+			}
+			libraryConfigs = append(libraryConfigs, libraryConfig)
+		}
+	}
+
+	// Check every library, including tags that were created outside of mppm, before committing anything,
+	// so that a tag which is already in use, or a library with an older version checked out, doesn't leave
+	// only some of the libraries snapshotted.
+	for _, libraryConfig := range libraryConfigs {
+		if tag != "" && isLibraryTagged(libraryConfig, tag) {
+			err = errors.New("The library '" + libraryConfig.FilePath + "' already has a snapshot tagged '" + tag + "'.")
+			return
+		}
+		_, err = getLibrarySnapshotBranchName(libraryConfig)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, libraryConfig := range libraryConfigs {
+		err = snapshotLibrary(libraryConfig, message, tag)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+
+}
+
+// Returns true if the tag is recorded for the library in the global config file, or already exists in its git repository.
+func isLibraryTagged(libraryConfig *config.LibraryConfig, tag string) bool {
+	if _, ok := libraryConfig.Tags[tag]; ok {
+		return true
+	}
+	gitManager := util.NewGitManager(libraryConfig.FilePath)
+	_, err := gitManager.RevParse("--verify", "--quiet", "refs/tags/"+tag)
+	return err == nil
+}
+
+// Returns the name of the branch that the library has checked out, which snapshots of it are committed to, or an
+// error if it has an older version checked out instead, since snapshots of that wouldn't be on any branch.
+func getLibrarySnapshotBranchName(libraryConfig *config.LibraryConfig) (branchName string, err error) {
+
+	gitManager := util.NewGitManager(libraryConfig.FilePath)
+
+	revision, isBranch, err := getCheckedOutLibraryRevision(gitManager)
+	if err != nil {
+		return
+	}
+
+	if !isBranch {
+		err = errors.New(
+			"Unable to take a snapshot of the library '" + libraryConfig.FilePath + "' because it has an older version (" + revision + ") checked out. " +
+				"To check out its most recent version first, run 'mppm library checkout --recent'.",
+		)
+		return
+	}
+
+	branchName = revision
+	return
+
+}
+
+// Commits all changes to the given library, unless there are none, tags the resulting version if a tag is given,
+// and records it in the global config file. The library's most recent version is only changed if the snapshot
+// is on the branch that its snapshots are usually committed to.
+func snapshotLibrary(libraryConfig *config.LibraryConfig, message string, tag string) (err error) {
+
+	gitManager := util.NewGitManager(libraryConfig.FilePath)
+
+	checkedOutBranchName, err := getLibrarySnapshotBranchName(libraryConfig)
+	if err != nil {
+		return
+	}
+
+	branchName, err := libraryConfig.GetBranchName()
+	if err != nil {
+		return
+	}
+
+	err = gitManager.Add("-A", ".")
+	if err != nil {
+		return
+	}
+
+	changedFileNames, err := gitManager.Diff("--cached", "--name-only")
+	if err != nil {
+		return
+	}
+
+	hasChanges := strings.TrimSpace(changedFileNames) != ""
+	if hasChanges {
+		err = gitManager.Commit("-m", message)
+		if err != nil {
+			return
+		}
+	}
+
+	err = libraryConfig.UpdateCurrentGitCommitId()
+	if err != nil {
+		return
+	}
+	if checkedOutBranchName == branchName {
+		libraryConfig.MostRecentGitCommitId = libraryConfig.CurrentGitCommitId
+	}
+
+	summary := libraryConfig.FilePath + " has no changes since version " + libraryConfig.CurrentGitCommitId
+	if hasChanges {
+		summary = "Took a snapshot of " + libraryConfig.FilePath + " at version " + libraryConfig.CurrentGitCommitId
+	}
+
+	if tag != "" {
+		err = gitManager.Tag("-a", tag, "-m", message)
+		if err != nil {
+			return
+		}
+		libraryConfig.AddTag(tag, libraryConfig.CurrentGitCommitId)
+		summary += ", tagged '" + tag + "'"
+	}
+
+	err = configManager.SaveGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	util.Println(summary + ".")
+	return
+
+}
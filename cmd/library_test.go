@@ -1,8 +1,12 @@
 package cmd_test
 
 import (
+	"errors"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/stevengt/mppm/config"
 	"github.com/stevengt/mppm/config/configtest"
 
 	"github.com/stevengt/mppm/cmd"
@@ -11,59 +15,1249 @@ import (
 
 func TestLibraryCmd(t *testing.T) {
 
+	// Worktrees record the absolute locations of the projects that use them.
+	projectDirectoryName, _ := filepath.Abs(".")
+
 	testCases := []*LibraryCmdTestCase{
 
 		&LibraryCmdTestCase{
-			description: "Test that all libraries in the global config file are displayed.",
-			args:        []string{"library", "--list"},
+			description: "Test that all libraries in the global config file are displayed.",
+			args:        []string{"library", "--list"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("\n/home/testuser/library\n\tmost-recent-version=\"56789\"\n\tcurrent-version=\"56789\"\n\n"),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that changes to all libraries are committed with git, and that the global config file is updated.",
+			args:        []string{"library", "--commit-all"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetDiffStdout("Drums/kick.wav\n").
+						SetRevParseStdout("56789").
+						SetRevParseStdouts(
+							map[string]string{
+								"--abbrev-ref HEAD": "main\n",
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "--verify", "--quiet", "refs/heads/main"},
+							[]string{"add", "-A", "."},
+							[]string{"diff", "--cached", "--name-only"},
+							[]string{"commit", "-m", "Committed all changes."},
+							[]string{"rev-parse", "HEAD"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Took a snapshot of /home/testuser/library at version 56789.\n"),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that a snapshot of all libraries is committed with the given message and tagged, and that the tag is recorded in the global config file.",
+			args:        []string{"library", "snapshot", "--message", "Add drums", "--tag", "v1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetDiffStdout("Drums/kick.wav\n").
+						SetRevParseStdout("56789\n").
+						SetRevParseStdouts(
+							map[string]string{
+								"--abbrev-ref HEAD": "main\n",
+							},
+						).
+						SetRevParseErrors(
+							map[string]error{
+								"--verify --quiet refs/tags/v1": utiltest.DefaultRevParseError,
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--verify", "--quiet", "refs/tags/v1"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "--verify", "--quiet", "refs/heads/main"},
+							[]string{"add", "-A", "."},
+							[]string{"diff", "--cached", "--name-only"},
+							[]string{"commit", "-m", "Add drums"},
+							[]string{"rev-parse", "HEAD"},
+							[]string{"tag", "-a", "v1", "-m", "Add drums"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Took a snapshot of /home/testuser/library at version 56789, tagged 'v1'.\n"),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that libraries without changes are tagged without being committed.",
+			args:        []string{"library", "snapshot", "/home/testuser/library", "-t", "v1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("56789\n").
+						SetRevParseStdouts(
+							map[string]string{
+								"--abbrev-ref HEAD": "main\n",
+							},
+						).
+						SetRevParseErrors(
+							map[string]error{
+								"--verify --quiet refs/tags/v1": utiltest.DefaultRevParseError,
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--verify", "--quiet", "refs/tags/v1"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "--verify", "--quiet", "refs/heads/main"},
+							[]string{"add", "-A", "."},
+							[]string{"diff", "--cached", "--name-only"},
+							[]string{"rev-parse", "HEAD"},
+							[]string{"tag", "-a", "v1", "-m", "Committed all changes."},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("/home/testuser/library has no changes since version 56789, tagged 'v1'.\n"),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that nothing is committed if a library already has a snapshot with the given tag.",
+			args:        []string{"library", "snapshot", "-t", "v1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("The library '/home/testuser/library' already has a snapshot tagged 'v1'.")),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that nothing is committed if a library's git repository already has the given tag.",
+			args:        []string{"library", "snapshot", "-t", "v1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--verify", "--quiet", "refs/tags/v1"},
+						},
+					},
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("The library '/home/testuser/library' already has a snapshot tagged 'v1'.")),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that nothing is committed if a library has an older version checked out, so that the snapshot would not be on its branch.",
+			args:        []string{"library", "snapshot"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("01234\n").
+						SetRevParseStdouts(
+							map[string]string{
+								"--abbrev-ref HEAD": "HEAD\n",
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "HEAD"},
+						},
+					},
+				).
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New(
+						"Unable to take a snapshot of the library '/home/testuser/library' because it has an older version (01234) checked out. " +
+							"To check out its most recent version first, run 'mppm library checkout --recent'.",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that a snapshot taken on a branch other than the library's own branch doesn't change its most recent version.",
+			args:        []string{"library", "snapshot"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetDiffStdout("Drums/kick.wav\n").
+						SetRevParseStdout("01234\n").
+						SetRevParseStdouts(
+							map[string]string{
+								"--abbrev-ref HEAD": "experiments\n",
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"rev-parse", "--verify", "--quiet", "refs/heads/main"},
+							[]string{"add", "-A", "."},
+							[]string{"diff", "--cached", "--name-only"},
+							[]string{"commit", "-m", "Committed all changes."},
+							[]string{"rev-parse", "HEAD"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Took a snapshot of /home/testuser/library at version 01234.\n"),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that libraries with a snapshot with the given tag are checked out to it.",
+			args:        []string{"library", "checkout", "--tag", "v1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"checkout", "v1"},
+						},
+					},
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that an error is raised if no libraries have a snapshot with the given tag.",
+			args:        []string{"library", "checkout", "--tag", "v2"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("None of the libraries have a snapshot tagged 'v2'. To see the tags of each library, run 'mppm library --list'.")),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that the snapshots of a library are listed with the number of files they changed and the sizes of git lfs files.",
+			args:        []string{"library", "log", "/home/testuser/library"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					getLibraryHistoryMockGitManagerCreatorBuilder().
+						SetLogStdout(
+							"56789\t2026-10-01 12:00\tAdd drums\x00\n" +
+								":100644 100644 aaaaa bbbbb M\x00Drums/hat.wav\x00:000000 100644 0000000 ccccc A\x00Drums/kick.wav\x00" +
+								"12345\t2026-09-01 09:30\tInitial commit.\x00\n" +
+								":000000 100644 0000000 aaaaa A\x00Drums/hat.wav\x00",
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"log", "--raw", "-z", "--no-abbrev", "--no-renames", "--root", "--format=%H%x09%ad%x09%s", "--date=format:%Y-%m-%d %H:%M", "56789"},
+							[]string{"cat-file", "--batch-check", "aaaaa", "bbbbb", "ccccc"},
+							[]string{"cat-file", "--batch", "aaaaa", "bbbbb"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"56789  2026-10-01 12:00  (tagged 'v1')\n" +
+							"\tAdd drums\n" +
+							"\t2 files changed, +1.9 MB\n" +
+							"12345  2026-09-01 09:30\n" +
+							"\tInitial commit.\n" +
+							"\t1 file changed, +1.0 KB\n",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that the files added, removed, and modified between two versions of a library are listed, and that tags are used as versions.",
+			args:        []string{"library", "diff", "/home/testuser/library", "12345", "v1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					getLibraryHistoryMockGitManagerCreatorBuilder(),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"diff-tree", "-r", "-z", "--no-commit-id", "--root", "12345", "56789"},
+							[]string{"cat-file", "--batch-check", "ddddd", "aaaaa", "bbbbb", "ccccc"},
+							[]string{"cat-file", "--batch", "aaaaa", "bbbbb"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Added:\n" +
+							"\tDrums/kick.wav (1.9 MB)\n" +
+							"Removed:\n" +
+							"\t.DS_Store (6.0 KB)\n" +
+							"Modified:\n" +
+							"\tDrums/hat.wav (+1.0 KB)\n",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that an error is raised when comparing versions of a folder that isn't a library.",
+			args:        []string{"library", "diff", "/home/testuser/other", "12345", "56789"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("'/home/testuser/other' is not a library. To add it, run 'mppm library add /home/testuser/other'.")),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that a single library is checked out to the given version.",
+			args:        []string{"library", "checkout", "/home/testuser/library", "HEAD~1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("01234\n"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"checkout", "HEAD~1"},
+							[]string{"rev-parse", "HEAD"},
+						},
+					},
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that files are restored from the given version of a library without checking out the whole library.",
+			args:        []string{"library", "checkout", "/home/testuser/library", "v1", "Drums/kick.wav", "/home/testuser/library/Drums/hat.wav"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"status", "--porcelain", "-z", "--", "Drums/kick.wav", "Drums/hat.wav"},
+							[]string{"checkout", "v1", "--", "Drums/kick.wav", "Drums/hat.wav"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Restored Drums/kick.wav from version v1 of /home/testuser/library.\n" +
+							"Restored Drums/hat.wav from version v1 of /home/testuser/library.\n" +
+							"To keep the restored files, run 'mppm library snapshot /home/testuser/library'.\n",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that files with uncommitted changes aren't restored from another version of a library.",
+			args:        []string{"library", "checkout", "/home/testuser/library", "v1", "Drums/kick.wav"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("main\n").
+						SetStatusStdout(" M Drums/kick.wav\x00"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"status", "--porcelain", "-z", "--", "Drums/kick.wav"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+						},
+					},
+				).
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New(
+						"Unable to check out because these libraries have changes that haven't been snapshotted: /home/testuser/library. " +
+							"To take a snapshot of the changes first, run again with --snapshot. To set them aside with 'git stash' instead, run again with --stash.",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that uncommitted changes to files are stashed before restoring them from another version of a library if --stash is given.",
+			args:        []string{"library", "checkout", "/home/testuser/library", "v1", "Drums/kick.wav", "--stash"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("main\n").
+						SetStatusStdout(" M Drums/kick.wav\x00"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"status", "--porcelain", "-z", "--", "Drums/kick.wav"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+							[]string{"checkout", "v1", "--", "Drums/kick.wav"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Set aside the changes to /home/testuser/library. To get them back, run 'git -C /home/testuser/library stash pop'.\n" +
+							"Restored Drums/kick.wav from version v1 of /home/testuser/library.\n" +
+							"To keep the restored files, run 'mppm library snapshot /home/testuser/library'.\n",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that nothing is restored if a file is outside of the library.",
+			args:        []string{"library", "checkout", "/home/testuser/library", "v1", "/home/testuser/Downloads/kick.wav"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("'/home/testuser/Downloads/kick.wav' is not in the library '/home/testuser/library'.")),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that libraries are checked out to their most recent versions on 'master' if they don't have a 'main' branch.",
+			args:        []string{"library", "checkout", "--recent"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseErrors(
+							map[string]error{
+								"--verify --quiet refs/heads/main": errors.New("exit status 1"),
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--verify", "--quiet", "refs/heads/main"},
+							[]string{"rev-parse", "--verify", "--quiet", "refs/heads/master"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"checkout", "master"},
+						},
+					},
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that libraries with uncommitted changes aren't checked out.",
+			args:        []string{"library", "checkout", "--tag", "v1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("main\n").
+						SetStatusStdout("?? Drums/kick.wav\n"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+						},
+					},
+				).
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New(
+						"Unable to check out because these libraries have changes that haven't been snapshotted: /home/testuser/library. " +
+							"To take a snapshot of the changes first, run again with --snapshot. To set them aside with 'git stash' instead, run again with --stash.",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that uncommitted changes to libraries are stashed before checking them out if --stash is given.",
+			args:        []string{"library", "checkout", "--tag", "v1", "--stash"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("main\n").
+						SetStatusStdout("?? Drums/kick.wav\n"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTaggedLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+							[]string{"checkout", "v1"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Set aside the changes to /home/testuser/library. To get them back, run 'git -C /home/testuser/library stash pop'.\n"),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that libraries that were already checked out are returned to their previous versions if another library can't be checked out.",
+			args:        []string{"library", "checkout", "--project"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTwoPreviousLibraryVersions.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndTwoLibraries.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("main\n").
+						SetCheckoutErrors(
+							map[string]error{
+								"43210": errors.New("error: pathspec '43210' did not match any file(s) known to git"),
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTwoPreviousLibraryVersions.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndTwoLibraries.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"checkout", "01234"},
+							[]string{"checkout", "43210"},
+							[]string{"checkout", "main"},
+						},
+						"/home/testuser/other-library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"checkout", "01234"},
+							[]string{"checkout", "43210"},
+							[]string{"checkout", "main"},
+						},
+					},
+				).
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New(
+						"Unable to check out version 43210 of the library '/home/testuser/other-library': error: pathspec '43210' did not match any file(s) known to git\n" +
+							"The libraries that were already checked out were returned to their previous versions.",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that the error lists the libraries whose changes were already stashed if stashing the changes to a later library fails.",
+			args:        []string{"library", "checkout", "--project", "--stash"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTwoPreviousLibraryVersions.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndTwoLibraries.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("main\n").
+						SetStatusStdout(" M Drums/hat.wav\n").
+						SetStashErrors([]error{nil, utiltest.DefaultStashError}),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTwoPreviousLibraryVersions.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndTwoLibraries.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+						},
+						"/home/testuser/other-library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Set aside the changes to /home/testuser/library. To get them back, run 'git -C /home/testuser/library stash pop'.\n"),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New(
+						utiltest.DefaultStashError.Error() + "\n" +
+							"The changes to /home/testuser/library are still set aside. To get them back, run 'git -C /home/testuser/library stash pop'.",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that the rollback error lists the libraries whose changes were stashed before checking out.",
+			args:        []string{"library", "checkout", "--project", "--stash"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndTwoPreviousLibraryVersions.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndTwoLibraries.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("main\n").
+						SetStatusStdout(" M Drums/hat.wav\n").
+						SetCheckoutErrors(
+							map[string]error{
+								"43210": errors.New("error: pathspec '43210' did not match any file(s) known to git"),
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndTwoPreviousLibraryVersions.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndTwoLibraries.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+							[]string{"checkout", "01234"},
+							[]string{"checkout", "43210"},
+							[]string{"checkout", "main"},
+						},
+						"/home/testuser/other-library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+							[]string{"stash", "push", "--include-untracked", "-m", "Changes set aside by 'mppm library checkout'."},
+							[]string{"checkout", "01234"},
+							[]string{"checkout", "43210"},
+							[]string{"checkout", "main"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Set aside the changes to /home/testuser/library. To get them back, run 'git -C /home/testuser/library stash pop'.\n" +
+							"Set aside the changes to /home/testuser/other-library. To get them back, run 'git -C /home/testuser/other-library stash pop'.\n",
+					),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New(
+						"Unable to check out version 43210 of the library '/home/testuser/other-library': error: pathspec '43210' did not match any file(s) known to git\n" +
+							"The libraries that were already checked out were returned to their previous versions.\n" +
+							"The changes to /home/testuser/library are still set aside. To get them back, run 'git -C /home/testuser/library stash pop'.\n" +
+							"The changes to /home/testuser/other-library are still set aside. To get them back, run 'git -C /home/testuser/other-library stash pop'.",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that no libraries are checked out with --project if changing the project's Live Sets back from worktrees would overwrite changes that haven't been extracted.",
+			args:        []string{"library", "checkout", "--project"},
 			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
 				SetMockFileSystemDelegaterBuilder(
 					utiltest.NewMockFileSystemDelegaterBuilder().
 						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
 							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
 								SetFilePath("/home/testuser/.mppm.json"),
+							getLibraryWorktreesFileBuilder(),
+							getAbletonLiveSetWithExternalFilesFileBuilder().
+								SetContentsFromBytes(utiltest.GetGzippedContents(changedAbletonLiveSetWithLibraryWorktreeFilesContents)),
+							getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
 						),
 				),
 			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
 				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
 					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
 						SetFilePath("/home/testuser/.mppm.json").
 						SetWasClosed(true),
+					getLibraryWorktreesFileBuilder().
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(changedAbletonLiveSetWithLibraryWorktreeFilesContents)).
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
 				).
-				SetWritePrinterOutputContents(
-					[]byte("\n/home/testuser/library\n\tmost-recent-version=\"56789\"\n\tcurrent-version=\"56789\"\n\n"),
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New(
+						"Changing the files that these Live Sets refer to would overwrite changes that have not been extracted:\n" +
+							"\tsong.als\n" +
+							"To keep the changes, run 'mppm project extract' first, and then try again.",
+					),
 				),
 		},
 
 		&LibraryCmdTestCase{
-			description: "Test that changes to all libraries are committed with git, and that the global config file is updated.",
-			args:        []string{"library", "--commit-all"},
+			description: "Test that the project's Live Sets are changed back from worktrees to the libraries' own folders when its libraries are checked out with --project.",
+			args:        []string{"library", "checkout", "--project"},
 			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
 				SetMockFileSystemDelegaterBuilder(
 					utiltest.NewMockFileSystemDelegaterBuilder().
 						SetMockFileBuilders(
 							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
 								SetFilePath("/home/testuser/.mppm.json"),
+							getLibraryWorktreesFileBuilder(),
+							getAbletonLiveSetWithExternalFilesFileBuilder().
+								SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithLibraryWorktreeFilesContents)),
+							getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
 						),
 				).
 				SetMockGitManagerCreatorBuilder(
 					utiltest.NewMockGitManagerCreatorBuilder().
-						SetRevParseStdout("56789"),
+						SetRevParseStdout("main\n"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					configtest.GetProjectLibraryWorktreesFileBuilder(map[string]string{}).
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						getAbletonLiveSetWithExternalFilesFileBuilder(),
+						getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"rev-parse", "--abbrev-ref", "HEAD"},
+							[]string{"status", "--porcelain"},
+							[]string{"checkout", "01234"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Restored song.als\n"),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that an error is raised if --worktree is given without --project.",
+			args:        []string{"library", "checkout", "--worktree"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json"),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("--worktree can only be given with --project.")),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that the project's libraries are checked out into worktrees, that only its binary Live Sets are relinked to the files in the worktrees, and that the worktrees are only recorded locally.",
+			args:        []string{"library", "checkout", "--project", "--worktree"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							getAbletonLiveSetWithExternalFilesFileBuilder(),
+							getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
+						),
 				),
 			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
 				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
 					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
 						SetFilePath("/home/testuser/.mppm.json").
 						SetWasClosed(true),
+					getLibraryWorktreesFileBuilder().
+						SetWasClosed(true),
+					configtest.GetLibraryWorktreeRegistryFileBuilder(
+						map[string]*config.LibraryWorktreeRegistryEntry{
+							"/home/testuser/.mppm-worktrees/library-9b546a0c/01234": &config.LibraryWorktreeRegistryEntry{
+								LibraryFilePath:       "/home/testuser/library",
+								ProjectDirectoryNames: []string{projectDirectoryName},
+							},
+						},
+					).
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithLibraryWorktreeFilesContents)).
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						getAbletonLiveSetWithExternalFilesFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithLibraryWorktreeFilesContents)),
+						getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
 				).
 				SetGitManagerInputHistoriesIndexedByRepoPath(
 					map[string][][]string{
 						"/home/testuser/library": [][]string{
-							[]string{"add", "-A", "."},
-							[]string{"commit", "-m", "Committed all changes."},
-							[]string{"rev-parse", "HEAD"},
+							[]string{"worktree", "add", "--force", "--detach", "/home/testuser/.mppm-worktrees/library-9b546a0c/01234", "01234"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"[1/1] Restored song.als\n" +
+							"Checked out libraries into worktrees:\n" +
+							"\t/home/testuser/library at version 01234 -> /home/testuser/.mppm-worktrees/library-9b546a0c/01234\n",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that only the absolute paths of references to library files are relinked to worktrees, so that their relative paths are restored and extracted unchanged.",
+			args:        []string{"library", "checkout", "--project", "--worktree"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							getAbletonLiveSetWithExternalFilesFileBuilder().
+								SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithRelativeLibraryFilesContents)),
+							getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+								SetContentsFromString(abletonLiveSetWithRelativeLibraryFilesContents),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					getLibraryWorktreesFileBuilder().
+						SetWasClosed(true),
+					configtest.GetLibraryWorktreeRegistryFileBuilder(
+						map[string]*config.LibraryWorktreeRegistryEntry{
+							"/home/testuser/.mppm-worktrees/library-9b546a0c/01234": &config.LibraryWorktreeRegistryEntry{
+								LibraryFilePath:       "/home/testuser/library",
+								ProjectDirectoryNames: []string{projectDirectoryName},
+							},
+						},
+					).
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithRelativeLibraryWorktreeFilesContents)).
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromString(abletonLiveSetWithRelativeLibraryFilesContents).
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						getAbletonLiveSetWithExternalFilesFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithRelativeLibraryWorktreeFilesContents)),
+						getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+							SetContentsFromString(abletonLiveSetWithRelativeLibraryFilesContents),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"worktree", "add", "--force", "--detach", "/home/testuser/.mppm-worktrees/library-9b546a0c/01234", "01234"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"[1/1] Restored song.als\n" +
+							"Checked out libraries into worktrees:\n" +
+							"\t/home/testuser/library at version 01234 -> /home/testuser/.mppm-worktrees/library-9b546a0c/01234\n",
+					),
+				),
+		},
+
+		&LibraryCmdTestCase{
+			description: "Test that worktrees are removed by 'mppm library prune' unless a project still has a library checked out into them.",
+			args:        []string{"library", "prune"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							getLibraryWorktreeRegistryWithTwoWorktreesFileBuilder(),
+							getLibraryWorktreesFileBuilder().
+								SetFilePath("/home/testuser/project/"+config.GetProjectLibraryWorktreesFileName()),
+							getLibraryWorktreeHatFileBuilder("01234"),
+							getLibraryWorktreeHatFileBuilder("12345"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.GetLibraryWorktreeRegistryFileBuilder(
+						map[string]*config.LibraryWorktreeRegistryEntry{
+							"/home/testuser/.mppm-worktrees/library-9b546a0c/01234": &config.LibraryWorktreeRegistryEntry{
+								LibraryFilePath:       "/home/testuser/library",
+								ProjectDirectoryNames: []string{"/home/testuser/project"},
+							},
+						},
+					).
+						SetWasClosed(true),
+					getLibraryWorktreesFileBuilder().
+						SetFilePath("/home/testuser/project/"+config.GetProjectLibraryWorktreesFileName()).
+						SetWasClosed(true),
+					getLibraryWorktreeHatFileBuilder("01234"),
+					getLibraryWorktreeHatFileBuilder("12345"),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/library": [][]string{
+							[]string{"worktree", "remove", "--force", "/home/testuser/.mppm-worktrees/library-9b546a0c/12345"},
 						},
 					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Removed worktrees:\n" +
+							"\t/home/testuser/.mppm-worktrees/library-9b546a0c/12345\n",
+					),
 				),
 		},
 	}
@@ -92,3 +1286,92 @@ func (testCase *LibraryCmdTestCase) Run(t *testing.T) {
 	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
 
 }
+
+// A Live Set that refers to a file in a worktree of the library, instead of in the library's own folder.
+var abletonLiveSetWithLibraryWorktreeFilesContents string = strings.Replace(
+	abletonLiveSetWithExternalFilesContents,
+	"/home/testuser/library/Drums/hat.wav",
+	"/home/testuser/.mppm-worktrees/library-9b546a0c/01234/Drums/hat.wav",
+	1,
+)
+
+// A Live Set that refers to a file in the library by its path relative to the project folder, as well as its absolute path.
+var abletonLiveSetWithRelativeLibraryFilesContents string = strings.Replace(
+	abletonLiveSetWithExternalFilesContents,
+	`<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/library/Drums/hat.wav" />`,
+	`<RelativePathType Value="3" />
+					<RelativePath Value="../library/Drums/hat.wav" />
+					<Path Value="/home/testuser/library/Drums/hat.wav" />`,
+	1,
+)
+
+// The same as abletonLiveSetWithRelativeLibraryFilesContents, except that the absolute path refers to a worktree of the library.
+var abletonLiveSetWithRelativeLibraryWorktreeFilesContents string = strings.Replace(
+	abletonLiveSetWithRelativeLibraryFilesContents,
+	"/home/testuser/library/Drums/hat.wav",
+	"/home/testuser/.mppm-worktrees/library-9b546a0c/01234/Drums/hat.wav",
+	1,
+)
+
+// A Live Set that refers to a file in a worktree of the library, and has changes that haven't been extracted.
+var changedAbletonLiveSetWithLibraryWorktreeFilesContents string = strings.Replace(
+	abletonLiveSetWithLibraryWorktreeFilesContents,
+	"/home/testuser/Downloads/snare.aif",
+	"/home/testuser/Downloads/other-snare.aif",
+	1,
+)
+
+// Returns a builder for the file recording that the project has checked version 01234 of the library out into a worktree.
+func getLibraryWorktreesFileBuilder() *utiltest.MockFileBuilder {
+	return configtest.GetProjectLibraryWorktreesFileBuilder(
+		map[string]string{
+			"/home/testuser/library": "/home/testuser/.mppm-worktrees/library-9b546a0c/01234",
+		},
+	)
+}
+
+// Returns a builder for a registry of worktrees of versions 01234 and 12345 of the library. Version 01234 was checked out
+// by a project that still uses it, and version 12345 was checked out by a project that has since been deleted.
+func getLibraryWorktreeRegistryWithTwoWorktreesFileBuilder() *utiltest.MockFileBuilder {
+	return configtest.GetLibraryWorktreeRegistryFileBuilder(
+		map[string]*config.LibraryWorktreeRegistryEntry{
+			"/home/testuser/.mppm-worktrees/library-9b546a0c/01234": &config.LibraryWorktreeRegistryEntry{
+				LibraryFilePath:       "/home/testuser/library",
+				ProjectDirectoryNames: []string{"/home/testuser/project"},
+			},
+			"/home/testuser/.mppm-worktrees/library-9b546a0c/12345": &config.LibraryWorktreeRegistryEntry{
+				LibraryFilePath:       "/home/testuser/library",
+				ProjectDirectoryNames: []string{"/home/testuser/deleted-project"},
+			},
+		},
+	)
+}
+
+func getLibraryWorktreeHatFileBuilder(gitCommitId string) *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("/home/testuser/.mppm-worktrees/library-9b546a0c/" + gitCommitId + "/Drums/hat.wav").
+		SetContentsFromString("hat")
+}
+
+// Returns a builder for a mock library where version 12345 added hat.wav, tracked by git lfs,
+// and version 56789 made hat.wav larger, added kick.wav, which isn't tracked by git lfs, and removed .DS_Store.
+func getLibraryHistoryMockGitManagerCreatorBuilder() *utiltest.MockGitManagerCreatorBuilder {
+	return utiltest.NewMockGitManagerCreatorBuilder().
+		SetDiffTreeStdouts(
+			map[string]string{
+				"-r -z --no-commit-id --root 12345 56789": ":100644 000000 ddddd 0000000 D\x00.DS_Store\x00:100644 100644 aaaaa bbbbb M\x00Drums/hat.wav\x00:000000 100644 0000000 ccccc A\x00Drums/kick.wav\x00",
+			},
+		).
+		SetCatFileStdouts(
+			map[string]string{
+				"-s aaaaa": "130\n",
+				"-p aaaaa": "version https://git-lfs.github.com/spec/v1\noid sha256:aaaaa\nsize 1024\n",
+				"-s bbbbb": "130\n",
+				"-p bbbbb": "version https://git-lfs.github.com/spec/v1\noid sha256:bbbbb\nsize 2048\n",
+				"-s ccccc": "2000000\n",
+				"-s ddddd": "6148\n",
+			},
+		)
+}
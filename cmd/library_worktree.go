@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+// The folder in your home folder that library worktrees are created in.
+var LibraryWorktreesDirectoryName = ".mppm-worktrees"
+
+// The file in the worktrees folder that records which projects use each worktree.
+var LibraryWorktreeRegistryFileName = "worktrees.json"
+
+// Checks out the versions of the libraries in the current project's config file into separate worktrees,
+// instead of changing the versions checked out in the libraries' own folders, and changes the project's
+// Live Sets to refer to the files in the worktrees. Worktrees are shared by all projects that use the same
+// version of a library.
+//
+// The worktrees are only recorded in the project's mppm directory, and the extracted Live Sets keep referring
+// to the libraries' own folders, so that nothing specific to this computer is committed.
+func checkoutProjectSpecifiedLibraryWorktrees() (err error) {
+
+	projectConfig, globalConfig, err := configManager.GetProjectAndGlobalConfigs()
+	if err != nil {
+		return
+	}
+
+	worktrees, err := config.LoadProjectLibraryWorktrees()
+	if err != nil {
+		return
+	}
+	newWorktrees := worktrees.Copy()
+
+	libraryGlobalConfigs := make([]*config.LibraryConfig, 0)
+	gitCommitIds := make([]string, 0)
+	checkedOutLines := make([]string, 0)
+
+	for _, libraryProjectConfig := range projectConfig.Libraries {
+
+		libraryGlobalConfig := getLibraryWithFilePath(libraryProjectConfig.FilePath, globalConfig.Libraries)
+		if libraryGlobalConfig == nil {
+			continue
+		}
+
+		gitCommitId := libraryGlobalConfig.GetGitCommitId(libraryProjectConfig.CurrentGitCommitId)
+
+		var worktreeFilePath string
+		worktreeFilePath, err = getLibraryWorktreeFilePath(libraryGlobalConfig.FilePath, gitCommitId)
+		if err != nil {
+			return
+		}
+
+		newWorktrees.Set(libraryGlobalConfig.FilePath, worktreeFilePath)
+		libraryGlobalConfigs = append(libraryGlobalConfigs, libraryGlobalConfig)
+		gitCommitIds = append(gitCommitIds, gitCommitId)
+
+		libraryProjectConfig.MostRecentGitCommitId = libraryGlobalConfig.MostRecentGitCommitId
+		checkedOutLines = append(checkedOutLines, libraryProjectConfig.FilePath+" at version "+libraryProjectConfig.CurrentGitCommitId+" -> "+worktreeFilePath)
+
+	}
+
+	if len(libraryGlobalConfigs) == 0 {
+		return
+	}
+
+	// Check that the project's Live Sets can be changed before creating any worktrees.
+	preparedRelink, err := prepareProjectLibraryWorktreesRelink(worktrees, newWorktrees)
+	if err != nil {
+		return
+	}
+
+	registry, err := loadLibraryWorktreeRegistry()
+	if err != nil {
+		return
+	}
+
+	projectDirectoryName, err := filepath.Abs(".")
+	if err != nil {
+		return
+	}
+
+	for i, libraryGlobalConfig := range libraryGlobalConfigs {
+		worktreeFilePath := newWorktrees.Get(libraryGlobalConfig.FilePath)
+		if !util.DoesFileExist(worktreeFilePath) {
+			// --force is needed in case the worktree was deleted without git knowing about it.
+			gitManager := util.NewGitManager(libraryGlobalConfig.FilePath)
+			err = gitManager.Worktree("add", "--force", "--detach", worktreeFilePath, gitCommitIds[i])
+			if err != nil {
+				return
+			}
+		}
+		// The registry is saved after each worktree, so that 'mppm library prune' can remove the worktrees
+		// that were already created if a later one can't be.
+		registry.Add(worktreeFilePath, libraryGlobalConfig.FilePath, projectDirectoryName)
+		err = registry.Save()
+		if err != nil {
+			return
+		}
+	}
+
+	err = preparedRelink.Apply()
+	if err != nil {
+		return
+	}
+
+	err = configManager.SaveProjectConfig()
+	if err != nil {
+		return
+	}
+
+	printProjectStatusSection("Checked out libraries into worktrees:", checkedOutLines)
+	return
+
+}
+
+// Returns the folder that the given version of the library is checked out into by 'mppm library checkout --worktree'.
+func getLibraryWorktreeFilePath(libraryFilePath string, gitCommitId string) (worktreeFilePath string, err error) {
+
+	worktreesDirectoryName, err := getLibraryWorktreesDirectoryName(libraryFilePath)
+	if err != nil {
+		return
+	}
+
+	worktreeFilePath = util.JoinFilePath(worktreesDirectoryName, gitCommitId)
+	return
+
+}
+
+// Returns the folder that all worktrees of the library are created in. It is named after the library's folder, with
+// part of a hash of its location, so that libraries in different folders with the same name don't share worktrees.
+func getLibraryWorktreesDirectoryName(libraryFilePath string) (directoryName string, err error) {
+
+	homeDirectoryName, err := util.UserHomeDir()
+	if err != nil {
+		return
+	}
+
+	libraryFilePath = filepath.Clean(libraryFilePath)
+	libraryFilePathHash := sha1.Sum([]byte(libraryFilePath))
+
+	directoryName = util.JoinFilePath(
+		homeDirectoryName,
+		LibraryWorktreesDirectoryName,
+		filepath.Base(libraryFilePath)+"-"+hex.EncodeToString(libraryFilePathHash[:])[:8],
+	)
+	return
+
+}
+
+// Loads the registry of worktrees in the worktrees folder in your home folder.
+func loadLibraryWorktreeRegistry() (registry *config.LibraryWorktreeRegistry, err error) {
+
+	homeDirectoryName, err := util.UserHomeDir()
+	if err != nil {
+		return
+	}
+
+	registry, err = config.LoadLibraryWorktreeRegistry(util.JoinFilePath(homeDirectoryName, LibraryWorktreesDirectoryName, LibraryWorktreeRegistryFileName))
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+// A change of the worktrees that the project's Live Sets refer to, which has been checked but not made yet.
+type preparedProjectRelink struct {
+	NewWorktrees       *config.ProjectLibraryWorktrees
+	ExtractedFileNames []string // The extracted Live Sets whose binary files are restored to refer to the new worktrees.
+	FilePatternsConfig *applications.FilePatternsConfig
+	Manifest           *config.ExtractionManifest
+	HasChanges         bool
+}
+
+// Prepares to change the project's Live Sets from referring to the files in the given worktrees, or in the libraries'
+// own folders for libraries without worktrees, to refer to the files in the new worktrees instead. The extracted
+// Live Sets always refer to the libraries' own folders, so only the binary Live Sets are changed, by restoring them.
+//
+// The Live Sets are checked for changes that haven't been extracted first, so that anything which must happen
+// before they are restored, such as checking out libraries, can be skipped if they can't be.
+func prepareProjectLibraryWorktreesRelink(worktrees *config.ProjectLibraryWorktrees, newWorktrees *config.ProjectLibraryWorktrees) (preparedRelink *preparedProjectRelink, err error) {
+
+	preparedRelink = &preparedProjectRelink{
+		NewWorktrees:       newWorktrees,
+		ExtractedFileNames: make([]string, 0),
+	}
+
+	changedDirectoryNames := make([]string, 0)
+	for _, libraryFilePaths := range []map[string]string{worktrees.FilePaths, newWorktrees.FilePaths} {
+		for libraryFilePath := range libraryFilePaths {
+			if worktrees.Get(libraryFilePath) != newWorktrees.Get(libraryFilePath) {
+				changedDirectoryNames = appendIfMissing(changedDirectoryNames, libraryFilePath)
+				if worktreeFilePath := worktrees.Get(libraryFilePath); worktreeFilePath != "" {
+					changedDirectoryNames = appendIfMissing(changedDirectoryNames, worktreeFilePath)
+				}
+			}
+		}
+	}
+
+	if len(changedDirectoryNames) == 0 {
+		return
+	}
+	preparedRelink.HasChanges = true
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	manifest, err := config.LoadExtractionManifest()
+	if err != nil {
+		return
+	}
+
+	dependencies, err := getProjectDependencies(filePatternsConfig, nil)
+	if err != nil {
+		return
+	}
+
+	for _, dependency := range dependencies {
+		if dependency.Location == projectDependencyLocation {
+			continue
+		}
+		for _, directoryName := range changedDirectoryNames {
+			if isFileInDirectory(dependency.FilePath, directoryName) {
+				preparedRelink.ExtractedFileNames = appendIfMissing(preparedRelink.ExtractedFileNames, dependency.ExtractedFileName)
+			}
+		}
+	}
+
+	err = checkForUnextractedChanges(preparedRelink.ExtractedFileNames, filePatternsConfig, manifest)
+	if err != nil {
+		return
+	}
+
+	preparedRelink.FilePatternsConfig = filePatternsConfig
+	preparedRelink.Manifest = manifest
+	return
+
+}
+
+// Records the new worktrees in the project's mppm directory, and restores the Live Sets that refer to them.
+func (preparedRelink *preparedProjectRelink) Apply() (err error) {
+
+	if !preparedRelink.HasChanges {
+		return
+	}
+
+	err = preparedRelink.NewWorktrees.Save()
+	if err != nil {
+		return
+	}
+
+	if len(preparedRelink.ExtractedFileNames) == 0 {
+		return
+	}
+
+	tasks := make([]*util.Task, 0, len(preparedRelink.ExtractedFileNames))
+	for _, extractedFileName := range preparedRelink.ExtractedFileNames {
+		compressedXmlFile := newRestoredGzippedXmlFile(extractedFileName, preparedRelink.FilePatternsConfig)
+		tasks = append(tasks, compressedXmlFile.getRestoreTask(preparedRelink.Manifest))
+	}
+
+	err = util.RunTasks(numberOfJobs, tasks)
+
+	// Record any files that were restored, even if others couldn't be.
+	if saveErr := preparedRelink.Manifest.Save(); err == nil {
+		err = saveErr
+	}
+
+	return
+
+}
+
+// Returns the worktrees that the current project has checked libraries out into, if the given file is
+// an Ableton Live Set or another file that can refer to library files, or no worktrees otherwise.
+func getLibraryWorktreesForXmlFile(fileName string) (worktrees *config.ProjectLibraryWorktrees, err error) {
+	if !applications.AbletonInfo.IsCompressedXmlFile(fileName) {
+		worktrees = &config.ProjectLibraryWorktrees{FilePaths: make(map[string]string)}
+		return
+	}
+	worktrees, err = config.LoadProjectLibraryWorktrees()
+	return
+}
+
+// Changes the references in the given Live Set to files in the libraries' own folders to refer to the same files in the
+// worktrees that the libraries are checked out into, or the other way around if toWorktrees is false. Returns false
+// if no references were changed.
+func relinkLibraryWorktreeFileRefs(root *ableton.Element, worktrees *config.ProjectLibraryWorktrees, toWorktrees bool) (wasRelinked bool, err error) {
+
+	if len(worktrees.FilePaths) == 0 {
+		return
+	}
+
+	for _, fileRef := range ableton.GetFileRefs(root) {
+
+		if fileRef.Path == "" {
+			continue
+		}
+		filePath := filepath.FromSlash(fileRef.Path)
+
+		for libraryFilePath, worktreeFilePath := range worktrees.FilePaths {
+
+			previousDirectoryName, directoryName := worktreeFilePath, libraryFilePath
+			if toWorktrees {
+				previousDirectoryName, directoryName = libraryFilePath, worktreeFilePath
+			}
+
+			if !isFileInDirectory(filePath, previousDirectoryName) {
+				continue
+			}
+
+			relativeFilePath, relErr := filepath.Rel(filepath.Clean(previousDirectoryName), filepath.Clean(filePath))
+			if relErr != nil {
+				continue
+			}
+
+			// Only the absolute path is changed, so that the extracted Live Set is the same as before it was restored.
+			err = fileRef.SetAbsolutePath(filepath.ToSlash(util.JoinFilePath(directoryName, relativeFilePath)))
+			if err != nil {
+				return
+			}
+			wasRelinked = true
+			break
+
+		}
+
+	}
+
+	return
+
+}
+
+// Returns the location of the given file relative to the library's folder, or relative to one of the library's
+// worktrees, or false if it isn't in either of them.
+func getLibraryFileNameFromFilePath(filePath string, libraryConfig *config.LibraryConfig) (libraryFileName string, ok bool) {
+
+	libraryDirectoryName := filepath.Clean(libraryConfig.FilePath)
+
+	if !isFileInDirectory(filePath, libraryDirectoryName) {
+		worktreesDirectoryName, err := getLibraryWorktreesDirectoryName(libraryConfig.FilePath)
+		if err != nil || !isFileInDirectory(filePath, worktreesDirectoryName) {
+			return
+		}
+		// The first folder inside the worktrees folder is the worktree of a version.
+		relativeFilePath, _ := filepath.Rel(worktreesDirectoryName, filepath.Clean(filePath))
+		worktreeName := strings.SplitN(relativeFilePath, string(filepath.Separator), 2)[0]
+		libraryDirectoryName = util.JoinFilePath(worktreesDirectoryName, worktreeName)
+	}
+
+	libraryFileName, err := filepath.Rel(libraryDirectoryName, filepath.Clean(filePath))
+	if err != nil || libraryFileName == "." {
+		return
+	}
+
+	libraryFileName = filepath.ToSlash(libraryFileName)
+	ok = true
+	return
+
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	RootCmd.AddCommand(MergeDriverCmd)
+}
+
+var MergeDriverName = "mppm"
+
+var MergeDriverCommand = "mppm merge-driver %O %A %B %P"
+
+var MergeDriverCmd = &cobra.Command{
+
+	Use: "merge-driver <ancestor-file> <current-file> <other-file> [path]",
+
+	Short: "Merges changes to extracted Ableton files by track, device, and clip. Used by git during 'git merge'.",
+
+	Long: `Merges changes to extracted Ableton files by track, device, and clip. Used by git during 'git merge'.
+
+The merged result is written to <current-file>. If the same element was changed on both sides,
+or if elements with the same ID were added on both sides, each conflict is reported, both versions
+of the element are written between git-style conflict markers, and the merge is marked as failed.
+
+This is registered automatically for projects created with 'mppm project init'.
+After cloning a project, run 'mppm project setup' to register it in the clone.`,
+
+	Args: cobra.RangeArgs(3, 4),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		ancestorFileName, currentFileName, otherFileName := args[0], args[1], args[2]
+		displayFileName := currentFileName
+		if len(args) > 3 {
+			displayFileName = args[3]
+		}
+		if err := mergeExtractedFiles(ancestorFileName, currentFileName, otherFileName, displayFileName); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func mergeExtractedFiles(ancestorFileName string, currentFileName string, otherFileName string, displayFileName string) (err error) {
+
+	var ancestor *ableton.Element
+	ancestorContents, err := util.ReadFile(ancestorFileName)
+	if err != nil {
+		return
+	}
+	if len(strings.TrimSpace(string(ancestorContents))) > 0 {
+		ancestor, err = ableton.ParseElementFromBytes(ancestorContents)
+		if err != nil {
+			return
+		}
+	}
+
+	current, err := readAndParseElement(currentFileName)
+	if err != nil {
+		return
+	}
+
+	other, err := readAndParseElement(otherFileName)
+	if err != nil {
+		return
+	}
+
+	result := ableton.MergeElements(ancestor, current, other)
+
+	err = util.WriteFile(currentFileName, result.AsXmlDocument())
+	if err != nil {
+		return
+	}
+
+	if len(result.Conflicts) > 0 {
+		util.Println("Conflicts in " + displayFileName + ":")
+		for _, conflict := range result.Conflicts {
+			util.Println("\t" + conflict)
+		}
+		err = errors.New(
+			fmt.Sprintf(
+				"Unable to automatically merge %d changes in %s. Both versions of each were kept between conflict markers. "+
+					"Edit the file to keep one version of each, then run 'git add %s' and 'mppm project restore'.",
+				len(result.Conflicts),
+				displayFileName,
+				displayFileName,
+			),
+		)
+		return
+	}
+
+	return
+
+}
+
+func readAndParseElement(fileName string) (element *ableton.Element, err error) {
+	contents, err := util.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+	element, err = ableton.ParseElementFromBytes(contents)
+	return
+}
+
+func getMergeDriverGitAttributes(abletonXmlFileExtensions []string) (gitAttributes []string) {
+	gitAttributes = make([]string, 0)
+	for _, fileExtension := range abletonXmlFileExtensions {
+		gitAttributes = append(gitAttributes, "*."+fileExtension+".xml merge="+MergeDriverName)
+	}
+	return
+}
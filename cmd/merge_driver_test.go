@@ -0,0 +1,119 @@
+package cmd_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+func TestMergeDriverCmd(t *testing.T) {
+
+	testCases := []*MergeDriverCmdTestCase{
+
+		&MergeDriverCmdTestCase{
+			description: "Test that changes from both sides are merged into the current file.",
+			args:        []string{"merge-driver", "ancestor.xml", "current.xml", "other.xml", "song.als.xml"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.NewMockFileBuilder().
+								SetFilePath("ancestor.xml").
+								SetContentsFromString("<Ableton><Tempo Value=\"120\" /><Name Value=\"Song\" /></Ableton>"),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("current.xml").
+								SetContentsFromString("<Ableton><Tempo Value=\"128\" /><Name Value=\"Song\" /></Ableton>"),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("other.xml").
+								SetContentsFromString("<Ableton><Tempo Value=\"120\" /><Name Value=\"Song 2\" /></Ableton>"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.NewMockFileBuilder().
+						SetFilePath("ancestor.xml").
+						SetContentsFromString("<Ableton><Tempo Value=\"120\" /><Name Value=\"Song\" /></Ableton>").
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("current.xml").
+						SetContentsFromString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Ableton>\n\t<Tempo Value=\"128\" />\n\t<Name Value=\"Song 2\" />\n</Ableton>\n").
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("other.xml").
+						SetContentsFromString("<Ableton><Tempo Value=\"120\" /><Name Value=\"Song 2\" /></Ableton>").
+						SetWasClosed(true),
+				),
+		},
+
+		&MergeDriverCmdTestCase{
+			description: "Test that conflicts are reported and the merge fails, keeping both versions of conflicting elements between conflict markers.",
+			args:        []string{"merge-driver", "ancestor.xml", "current.xml", "other.xml", "song.als.xml"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.NewMockFileBuilder().
+								SetFilePath("ancestor.xml").
+								SetContentsFromString("<Ableton><Tempo Value=\"120\" /></Ableton>"),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("current.xml").
+								SetContentsFromString("<Ableton><Tempo Value=\"128\" /></Ableton>"),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("other.xml").
+								SetContentsFromString("<Ableton><Tempo Value=\"90\" /></Ableton>"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New(
+						"Unable to automatically merge 1 changes in song.als.xml. Both versions of each were kept between conflict markers. "+
+							"Edit the file to keep one version of each, then run 'git add song.als.xml' and 'mppm project restore'.",
+					),
+				).
+				SetMockFileBuilders(
+					utiltest.NewMockFileBuilder().
+						SetFilePath("ancestor.xml").
+						SetContentsFromString("<Ableton><Tempo Value=\"120\" /></Ableton>").
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("current.xml").
+						SetContentsFromString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Ableton>\n<<<<<<< ours\n\t<Tempo Value=\"128\" />\n=======\n\t<Tempo Value=\"90\" />\n>>>>>>> theirs\n</Ableton>\n").
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("other.xml").
+						SetContentsFromString("<Ableton><Tempo Value=\"90\" /></Ableton>").
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Conflicts in song.als.xml:\n\tAbleton/Tempo[0]@Value was changed to \"128\" and \"90\"\n"),
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+type MergeDriverCmdTestCase struct {
+	description                              string
+	args                                     []string
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *MergeDriverCmdTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	cmd.RootCmd.SetArgs(testCase.args)
+	cmd.RootCmd.Execute()
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
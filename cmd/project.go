@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
 	"github.com/stevengt/mppm/util"
 )
 
@@ -10,6 +11,8 @@ func init() {
 	cobra.OnInitialize(
 		func() {
 			isPreviewCommand, _ = ProjectCmd.PersistentFlags().GetBool("preview")
+			isForceCommand, _ = ProjectCmd.PersistentFlags().GetBool("force")
+			numberOfJobs, _ = ProjectCmd.PersistentFlags().GetInt("jobs")
 			isCommitAllCommand, _ = ProjectCmd.Flags().GetBool("commit-all")
 			shouldUpdateLibraries, _ = ProjectCmd.Flags().GetBool("update-libraries")
 		},
@@ -23,6 +26,25 @@ func init() {
 		"Shows what files will be affected without actually making changes.",
 	)
 
+	ProjectCmd.PersistentFlags().BoolVarP(
+		&isForceCommand,
+		"force",
+		"f",
+		false,
+		`Extracts or restores all files, including files that haven't changed since they were
+last extracted or restored. For 'mppm project relink', relinks the matching files
+instead of only listing them.`,
+	)
+
+	ProjectCmd.PersistentFlags().IntVarP(
+		&numberOfJobs,
+		"jobs",
+		"j",
+		0,
+		`The number of files to extract or restore at the same time.
+Defaults to the number of CPUs.`,
+	)
+
 	ProjectCmd.Flags().BoolVarP(
 		&isCommitAllCommand,
 		"commit-all",
@@ -46,6 +68,8 @@ To see the global current versions, run 'mppm library --list'.`,
 }
 
 var isPreviewCommand bool
+var isForceCommand bool
+var numberOfJobs int
 var isCommitAllCommand bool
 var shouldUpdateLibraries bool
 
@@ -88,8 +112,15 @@ var ProjectCmd = &cobra.Command{
 	PersistentPostRun: func(cmd *cobra.Command, args []string) {
 		RootCmd.PersistentPostRun(cmd, args)
 		isPreviewCommand = false
+		isForceCommand = false
+		numberOfJobs = 0
 		isCommitAllCommand = false
 		shouldUpdateLibraries = false
+		shouldSplitPluginStateChunks = false
+		restoreConflictAction = restoreConflictActionAbort
+		shouldCollectLibraryFiles = false
+		importedLibrariesDirectoryName = ""
+		projectApplicationNames = nil
 	},
 }
 
@@ -119,7 +150,17 @@ func updateProjectLibraryGitCommitIds() (err error) {
 		return
 	}
 
-	projectConfig.Libraries = globalConfig.Libraries
+	// Tags and branches are only recorded in the global config file, since the project only needs its own versions.
+	projectConfig.Libraries = make([]*config.LibraryConfig, 0, len(globalConfig.Libraries))
+	for _, globalLibrary := range globalConfig.Libraries {
+		projectLibrary := &config.LibraryConfig{
+			FilePath:              globalLibrary.FilePath,
+			MostRecentGitCommitId: globalLibrary.MostRecentGitCommitId,
+			CurrentGitCommitId:    globalLibrary.CurrentGitCommitId,
+		}
+		projectConfig.Libraries = append(projectConfig.Libraries, projectLibrary)
+	}
+
 	err = configManager.SaveProjectConfig()
 	return
 }
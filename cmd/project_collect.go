@@ -0,0 +1,336 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+
+	cobra.OnInitialize(
+		func() {
+			shouldCollectLibraryFiles, _ = CollectCmd.Flags().GetBool("include-libraries")
+		},
+	)
+
+	CollectCmd.Flags().BoolVar(
+		&shouldCollectLibraryFiles,
+		"include-libraries",
+		false,
+		"Also copies samples from the libraries in the global config file into the project.",
+	)
+
+	ProjectCmd.AddCommand(CollectCmd)
+
+}
+
+// The folder that samples from outside of the project are copied into, the same as Live's "Collect All and Save".
+var CollectedSamplesDirectoryName = "Samples/Imported"
+
+var DefaultCollectCommitMessage = "Collect external samples into " + CollectedSamplesDirectoryName
+
+var shouldCollectLibraryFiles bool
+
+var CollectCmd = &cobra.Command{
+
+	Use: "collect [commit message]",
+
+	Short: "Copies samples from outside of the project into it, and commits them.",
+
+	Long: `Copies samples from outside of the project into it, and commits them.
+
+Every audio sample that the project's Ableton Live Sets refer to from outside of the project
+(see 'mppm project deps') is copied into the ` + CollectedSamplesDirectoryName + ` folder, like Live's
+"Collect All and Save". Samples from libraries are only copied if --include-libraries is given.
+
+The references are then updated in the extracted .xml files, the binary files are restored, and
+the copied samples and extracted files are committed with the given message, or with
+"` + DefaultCollectCommitMessage + `" by default. Copied samples that don't match the
+project's git lfs patterns are tracked with git lfs individually.
+Only references in Live Sets saved by Live 11 and later can be updated.`,
+
+	Args: cobra.MaximumNArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		commitMessage := DefaultCollectCommitMessage
+		if len(args) > 0 {
+			commitMessage = args[0]
+		}
+		if err := collectExternalSamples(commitMessage); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func collectExternalSamples(commitMessage string) (err error) {
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	globalConfig, err := configManager.GetGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	manifest, err := config.LoadExtractionManifest()
+	if err != nil {
+		return
+	}
+
+	dependencies, err := getProjectDependencies(filePatternsConfig, globalConfig.Libraries)
+	if err != nil {
+		return
+	}
+
+	collectedLines := make([]string, 0)
+	uncollectedLines := make([]string, 0)
+	collectedFileNames := make(map[string]string) // Indexed by the original file names.
+	collectedExtractedFileNames := make([]string, 0)
+	collectedExtractedRoots := make(map[string]*ableton.Element)
+
+	for _, dependency := range dependencies {
+
+		if dependency.FileRef.Kind != ableton.SampleFileRefKind || !shouldCollectProjectDependency(dependency) {
+			continue
+		}
+
+		description := dependency.FilePath + " (used by " + strings.TrimSuffix(dependency.ExtractedFileName, ".xml") + ")"
+
+		if dependency.Location == missingDependencyLocation {
+			uncollectedLines = appendIfMissing(uncollectedLines, description+": The file does not exist. To find it, run 'mppm project relink'.")
+			continue
+		}
+
+		collectedFileName, ok := collectedFileNames[dependency.FilePath]
+		if !ok {
+			collectedFileName, err = getCollectedSampleFileName(dependency.FilePath, collectedFileNames)
+			if err != nil {
+				return
+			}
+		}
+
+		setPathErr := relinkProjectDependency(dependency, collectedFileName)
+		if setPathErr != nil {
+			uncollectedLines = appendIfMissing(uncollectedLines, description+": "+setPathErr.Error())
+			continue
+		}
+
+		collectedFileNames[dependency.FilePath] = collectedFileName
+		collectedLines = appendIfMissing(collectedLines, dependency.FilePath+" -> "+collectedFileName+" (used by "+strings.TrimSuffix(dependency.ExtractedFileName, ".xml")+")")
+		collectedExtractedFileNames = appendIfMissing(collectedExtractedFileNames, dependency.ExtractedFileName)
+		collectedExtractedRoots[dependency.ExtractedFileName] = dependency.ExtractedRoot
+
+	}
+
+	if len(collectedLines) == 0 && len(uncollectedLines) == 0 {
+		util.Println("There are no samples outside of the project to collect.")
+		return
+	}
+
+	if isPreviewCommand {
+		printProjectStatusSection("Files that will be collected:", collectedLines)
+	} else {
+		printProjectStatusSection("Collected files:", collectedLines)
+	}
+	printProjectStatusSection("Files that could not be collected:", uncollectedLines)
+
+	if isPreviewCommand || len(collectedExtractedFileNames) == 0 {
+		return
+	}
+
+	err = checkForUnextractedChanges(collectedExtractedFileNames, filePatternsConfig, manifest)
+	if err != nil {
+		return
+	}
+
+	err = copyCollectedSamples(collectedFileNames, filePatternsConfig)
+	if err != nil {
+		return
+	}
+
+	err = writeAndRestoreExtractedFiles(collectedExtractedFileNames, collectedExtractedRoots, filePatternsConfig, manifest)
+
+	// Record any files that were restored, even if others couldn't be.
+	if saveErr := manifest.Save(); err == nil {
+		err = saveErr
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = commitCollectedSamples(commitMessage, collectedFileNames, collectedExtractedFileNames)
+	return
+
+}
+
+// Returns true if the given dependency is outside of the project, and should be copied into it.
+func shouldCollectProjectDependency(dependency *projectDependency) bool {
+	switch dependency.Location {
+	case externalDependencyLocation:
+		return true
+	case libraryDependencyLocation:
+		return shouldCollectLibraryFiles
+	case missingDependencyLocation:
+		return dependency.Library == nil || shouldCollectLibraryFiles
+	default:
+		return false
+	}
+}
+
+// Returns the name of the file in the collected samples directory that the given sample will be copied to.
+// If a different file with the same name was already collected, then the new file is numbered, like "kick (2).wav".
+func getCollectedSampleFileName(fileName string, collectedFileNames map[string]string) (collectedFileName string, err error) {
+
+	extension := filepath.Ext(fileName)
+	baseName := strings.TrimSuffix(filepath.Base(fileName), extension)
+
+	usedFileNames := make(map[string]bool)
+	for _, usedFileName := range collectedFileNames {
+		usedFileNames[strings.ToLower(usedFileName)] = true
+	}
+
+	for i := 1; ; i++ {
+
+		collectedFileName = util.JoinFilePath(CollectedSamplesDirectoryName, baseName+extension)
+		if i > 1 {
+			collectedFileName = util.JoinFilePath(CollectedSamplesDirectoryName, fmt.Sprintf("%s (%d)%s", baseName, i, extension))
+		}
+
+		if usedFileNames[strings.ToLower(collectedFileName)] {
+			continue
+		}
+
+		if !util.DoesFileExist(collectedFileName) {
+			return
+		}
+
+		// Reuse a previously collected copy of the same file.
+		var isSameFile bool
+		isSameFile, err = haveSameContents(fileName, collectedFileName)
+		if err != nil || isSameFile {
+			return
+		}
+
+	}
+
+}
+
+func haveSameContents(fileName1 string, fileName2 string) (haveSameContents bool, err error) {
+
+	fileInfo1, err := util.StatFile(fileName1)
+	if err != nil {
+		return
+	}
+
+	fileInfo2, err := util.StatFile(fileName2)
+	if err != nil {
+		return
+	}
+
+	if fileInfo1.Size() != fileInfo2.Size() {
+		return
+	}
+
+	contents1, err := util.ReadFile(fileName1)
+	if err != nil {
+		return
+	}
+
+	contents2, err := util.ReadFile(fileName2)
+	if err != nil {
+		return
+	}
+
+	haveSameContents = bytes.Equal(contents1, contents2)
+	return
+
+}
+
+// Copies the given samples into the project, indexed by their original file names, and tracks any that
+// don't match the project's git lfs patterns, such as audio files with uncommon extensions, with git lfs.
+func copyCollectedSamples(collectedFileNames map[string]string, filePatternsConfig *applications.FilePatternsConfig) (err error) {
+
+	gitManager := util.NewGitManager(".")
+
+	for _, fileName := range getSortedKeys(collectedFileNames) {
+
+		collectedFileName := collectedFileNames[fileName]
+
+		if !util.DoesFileExist(collectedFileName) {
+			err = util.CopyFile(fileName, collectedFileName)
+			if err != nil {
+				return
+			}
+		}
+
+		if !doesFileNameMatchAnyGitPattern(filepath.ToSlash(collectedFileName), filePatternsConfig.GitLfsTrackPatterns) {
+			err = gitManager.LfsTrack(filepath.ToSlash(collectedFileName))
+			if err != nil {
+				return
+			}
+		}
+
+	}
+
+	return
+
+}
+
+// Commits the collected samples, the extracted files that refer to them, and any changes to the git lfs patterns.
+func commitCollectedSamples(commitMessage string, collectedFileNames map[string]string, extractedFileNames []string) (err error) {
+
+	fileNames := make([]string, 0)
+	if util.DoesFileExist(".gitattributes") {
+		fileNames = append(fileNames, ".gitattributes")
+	}
+	for _, fileName := range getSortedKeys(collectedFileNames) {
+		fileNames = appendIfMissing(fileNames, collectedFileNames[fileName])
+	}
+	for _, extractedFileName := range extractedFileNames {
+		fileNames = append(fileNames, extractedFileName)
+		if partsDirectoryName := getSplitXmlPartsDirectoryName(extractedFileName); util.DoesFileExist(partsDirectoryName) {
+			fileNames = append(fileNames, partsDirectoryName)
+		}
+	}
+
+	gitManager := util.NewGitManager(".")
+
+	err = gitManager.Add(append([]string{"-A", "--"}, fileNames...)...)
+	if err != nil {
+		return
+	}
+
+	err = gitManager.Commit("-m", commitMessage)
+	return
+
+}
+
+func doesFileNameMatchAnyGitPattern(fileName string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if doesFileNameMatchGitPattern(fileName, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func getSortedKeys(values map[string]string) (keys []string) {
+	keys = make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
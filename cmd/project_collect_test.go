@@ -0,0 +1,265 @@
+package cmd_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+var abletonLiveSetWithExternalFilesContents string = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="11.0_433" Creator="Ableton Live 11.3.4">
+	<LiveSet>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/Downloads/kick.wav" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/Downloads/snare.aif" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/library/Drums/hat.wav" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/Users/other/clap.wav" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+	</LiveSet>
+</Ableton>
+`
+
+func getCollectedAbletonLiveSetWithExternalFilesContents() string {
+	kickFilePath, _ := filepath.Abs("Samples/Imported/kick (2).wav")
+	snareFilePath, _ := filepath.Abs("Samples/Imported/snare.aif")
+	return strings.NewReplacer(
+		`					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/Downloads/kick.wav" />`,
+		`					<RelativePathType Value="3" />
+					<RelativePath Value="Samples/Imported/kick (2).wav" />
+					<Path Value="`+filepath.ToSlash(kickFilePath)+`" />`,
+		`					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/Downloads/snare.aif" />`,
+		`					<RelativePathType Value="3" />
+					<RelativePath Value="Samples/Imported/snare.aif" />
+					<Path Value="`+filepath.ToSlash(snareFilePath)+`" />`,
+	).Replace(abletonLiveSetWithExternalFilesContents)
+}
+
+func getAbletonLiveSetWithExternalFilesFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("song.als").
+		SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithExternalFilesContents))
+}
+
+func getUncompressedAbletonLiveSetWithExternalFilesFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("song.als.xml").
+		SetContentsFromString(abletonLiveSetWithExternalFilesContents)
+}
+
+func getExternalKickFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("/home/testuser/Downloads/kick.wav").
+		SetContentsFromString("kick")
+}
+
+func getExternalSnareFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("/home/testuser/Downloads/snare.aif").
+		SetContentsFromString("snare")
+}
+
+func getLibraryHatFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("/home/testuser/library/Drums/hat.wav").
+		SetContentsFromString("hat")
+}
+
+// A different file with the same name as the external kick sample, which was already collected.
+func getPreviouslyCollectedKickFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("Samples/Imported/kick.wav").
+		SetContentsFromString("other kick")
+}
+
+func TestProjectCollectCmd(t *testing.T) {
+
+	testCases := []*ProjectCollectCmdTestCase{
+
+		&ProjectCollectCmdTestCase{
+			description: "Test that external samples are copied into the project, relinked, restored, and committed.",
+			args:        []string{"project", "collect"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							getAbletonLiveSetWithExternalFilesFileBuilder(),
+							getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
+							getExternalKickFileBuilder(),
+							getExternalSnareFileBuilder(),
+							getLibraryHatFileBuilder(),
+							getPreviouslyCollectedKickFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(getCollectedAbletonLiveSetWithExternalFilesContents())).
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromString(getCollectedAbletonLiveSetWithExternalFilesContents()).
+						SetWasClosed(true),
+					getExternalKickFileBuilder().
+						SetWasClosed(true),
+					getExternalSnareFileBuilder().
+						SetWasClosed(true),
+					getLibraryHatFileBuilder(),
+					getPreviouslyCollectedKickFileBuilder(),
+					getExternalKickFileBuilder().
+						SetFilePath("Samples/Imported/kick (2).wav").
+						SetWasClosed(true),
+					getExternalSnareFileBuilder().
+						SetFilePath("Samples/Imported/snare.aif").
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						getAbletonLiveSetWithExternalFilesFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents(getCollectedAbletonLiveSetWithExternalFilesContents())),
+						getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+							SetContentsFromString(getCollectedAbletonLiveSetWithExternalFilesContents()),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"lfs", "track", "Samples/Imported/snare.aif"},
+							[]string{"add", "-A", "--", "Samples/Imported/kick (2).wav", "Samples/Imported/snare.aif", "song.als.xml"},
+							[]string{"commit", "-m", "Collect external samples into Samples/Imported"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Collected files:\n" +
+							"\t/home/testuser/Downloads/kick.wav -> Samples/Imported/kick (2).wav (used by song.als)\n" +
+							"\t/home/testuser/Downloads/snare.aif -> Samples/Imported/snare.aif (used by song.als)\n" +
+							"Files that could not be collected:\n" +
+							"\t/Users/other/clap.wav (used by song.als): The file does not exist. To find it, run 'mppm project relink'.\n" +
+							"[1/1] Restored song.als\n",
+					),
+				),
+		},
+
+		&ProjectCollectCmdTestCase{
+			description: "Test that samples from libraries are also collected if --include-libraries is given, and that nothing is changed if --preview is given.",
+			args:        []string{"project", "collect", "--include-libraries", "--preview"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							getAbletonLiveSetWithExternalFilesFileBuilder(),
+							getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
+							getExternalKickFileBuilder(),
+							getExternalSnareFileBuilder(),
+							getLibraryHatFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder(),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					getExternalKickFileBuilder(),
+					getExternalSnareFileBuilder(),
+					getLibraryHatFileBuilder(),
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Files that will be collected:\n" +
+							"\t/home/testuser/Downloads/kick.wav -> Samples/Imported/kick.wav (used by song.als)\n" +
+							"\t/home/testuser/Downloads/snare.aif -> Samples/Imported/snare.aif (used by song.als)\n" +
+							"\t/home/testuser/library/Drums/hat.wav -> Samples/Imported/hat.wav (used by song.als)\n" +
+							"Files that could not be collected:\n" +
+							"\t/Users/other/clap.wav (used by song.als): The file does not exist. To find it, run 'mppm project relink'.\n",
+					),
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+// ------------------------------------------------------------------------------
+
+type ProjectCollectCmdTestCase struct {
+	description                              string
+	args                                     []string
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *ProjectCollectCmdTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	cmd.RootCmd.SetArgs(testCase.args)
+	cmd.RootCmd.Execute()
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	ProjectCmd.AddCommand(DepsCmd)
+}
+
+var DepsCmd = &cobra.Command{
+
+	Use: "deps",
+
+	Short: "Lists the external files that the project's Ableton Live Sets refer to.",
+
+	Long: `Lists the external files that the project's Ableton Live Sets refer to.
+
+Every extracted .xml file of a supported Ableton file type is searched for the
+audio samples, presets, and Max devices that it refers to, and each file is listed as:
+
+	- Inside the project, if it exists relative to the project's folder.
+	- Inside a library, if it is inside one of the libraries in the global config file.
+	- Outside of the project and its libraries, if it exists anywhere else.
+	- Missing, if it can't be found.
+
+The libraries that the project depends on are listed last.
+To update the extracted .xml files first, run 'mppm project extract'.`,
+
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printProjectDependencies(); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+// Where a file that a Live Set refers to was found.
+const (
+	projectDependencyLocation  = "project"
+	libraryDependencyLocation  = "library"
+	externalDependencyLocation = "external"
+	missingDependencyLocation  = "missing"
+)
+
+// A reference from an extracted Live Set to an external file.
+type projectDependency struct {
+	ExtractedFileName string           // The extracted .xml file that contains the reference.
+	ExtractedRoot     *ableton.Element // The root element of the extracted file, which FileRef.Element belongs to.
+	FileRef           *ableton.FileRef
+	FilePath          string                // The path of the file on this system.
+	Location          string                // One of the dependency locations, such as projectDependencyLocation.
+	Library           *config.LibraryConfig // The library that contains FilePath, or nil.
+	LibraryFileName   string                // The location of FilePath relative to the library's folder or worktree.
+}
+
+func printProjectDependencies() (err error) {
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	globalConfig, err := configManager.GetGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	dependencies, err := getProjectDependencies(filePatternsConfig, globalConfig.Libraries)
+	if err != nil {
+		return
+	}
+
+	if len(dependencies) == 0 {
+		util.Println("The project does not refer to any external files.")
+		return
+	}
+
+	printProjectStatusSection("Files inside the project:", getProjectDependencyLines(dependencies, projectDependencyLocation))
+	printProjectStatusSection("Files inside libraries:", getProjectDependencyLines(dependencies, libraryDependencyLocation))
+	printProjectStatusSection("Files outside of the project and its libraries:", getProjectDependencyLines(dependencies, externalDependencyLocation))
+	printProjectStatusSection("Missing files:", getProjectDependencyLines(dependencies, missingDependencyLocation))
+
+	libraryFilePaths := make([]string, 0)
+	for _, dependency := range dependencies {
+		if dependency.Library != nil && !containsString(libraryFilePaths, dependency.Library.FilePath) {
+			libraryFilePaths = append(libraryFilePaths, dependency.Library.FilePath)
+		}
+	}
+	sort.Strings(libraryFilePaths)
+	printProjectStatusSection("Libraries used by the project:", libraryFilePaths)
+
+	return
+
+}
+
+// Returns one line for every distinct file with the given location, showing which Live Sets refer to it.
+func getProjectDependencyLines(dependencies []*projectDependency, location string) (lines []string) {
+
+	filePaths := make([]string, 0)
+	kinds := make(map[string]string)
+	referringFileNames := make(map[string][]string)
+
+	for _, dependency := range dependencies {
+		if dependency.Location != location {
+			continue
+		}
+		if _, ok := kinds[dependency.FilePath]; !ok {
+			filePaths = append(filePaths, dependency.FilePath)
+			kinds[dependency.FilePath] = dependency.FileRef.Kind
+		}
+		binaryFileName := strings.TrimSuffix(dependency.ExtractedFileName, ".xml")
+		if !containsString(referringFileNames[dependency.FilePath], binaryFileName) {
+			referringFileNames[dependency.FilePath] = append(referringFileNames[dependency.FilePath], binaryFileName)
+		}
+	}
+
+	sort.Strings(filePaths)
+	lines = make([]string, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		lines = append(lines, filePath+" ("+kinds[filePath]+", used by "+strings.Join(referringFileNames[filePath], ", ")+")")
+	}
+
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+// Returns every reference to an external file in the extracted .xml files of all supported
+// Ableton file types, in the order of the extracted file names and then document order.
+func getProjectDependencies(filePatternsConfig *applications.FilePatternsConfig, libraries []*config.LibraryConfig) (dependencies []*projectDependency, err error) {
+
+	extractedFileNames := make([]string, 0)
+	for _, fileExtension := range applications.AbletonInfo.FilterCompressedXmlFileExtensions(filePatternsConfig.CompressedXmlFileExtensions) {
+		var fileNames []string
+		fileNames, err = util.GetAllFileNamesWithExtension(fileExtension + ".xml")
+		if err != nil {
+			return
+		}
+		extractedFileNames = append(extractedFileNames, fileNames...)
+	}
+	sort.Strings(extractedFileNames)
+
+	dependencies = make([]*projectDependency, 0)
+
+	for _, extractedFileName := range extractedFileNames {
+
+		var contents []byte
+		contents, err = readExtractedXmlFile(extractedFileName)
+		if err != nil {
+			return
+		}
+
+		var root *ableton.Element
+		root, err = ableton.ParseElementFromBytes(contents)
+		if err != nil {
+			return
+		}
+
+		for _, fileRef := range ableton.GetFileRefs(root) {
+			dependencies = append(dependencies, newProjectDependency(extractedFileName, root, fileRef, libraries))
+		}
+
+	}
+
+	return
+
+}
+
+// Finds the file that the given reference refers to. Files relative to the project's folder
+// are preferred, since that is where Live looks first when the project has been moved.
+func newProjectDependency(extractedFileName string, extractedRoot *ableton.Element, fileRef *ableton.FileRef, libraries []*config.LibraryConfig) (dependency *projectDependency) {
+
+	dependency = &projectDependency{
+		ExtractedFileName: extractedFileName,
+		ExtractedRoot:     extractedRoot,
+		FileRef:           fileRef,
+		FilePath:          filepath.FromSlash(fileRef.Path),
+	}
+
+	if fileRef.IsRelativeToProject() {
+		relativeFilePath := util.JoinFilePath(filepath.Dir(extractedFileName), filepath.FromSlash(fileRef.RelativePath))
+		if util.DoesFileExist(relativeFilePath) && isFileInDirectory(relativeFilePath, ".") {
+			dependency.FilePath = relativeFilePath
+			dependency.Location = projectDependencyLocation
+			return
+		}
+		if dependency.FilePath == "" {
+			dependency.FilePath = relativeFilePath
+		}
+	} else if dependency.FilePath == "" {
+		dependency.FilePath = filepath.FromSlash(fileRef.RelativePath)
+	}
+
+	dependency.Library, dependency.LibraryFileName = getLibraryContainingFile(dependency.FilePath, libraries)
+
+	if !util.DoesFileExist(dependency.FilePath) {
+		dependency.Location = missingDependencyLocation
+	} else if dependency.Library != nil {
+		dependency.Location = libraryDependencyLocation
+	} else {
+		dependency.Location = externalDependencyLocation
+	}
+
+	return
+
+}
+
+// Returns the library that contains the given file, either in its own folder or in one of its worktrees, and the location
+// of the file relative to that folder, or nil if none of them do.
+func getLibraryContainingFile(filePath string, libraries []*config.LibraryConfig) (*config.LibraryConfig, string) {
+	for _, library := range libraries {
+		if libraryFileName, ok := getLibraryFileNameFromFilePath(filePath, library); ok {
+			return library, libraryFileName
+		}
+	}
+	return nil, ""
+}
+
+// Returns true if the given file is inside the given directory or any of its subdirectories.
+// Both paths must be absolute, or both must be relative to the current directory.
+func isFileInDirectory(filePath string, directoryName string) bool {
+	relativeFilePath, err := filepath.Rel(filepath.Clean(directoryName), filepath.Clean(filePath))
+	return err == nil &&
+		relativeFilePath != ".." &&
+		!strings.HasPrefix(relativeFilePath, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,178 @@
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+var abletonLiveSetWithFileRefsContents string = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="11.0_433" Creator="Ableton Live 11.3.4">
+	<LiveSet>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="3" />
+					<RelativePath Value="Samples/Imported/kick.wav" />
+					<Path Value="/Users/other/Music/Song Project/Samples/Imported/kick.wav" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/library/Drums/snare.wav" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/library/Drums/snare.wav" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<MxPatchRef Id="0">
+			<FileRef>
+				<RelativePathType Value="0" />
+				<RelativePath Value="" />
+				<Path Value="/home/testuser/Max Devices/LFO.amxd" />
+			</FileRef>
+		</MxPatchRef>
+		<FilePresetRef Id="1">
+			<FileRef>
+				<RelativePathType Value="3" />
+				<RelativePath Value="Presets/Lead.adv" />
+				<Path Value="/Users/other/Music/Song Project/Presets/Lead.adv" />
+			</FileRef>
+		</FilePresetRef>
+	</LiveSet>
+</Ableton>
+`
+
+func TestProjectDepsCmd(t *testing.T) {
+
+	testCases := []*ProjectDepsCmdTestCase{
+
+		&ProjectDepsCmdTestCase{
+			description: "Test that referenced files are listed by whether they are inside the project, inside a library, elsewhere, or missing.",
+			args:        []string{"project", "deps"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.als.xml").
+								SetContentsFromString(abletonLiveSetWithFileRefsContents),
+							utiltest.GetEmptyFileBuilder().
+								SetFilePath("Samples/Imported/kick.wav"),
+							utiltest.GetEmptyFileBuilder().
+								SetFilePath("/home/testuser/library/Drums/snare.wav"),
+							utiltest.GetEmptyFileBuilder().
+								SetFilePath("/home/testuser/Max Devices/LFO.amxd"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.als.xml").
+						SetContentsFromString(abletonLiveSetWithFileRefsContents).
+						SetWasClosed(true),
+					utiltest.GetEmptyFileBuilder().
+						SetFilePath("Samples/Imported/kick.wav"),
+					utiltest.GetEmptyFileBuilder().
+						SetFilePath("/home/testuser/library/Drums/snare.wav"),
+					utiltest.GetEmptyFileBuilder().
+						SetFilePath("/home/testuser/Max Devices/LFO.amxd"),
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Files inside the project:\n" +
+							"\tSamples/Imported/kick.wav (sample, used by song.als)\n" +
+							"Files inside libraries:\n" +
+							"\t/home/testuser/library/Drums/snare.wav (sample, used by song.als)\n" +
+							"Files outside of the project and its libraries:\n" +
+							"\t/home/testuser/Max Devices/LFO.amxd (Max device, used by song.als)\n" +
+							"Missing files:\n" +
+							"\t/Users/other/Music/Song Project/Presets/Lead.adv (preset, used by song.als)\n" +
+							"Libraries used by the project:\n" +
+							"\t/home/testuser/library\n",
+					),
+				),
+		},
+
+		&ProjectDepsCmdTestCase{
+			description: "Test that a message is displayed if the project doesn't refer to any external files.",
+			args:        []string{"project", "deps"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("The project does not refer to any external files.\n"),
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+// ------------------------------------------------------------------------------
+
+type ProjectDepsCmdTestCase struct {
+	description                              string
+	args                                     []string
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *ProjectDepsCmdTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	cmd.RootCmd.SetArgs(testCase.args)
+	cmd.RootCmd.Execute()
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	ProjectCmd.AddCommand(DiffCmd)
+}
+
+var DiffCmd = &cobra.Command{
+
+	Use: "diff <revision-1> <revision-2> [file]",
+
+	Short: "Shows the musical changes made to extracted Ableton Live Sets between two revisions.",
+
+	Long: `Shows the musical changes made to extracted Ableton Live Sets between two revisions,
+such as added, removed, or renamed tracks, inserted devices, edited clips, and tempo or time signature changes.
+
+Revisions can be anything understood by git, such as commit IDs, branch names, or 'HEAD~1'.
+If no file is given, all extracted files that were modified between the two revisions are compared,
+and Live Sets that were added or removed between them are listed.`,
+
+	Args: cobra.RangeArgs(2, 3),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := diffProjectRevisions(args[0], args[1], args[2:]...); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func diffProjectRevisions(revision1 string, revision2 string, fileNames ...string) (err error) {
+
+	gitRepoFilePath := "."
+	gitManager := util.NewGitManager(gitRepoFilePath)
+
+	// Files that are given are assumed to exist in both revisions.
+	fileStatuses := make(map[string]string)
+
+	if len(fileNames) == 0 {
+		fileNames, fileStatuses, err = getChangedExtractedFileNames(gitManager, revision1, revision2)
+		if err != nil {
+			return
+		}
+	}
+
+	for _, fileName := range fileNames {
+
+		if !strings.HasSuffix(fileName, ".xml") {
+			fileName += ".xml"
+		}
+
+		switch fileStatuses[fileName] {
+		case "A":
+			util.Println(fileName)
+			util.Println("\tAdded Live Set")
+		case "D":
+			util.Println(fileName)
+			util.Println("\tRemoved Live Set")
+		default:
+			err = diffExtractedFileRevisions(gitManager, revision1, revision2, fileName)
+			if err != nil {
+				return
+			}
+		}
+
+	}
+
+	return
+
+}
+
+// Returns the extracted files that were added, modified, or deleted between the two revisions, and the type of
+// each change, as shown by 'git diff --name-status', such as "A" (added), "D" (deleted), or "M" (modified).
+// The output is separated by NUL characters, so that git doesn't quote file names with non-ASCII characters.
+func getChangedExtractedFileNames(gitManager util.GitManager, revision1 string, revision2 string) (fileNames []string, fileStatuses map[string]string, err error) {
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	gitDiffArgs := []string{"--name-status", "-z", "--diff-filter=AMD", revision1, revision2, "--"}
+	for _, fileExtension := range applications.AbletonInfo.FilterCompressedXmlFileExtensions(filePatternsConfig.CompressedXmlFileExtensions) {
+		gitDiffArgs = append(gitDiffArgs, "*."+fileExtension+".xml")
+	}
+	for _, fileExtension := range filePatternsConfig.SplitXmlFileExtensions {
+		gitDiffArgs = append(gitDiffArgs, "*."+getSplitXmlPartsDirectoryName(fileExtension)+"/*")
+	}
+
+	stdout, err := gitManager.Diff(gitDiffArgs...)
+	if err != nil {
+		return
+	}
+
+	fileNames = make([]string, 0)
+	fileStatuses = make(map[string]string)
+
+	// Each status is followed by the name of the file it applies to.
+	fields := strings.Split(stdout, "\x00")
+	for i := 0; i+1 < len(fields); i += 2 {
+
+		status, fileName := strings.TrimSpace(fields[i]), fields[i+1]
+		if status == "" || fileName == "" {
+			continue
+		}
+
+		// Changes to the tracks of split files are shown as changes to the files they were split from.
+		// Adding or removing a track only modifies the file it was split from, unless the file itself was added or removed.
+		isPartFileName := false
+		for _, fileExtension := range filePatternsConfig.SplitXmlFileExtensions {
+			partsDirectorySuffix := "." + getSplitXmlPartsDirectoryName(fileExtension) + "/"
+			if partsDirectoryIndex := strings.LastIndex(fileName, partsDirectorySuffix); partsDirectoryIndex >= 0 {
+				fileName = fileName[:partsDirectoryIndex] + "." + fileExtension + ".xml"
+				isPartFileName = true
+			}
+		}
+
+		if !containsString(fileNames, fileName) {
+			fileNames = append(fileNames, fileName)
+		}
+
+		if !isPartFileName {
+			fileStatuses[fileName] = status
+		} else if _, ok := fileStatuses[fileName]; !ok {
+			fileStatuses[fileName] = "M"
+		}
+
+	}
+
+	return
+
+}
+
+func diffExtractedFileRevisions(gitManager util.GitManager, revision1 string, revision2 string, fileName string) (err error) {
+
+	oldFileContents, err := showExtractedFileRevision(gitManager, revision1, fileName)
+	if err != nil {
+		return
+	}
+
+	newFileContents, err := showExtractedFileRevision(gitManager, revision2, fileName)
+	if err != nil {
+		return
+	}
+
+	util.Println(fileName)
+
+	oldLiveSet, err := ableton.ParseLiveSet(bytes.NewReader(oldFileContents))
+	if err != nil {
+		util.Println("\t" + err.Error())
+		return nil
+	}
+
+	newLiveSet, err := ableton.ParseLiveSet(bytes.NewReader(newFileContents))
+	if err != nil {
+		util.Println("\t" + err.Error())
+		return nil
+	}
+
+	changes := ableton.DiffLiveSets(oldLiveSet, newLiveSet)
+	if len(changes) == 0 {
+		util.Println("\tNo musical changes.")
+	}
+	for _, change := range changes {
+		util.Println("\t" + change)
+	}
+
+	return
+
+}
+
+// Returns the contents of the given extracted file at the given revision. If the file was split,
+// then its parts from the same revision are joined back into it. Files are read from stdout only,
+// so that any warnings that git writes to stderr aren't parsed as part of them.
+func showExtractedFileRevision(gitManager util.GitManager, revision string, fileName string) (contents []byte, err error) {
+
+	contents, err = gitManager.CatFileContents("blob", revision+":"+fileName)
+	if err != nil || !ableton.HasPartReferences(contents) {
+		return
+	}
+
+	globalSettings, err := ableton.ParseElementFromBytes(contents)
+	if err != nil {
+		return
+	}
+
+	// Paths in git revisions are always separated by slashes.
+	partsDirectoryName := getSplitXmlPartsDirectoryName(fileName)
+	root, err := ableton.JoinLiveSet(
+		globalSettings,
+		func(partFileName string) (*ableton.Element, error) {
+			partContents, err := gitManager.CatFileContents("blob", revision+":"+partsDirectoryName+"/"+partFileName)
+			if err != nil {
+				return nil, err
+			}
+			return ableton.ParseElementFromBytes(partContents)
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	contents = root.AsXmlDocument()
+	return
+
+}
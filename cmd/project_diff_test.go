@@ -0,0 +1,250 @@
+package cmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stevengt/mppm/ableton/abletontest"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+func TestProjectDiffCmd(t *testing.T) {
+
+	testCases := []*ProjectDiffCmdTestCase{
+
+		&ProjectDiffCmdTestCase{
+			description: "Test that musical changes are displayed for all modified Live Sets if no file is given.",
+			args:        []string{"project", "diff", "HEAD~1", "HEAD"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetDiffStdout("M\x00song.als.xml\x00").
+						SetCatFileStdouts(
+							map[string]string{
+								"blob HEAD~1:song.als.xml": abletontest.OriginalLiveSetXml,
+								"blob HEAD:song.als.xml":   abletontest.ModifiedLiveSetXml,
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"diff", "--name-status", "-z", "--diff-filter=AMD", "HEAD~1", "HEAD", "--", "*.adg.xml", "*.adv.xml", "*.alc.xml", "*.als.xml"},
+							[]string{"cat-file", "blob", "HEAD~1:song.als.xml"},
+							[]string{"cat-file", "blob", "HEAD:song.als.xml"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("song.als.xml\n\t" + strings.Join(abletontest.OriginalToModifiedLiveSetChanges, "\n\t") + "\n"),
+				),
+		},
+
+		&ProjectDiffCmdTestCase{
+			description: "Test that Live Sets with non-ASCII characters in their names are compared, since git doesn't quote their names.",
+			args:        []string{"project", "diff", "HEAD~1", "HEAD"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetDiffStdout("M\x00Canción.als.xml\x00").
+						SetCatFileStdouts(
+							map[string]string{
+								"blob HEAD~1:Canción.als.xml": abletontest.OriginalLiveSetXml,
+								"blob HEAD:Canción.als.xml":   abletontest.ModifiedLiveSetXml,
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"diff", "--name-status", "-z", "--diff-filter=AMD", "HEAD~1", "HEAD", "--", "*.adg.xml", "*.adv.xml", "*.alc.xml", "*.als.xml"},
+							[]string{"cat-file", "blob", "HEAD~1:Canción.als.xml"},
+							[]string{"cat-file", "blob", "HEAD:Canción.als.xml"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Canción.als.xml\n\t" + strings.Join(abletontest.OriginalToModifiedLiveSetChanges, "\n\t") + "\n"),
+				),
+		},
+
+		&ProjectDiffCmdTestCase{
+			description: "Test that changes to the tracks of split Live Sets are shown as changes to the Live Sets they were split from.",
+			args:        []string{"project", "diff", "HEAD~1", "HEAD"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndSplitAbletonApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetDiffStdout("M\x00song.als.parts/master.xml\x00M\x00song.als.parts/tracks/1-MIDI.xml\x00").
+						SetCatFileStdouts(
+							map[string]string{
+								"blob HEAD~1:song.als.xml":                     abletontest.SplitOriginalLiveSetXml,
+								"blob HEAD~1:song.als.parts/tracks/1-MIDI.xml": abletontest.SplitOriginalLiveSetMidiTrackXml,
+								"blob HEAD~1:song.als.parts/master.xml":        abletontest.SplitOriginalLiveSetMasterTrackXml,
+								"blob HEAD:song.als.xml":                       abletontest.ModifiedLiveSetXml,
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndSplitAbletonApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"diff", "--name-status", "-z", "--diff-filter=AMD", "HEAD~1", "HEAD", "--", "*.adg.xml", "*.adv.xml", "*.alc.xml", "*.als.xml", "*.als.parts/*"},
+							[]string{"cat-file", "blob", "HEAD~1:song.als.xml"},
+							[]string{"cat-file", "blob", "HEAD~1:song.als.parts/tracks/1-MIDI.xml"},
+							[]string{"cat-file", "blob", "HEAD~1:song.als.parts/master.xml"},
+							[]string{"cat-file", "blob", "HEAD:song.als.xml"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("song.als.xml\n\t" + strings.Join(abletontest.OriginalToModifiedLiveSetChanges, "\n\t") + "\n"),
+				),
+		},
+
+		&ProjectDiffCmdTestCase{
+			description: "Test that Live Sets that were added or removed between the revisions are listed, including split Live Sets.",
+			args:        []string{"project", "diff", "HEAD~1", "HEAD"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndSplitAbletonApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetDiffStdout("A\x00new.als.parts/master.xml\x00A\x00new.als.xml\x00D\x00old.als.xml\x00"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndSplitAbletonApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"diff", "--name-status", "-z", "--diff-filter=AMD", "HEAD~1", "HEAD", "--", "*.adg.xml", "*.adv.xml", "*.alc.xml", "*.als.xml", "*.als.parts/*"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("new.als.xml\n\tAdded Live Set\nold.als.xml\n\tRemoved Live Set\n"),
+				),
+		},
+
+		&ProjectDiffCmdTestCase{
+			description: "Test that a given binary file name is compared using its extracted XML file.",
+			args:        []string{"project", "diff", "HEAD~1", "HEAD", "song.als"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetCatFileStdouts(
+							map[string]string{
+								"blob HEAD~1:song.als.xml": abletontest.OriginalLiveSetXml,
+								"blob HEAD:song.als.xml":   abletontest.OriginalLiveSetXml,
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"cat-file", "blob", "HEAD~1:song.als.xml"},
+							[]string{"cat-file", "blob", "HEAD:song.als.xml"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("song.als.xml\n\tNo musical changes.\n"),
+				),
+		},
+
+		&ProjectDiffCmdTestCase{
+			description: "Test that any error from 'git cat-file' is properly raised.",
+			args:        []string{"project", "diff", "HEAD~1", "HEAD", "song.als.xml"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetUseDefaultCatFileError(true),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterWasExited(true).
+				SetExiterError(utiltest.DefaultCatFileError).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"cat-file", "blob", "HEAD~1:song.als.xml"},
+						},
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+type ProjectDiffCmdTestCase struct {
+	description                              string
+	args                                     []string
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *ProjectDiffCmdTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	cmd.RootCmd.SetArgs(testCase.args)
+	cmd.RootCmd.Execute()
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
@@ -0,0 +1,280 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	ProjectCmd.AddCommand(ExportCmd)
+}
+
+var ExportCmd = &cobra.Command{
+
+	Use: "export <bundle file>",
+
+	Short: "Creates a self-contained bundle of the project, including the library files it uses.",
+
+	Long: `Creates a self-contained bundle of the project, including the library files it uses.
+
+The bundle contains every file in the project that isn't ignored by git, and every file in a library that
+the project's Ableton Live Sets refer to (see 'mppm project deps'). Library files are taken from the
+versions of the libraries in the project config file, even if different versions are checked out.
+To update those versions first, run 'mppm project --update-libraries'.
+
+The bundle can be unpacked on another system with 'mppm project import'.
+The type of bundle is determined by its file extension, which can be one of: ` + strings.Join(util.ArchiveFileExtensions, ", ") + `
+Creating .tar.zst bundles requires the zstd command to be installed.`,
+
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := exportProject(args[0]); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func exportProject(bundleFileName string) (err error) {
+
+	if !util.IsArchiveFileName(bundleFileName) {
+		err = errors.New("Unable to create '" + bundleFileName + "' because its file type is not supported. Supported file types are: " + strings.Join(util.ArchiveFileExtensions, ", "))
+		return
+	}
+
+	projectConfig, globalConfig, err := configManager.GetProjectAndGlobalConfigs()
+	if err != nil {
+		return
+	}
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	projectFileNames, err := getExportedProjectFileNames(bundleFileName)
+	if err != nil {
+		return
+	}
+
+	// Libraries that are only in the global config file are still checked, so that references to them can be reported.
+	libraries := append([]*config.LibraryConfig{}, projectConfig.Libraries...)
+	for _, globalLibrary := range globalConfig.Libraries {
+		if getLibraryWithFilePath(globalLibrary.FilePath, projectConfig.Libraries) == nil {
+			libraries = append(libraries, globalLibrary)
+		}
+	}
+
+	dependencies, err := getProjectDependencies(filePatternsConfig, libraries)
+	if err != nil {
+		return
+	}
+
+	manifest := config.NewBundleManifest()
+	excludedLines := make([]string, 0)
+
+	// Each file is written to the bundle as soon as it is read, so that only one file is held in memory at a time.
+	writeBundledFiles := func(writeFile util.ArchivedFileWriter) (err error) {
+
+		excludedLines, err = writeBundledLibraryFiles(writeFile, dependencies, projectConfig.Libraries, manifest)
+		if err != nil {
+			return
+		}
+
+		manifestAsJson, err := manifest.AsJson()
+		if err != nil {
+			return
+		}
+
+		err = writeFile(config.BundleManifestFileName, manifestAsJson)
+		if err != nil {
+			return
+		}
+
+		for _, fileName := range projectFileNames {
+			var contents []byte
+			contents, err = util.ReadFile(fileName)
+			if err != nil {
+				return
+			}
+			err = writeFile(config.GetBundledProjectFileName(filepath.ToSlash(fileName)), contents)
+			if err != nil {
+				return
+			}
+		}
+
+		return
+
+	}
+
+	if isPreviewCommand {
+		err = writeBundledFiles(func(archivedFileName string, contents []byte) error { return nil })
+	} else {
+		err = util.CreateArchiveFile(bundleFileName, writeBundledFiles)
+	}
+	if err != nil {
+		return
+	}
+
+	summaryLines := []string{getFileCountDescription(len(projectFileNames)) + " from the project"}
+	for _, bundledLibrary := range manifest.Libraries {
+		summaryLines = append(summaryLines, getFileCountDescription(len(bundledLibrary.Files))+" from "+bundledLibrary.OriginalFilePath+" at version "+bundledLibrary.OriginalGitCommitId)
+	}
+
+	if isPreviewCommand {
+		printProjectStatusSection("Files that will be exported to "+bundleFileName+":", summaryLines)
+	} else {
+		printProjectStatusSection("Exported to "+bundleFileName+":", summaryLines)
+	}
+	printProjectStatusSection("Files that could not be included:", excludedLines)
+
+	return
+
+}
+
+// Writes the library files that the given dependencies refer to into a bundle, and adds them to the given manifest.
+// Returns descriptions of the dependencies that could not be included.
+func writeBundledLibraryFiles(writeFile util.ArchivedFileWriter, dependencies []*projectDependency, projectLibraries []*config.LibraryConfig, manifest *config.BundleManifest) (excludedLines []string, err error) {
+
+	bundledLibraries := make(map[*config.LibraryConfig]*config.BundleLibrary)
+	excludedLines = make([]string, 0)
+
+	for _, dependency := range dependencies {
+
+		if dependency.Location == projectDependencyLocation {
+			continue
+		}
+
+		description := dependency.FilePath + " (used by " + strings.TrimSuffix(dependency.ExtractedFileName, ".xml") + ")"
+
+		if dependency.Library == nil {
+			if dependency.Location == missingDependencyLocation {
+				excludedLines = appendIfMissing(excludedLines, description+": The file does not exist.")
+			} else {
+				excludedLines = appendIfMissing(excludedLines, description+": The file is outside of the project and its libraries. To include it, run 'mppm project collect' first.")
+			}
+			continue
+		}
+
+		if getLibraryWithFilePath(dependency.Library.FilePath, projectLibraries) == nil {
+			excludedLines = appendIfMissing(excludedLines, description+": The project config file doesn't specify a version of the library. To add it, run 'mppm project --update-libraries'.")
+			continue
+		}
+
+		bundledLibrary, ok := bundledLibraries[dependency.Library]
+		if !ok {
+			bundledLibrary = &config.BundleLibrary{
+				Name:                getBundledLibraryName(dependency.Library.FilePath, manifest.Libraries),
+				OriginalFilePath:    dependency.Library.FilePath,
+				OriginalGitCommitId: dependency.Library.CurrentGitCommitId,
+				Files:               make([]string, 0),
+			}
+		}
+
+		libraryFileName := dependency.LibraryFileName
+		if containsString(bundledLibrary.Files, libraryFileName) {
+			continue
+		}
+
+		// Read the file from the library's git repository, so that the version in the project config file is
+		// used even if a different version is checked out. The filters replace git lfs pointers with the files.
+		gitManager := util.NewGitManager(dependency.Library.FilePath)
+		contents, catFileErr := gitManager.CatFileContents("--filters", bundledLibrary.OriginalGitCommitId+":"+libraryFileName)
+		if catFileErr != nil {
+			excludedLines = appendIfMissing(excludedLines, description+": The file does not exist in version "+bundledLibrary.OriginalGitCommitId+" of the library.")
+			continue
+		}
+
+		if !ok {
+			bundledLibraries[dependency.Library] = bundledLibrary
+			manifest.Libraries = append(manifest.Libraries, bundledLibrary)
+		}
+		bundledLibrary.Files = append(bundledLibrary.Files, libraryFileName)
+
+		err = writeFile(bundledLibrary.GetBundledFileName(libraryFileName), contents)
+		if err != nil {
+			return
+		}
+
+	}
+
+	for _, bundledLibrary := range manifest.Libraries {
+		sort.Strings(bundledLibrary.Files)
+	}
+
+	return
+
+}
+
+// Returns the sorted names of all files in the project that aren't ignored by git, except the given bundle
+// file, in case it is inside the project.
+func getExportedProjectFileNames(bundleFileName string) (fileNames []string, err error) {
+
+	gitManager := util.NewGitManager(".")
+	stdout, err := gitManager.LsFiles("--cached", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return
+	}
+
+	fileNames = make([]string, 0)
+	for _, fileName := range strings.Split(stdout, "\x00") {
+		fileName = filepath.FromSlash(fileName)
+		if fileName == "" || filepath.Clean(fileName) == filepath.Clean(bundleFileName) || !util.DoesFileExist(fileName) {
+			continue
+		}
+		fileNames = appendIfMissing(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	return
+
+}
+
+// Returns a name for the given library's directory within the bundle that isn't used by any other library yet.
+func getBundledLibraryName(libraryFilePath string, bundledLibraries []*config.BundleLibrary) (name string) {
+
+	baseName := filepath.Base(filepath.Clean(libraryFilePath))
+
+	for i := 1; ; i++ {
+
+		name = baseName
+		if i > 1 {
+			name = fmt.Sprintf("%s (%d)", baseName, i)
+		}
+
+		isNameUsed := false
+		for _, bundledLibrary := range bundledLibraries {
+			isNameUsed = isNameUsed || bundledLibrary.Name == name
+		}
+		if !isNameUsed {
+			return
+		}
+
+	}
+
+}
+
+// Returns the given number of files as a phrase, such as "1 file" or "2 files".
+func getFileCountDescription(numberOfFiles int) string {
+	if numberOfFiles == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", numberOfFiles)
+}
+
+// Returns the library with the given location, or nil if there isn't one.
+func getLibraryWithFilePath(filePath string, libraries []*config.LibraryConfig) *config.LibraryConfig {
+	for _, library := range libraries {
+		if library.FilePath == filePath {
+			return library
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package cmd_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+var exportedBundleManifestContents string = `{
+	"version": "` + config.Version + `",
+	"libraries": [
+		{
+			"name": "library",
+			"location": "/home/testuser/library",
+			"version": "56789",
+			"files": [
+				"Drums/hat.wav"
+			]
+		}
+	]
+}`
+
+func getExportedBundleFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("bundle.zip").
+		SetContentsFromBytes(
+			utiltest.GetZipFileContents(
+				map[string]string{
+					"mppm-bundle.json":                exportedBundleManifestContents,
+					"project/.mppm.json":              string(configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.ConfigAsJson),
+					"project/song.als":                string(utiltest.GetGzippedContents(abletonLiveSetWithExternalFilesContents)),
+					"project/song.als.xml":            abletonLiveSetWithExternalFilesContents,
+					"libraries/library/Drums/hat.wav": "hat at version 56789",
+				},
+			),
+		)
+}
+
+func TestProjectExportCmd(t *testing.T) {
+
+	testCases := []*ProjectCmdTestCase{
+
+		&ProjectCmdTestCase{
+			description: "Test that the project and the library files it refers to, at the versions in the project config file, are bundled together.",
+			args:        []string{"project", "export", "bundle.zip"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							getAbletonLiveSetWithExternalFilesFileBuilder(),
+							getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
+							getExternalKickFileBuilder(),
+							getLibraryHatFileBuilder(),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetLsFilesStdout(".mppm.json\x00song.als\x00song.als.xml\x00deleted.als\x00").
+						SetCatFileStdouts(
+							map[string]string{
+								"--filters 56789:Drums/hat.wav": "hat at version 56789",
+							},
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					getExternalKickFileBuilder(),
+					getLibraryHatFileBuilder(),
+					getExportedBundleFileBuilder().
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"ls-files", "--cached", "--others", "--exclude-standard", "-z"},
+							[]string{"cat-file", "--filters", "56789:Drums/hat.wav"},
+						},
+						"/home/testuser/library": [][]string{
+							[]string{"ls-files", "--cached", "--others", "--exclude-standard", "-z"},
+							[]string{"cat-file", "--filters", "56789:Drums/hat.wav"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Exported to bundle.zip:\n" +
+							"\t3 files from the project\n" +
+							"\t1 file from /home/testuser/library at version 56789\n" +
+							"Files that could not be included:\n" +
+							"\t/home/testuser/Downloads/kick.wav (used by song.als): The file is outside of the project and its libraries. To include it, run 'mppm project collect' first.\n" +
+							"\t/home/testuser/Downloads/snare.aif (used by song.als): The file does not exist.\n" +
+							"\t/Users/other/clap.wav (used by song.als): The file does not exist.\n",
+					),
+				),
+		},
+
+		&ProjectCmdTestCase{
+			description: "Test that an error is raised for unsupported bundle file types.",
+			args:        []string{"project", "export", "bundle.rar"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("Unable to create 'bundle.rar' because its file type is not supported. Supported file types are: .zip, .tar, .tar.zst")),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
@@ -1,14 +1,39 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/ableton"
 	"github.com/stevengt/mppm/config"
 	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/reaper"
 	"github.com/stevengt/mppm/util"
 )
 
 func init() {
+
+	cobra.OnInitialize(
+		func() {
+			shouldSplitPluginStateChunks, _ = ExtractCmd.Flags().GetBool("split-plugin-state")
+		},
+	)
+
+	ExtractCmd.Flags().BoolVar(
+		&shouldSplitPluginStateChunks,
+		"split-plugin-state",
+		false,
+		`Also writes a copy of each plain-text project file, such as Song.RPP for REAPER, to Song.RPP.split
+with its base64-encoded plugin states moved into separate files in Song.RPP.chunks, so that diffs stay readable.
+The project files themselves are not changed. Commit the split copies and their chunks. The project files
+can then be added to .gitignore, since 'mppm project restore' recreates them from the split copies.`,
+	)
+
 	ProjectCmd.AddCommand(ExtractCmd)
+
 }
 
 var ExtractCmd = &cobra.Command{
@@ -21,7 +46,25 @@ var ExtractCmd = &cobra.Command{
 This saves space within the git repository, and enables easier side-by-side comparison of different versions of the files.
 			
 Note that the original files are not stored in git directly.
-To restore the original files, run 'mppm project restore'.`,
+To restore the original files, run 'mppm project restore'.
+
+Some applications, such as Ableton Live, change parts of their XML files every time they are saved,
+even if nothing else changed. To extract those files in a canonical form instead, so that commits only
+show real changes, set "canonicalize-xml" to true for the application in the project config file, for example:
+
+	{"name": "Ableton", "version": "11", "canonicalize-xml": true}
+
+The restored files can still be opened by the application, but the changed values are reset.
+
+Large Ableton Live Sets can also be extracted with each track in a separate file, so that the history of
+a single track can be followed with 'git log' and merge conflicts are limited to the tracks that changed.
+To do this, set "split-xml" to true for the application in the project config file, for example:
+
+	{"name": "Ableton", "version": "11", "split-xml": true}
+
+Each track, return track, and the master track of a Live Set such as Song.als is then extracted into
+the Song.als.parts directory, for example Song.als.parts/tracks/Bass.xml, and Song.als.xml only keeps
+the global settings and the order of the tracks. They are joined together again by 'mppm project restore'.`,
 
 	Args: cobra.NoArgs,
 
@@ -39,58 +82,589 @@ func extractAllCompressedFiles() (err error) {
 		return
 	}
 
-	err = extractAllGzippedXmlFiles(filePatternsConfig)
+	manifest, err := config.LoadExtractionManifest()
+	if err != nil {
+		return
+	}
+
+	err = extractAllCompressedXmlFiles(filePatternsConfig, manifest)
+
+	// Record any files that were extracted, even if others couldn't be.
+	if !isPreviewCommand {
+		if saveErr := manifest.Save(); err == nil {
+			err = saveErr
+		}
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = extractAllZippedFiles(filePatternsConfig, manifest)
 	if err != nil {
 		return
 	}
 
+	if shouldSplitPluginStateChunks {
+		err = splitAllPluginStateChunkFiles(filePatternsConfig, manifest)
+		if err != nil {
+			return
+		}
+	}
+
 	return
 }
 
-func extractAllGzippedXmlFiles(filePatternsConfig *applications.FilePatternsConfig) (err error) {
-	gzippedXmlFileExtensions := filePatternsConfig.GzippedXmlFileExtensions
-	for _, fileExtension := range gzippedXmlFileExtensions {
-		err = extractAllGzippedXmlFilesWithExtension(fileExtension)
+var shouldSplitPluginStateChunks bool
+
+// Extracts all compressed XML files concurrently, using up to the number of jobs given by --jobs.
+func extractAllCompressedXmlFiles(filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	tasks := make([]*util.Task, 0)
+
+	for _, fileExtension := range filePatternsConfig.CompressedXmlFileExtensions {
+		var extensionTasks []*util.Task
+		codec := filePatternsConfig.GetCompressedXmlFileCodec(fileExtension)
+		extensionTasks, err = getExtractCompressedXmlFileTasks(fileExtension, codec, filePatternsConfig, manifest)
 		if err != nil {
 			return
 		}
+		tasks = append(tasks, extensionTasks...)
 	}
+
+	err = util.RunTasks(numberOfJobs, tasks)
 	return
+
 }
 
-func extractAllGzippedXmlFilesWithExtension(fileExtension string) (err error) {
+func getExtractCompressedXmlFileTasks(fileExtension string, codec util.CompressionCodec, filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (tasks []*util.Task, err error) {
 
 	fileNames, err := util.GetAllFileNamesWithExtension(fileExtension)
 	if err != nil {
 		return
 	}
+	sort.Strings(fileNames)
+
+	tasks = make([]*util.Task, 0, len(fileNames))
+
+	for _, fileName := range fileNames {
 
-	for _, originalFileName := range fileNames {
-		gzippedFileName := originalFileName + ".xml.gz"
+		originalFileName := fileName
 		newFileName := originalFileName + ".xml"
 
-		if isPreviewCommand {
-			printExtractPreviewMessage(originalFileName, newFileName)
+		var isUpToDate bool
+		isUpToDate, err = isExtractedFileUpToDate(manifest, originalFileName, newFileName, config.GetExtractionSettings(originalFileName, filePatternsConfig))
+		if err != nil {
+			return
+		}
+		if isUpToDate {
+			continue
+		}
+
+		task := &util.Task{
+			Name: originalFileName,
+			Run: func(printer util.WritePrinter) (err error) {
+				return extractCompressedXmlFile(printer, originalFileName, newFileName, codec, filePatternsConfig, manifest)
+			},
+		}
+		if !isPreviewCommand {
+			task.ProgressMessage = "Extracted " + originalFileName
+		}
+
+		tasks = append(tasks, task)
+
+	}
+
+	return
+
+}
+
+func extractCompressedXmlFile(printer util.WritePrinter, originalFileName string, newFileName string, codec util.CompressionCodec, filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	if isPreviewCommand {
+		if filePatternsConfig.IsSplitXmlFile(originalFileName) {
+			printExtractPreviewMessage(printer, originalFileName, newFileName+" and "+getSplitXmlPartsDirectoryName(newFileName))
 		} else {
+			printExtractPreviewMessage(printer, originalFileName, newFileName)
+		}
+		return
+	}
+
+	contents, err := readExtractedXmlContents(originalFileName, codec, filePatternsConfig)
+	if err != nil {
+		return
+	}
+
+	err = writeExtractedXmlFile(originalFileName, newFileName, contents, filePatternsConfig)
+	if err != nil {
+		return
+	}
+
+	err = warnIfAbletonVersionDoesNotMatchProjectConfig(printer, originalFileName, contents)
+	if err != nil {
+		return
+	}
+
+	err = updateExtractionManifest(manifest, originalFileName, newFileName, config.GetExtractionSettings(originalFileName, filePatternsConfig))
+	return
+
+}
+
+// Writes the given contents of an extracted XML file. If XML splitting is enabled for the binary file's type,
+// then each track is written to a separate file in the split XML parts directory instead, and only the global
+// settings are written to the extracted file.
+func writeExtractedXmlFile(compressedFileName string, extractedFileName string, contents []byte, filePatternsConfig *applications.FilePatternsConfig) (err error) {
+
+	// Remove any previously split parts, in case tracks were renamed or removed, or splitting was disabled.
+	partsDirectoryName := getSplitXmlPartsDirectoryName(extractedFileName)
+	if util.DoesFileExist(partsDirectoryName) {
+		err = util.RemoveFile(partsDirectoryName)
+		if err != nil {
+			return
+		}
+	}
+
+	if !filePatternsConfig.IsSplitXmlFile(compressedFileName) {
+		err = util.WriteFile(extractedFileName, contents)
+		return
+	}
+
+	root, err := ableton.ParseElementFromBytes(contents)
+	if err != nil {
+		return
+	}
+
+	globalSettings, parts, err := ableton.SplitLiveSet(root)
+	if err != nil {
+		return
+	}
+
+	for _, part := range parts {
+		err = util.WriteFile(util.JoinFilePath(partsDirectoryName, part.FileName), part.Element.AsXmlDocument())
+		if err != nil {
+			return
+		}
+	}
+
+	err = util.WriteFile(extractedFileName, globalSettings.AsXmlDocument())
+	return
+
+}
+
+// Returns the contents of the given extracted XML file. If it was split, then its parts are joined back into it.
+func readExtractedXmlFile(extractedFileName string) (contents []byte, err error) {
+
+	contents, err = util.ReadFile(extractedFileName)
+	if err != nil {
+		return
+	}
+
+	partsDirectoryName := getSplitXmlPartsDirectoryName(extractedFileName)
+	if !util.DoesFileExist(partsDirectoryName) {
+		return
+	}
+
+	globalSettings, err := ableton.ParseElementFromBytes(contents)
+	if err != nil {
+		return
+	}
+
+	root, err := ableton.JoinLiveSet(
+		globalSettings,
+		func(partFileName string) (*ableton.Element, error) {
+			partContents, err := util.ReadFile(util.JoinFilePath(partsDirectoryName, partFileName))
+			if err != nil {
+				return nil, err
+			}
+			return ableton.ParseElementFromBytes(partContents)
+		},
+	)
+	if err != nil {
+		return
+	}
+
+	contents = root.AsXmlDocument()
+	return
+
+}
+
+// Returns the names of all files in the split XML parts directory of the given extracted XML file,
+// or nil if it wasn't split.
+func getSplitXmlPartFileNames(extractedFileName string) (partFileNames []string, err error) {
+
+	partsDirectoryName := getSplitXmlPartsDirectoryName(extractedFileName)
+	if !util.DoesFileExist(partsDirectoryName) {
+		return
+	}
+
+	partFileNames, err = util.GetAllFileNamesInDirectory(partsDirectoryName)
+	if err != nil {
+		return
+	}
+	sort.Strings(partFileNames)
+
+	return
+
+}
+
+// Returns the directory that the tracks of the given extracted XML file are written to when it is split.
+func getSplitXmlPartsDirectoryName(extractedFileName string) string {
+	return strings.TrimSuffix(extractedFileName, ".xml") + ".parts"
+}
+
+// Records the current states of the given binary file, its extracted file, and any split parts of the extracted file.
+func updateExtractionManifest(manifest *config.ExtractionManifest, sourceFileName string, extractedFileName string, settings *config.ExtractionSettings) (err error) {
+
+	partFileNames, err := getSplitXmlPartFileNames(extractedFileName)
+	if err != nil {
+		return
+	}
+
+	err = manifest.Update(sourceFileName, extractedFileName, settings, partFileNames...)
+	return
+
+}
+
+// Returns the contents that the given compressed XML file is extracted to. If XML canonicalization
+// is enabled for the file's type, then the contents are canonicalized. If XML splitting is enabled,
+// then the contents are indented the same way as split files are when they are joined again.
+//
+// References to files in the worktrees that the project has checked libraries out into are changed to refer
+// to the libraries' own folders, since worktrees are only used by this copy of the project.
+func readExtractedXmlContents(compressedFileName string, codec util.CompressionCodec, filePatternsConfig *applications.FilePatternsConfig) (contents []byte, err error) {
+
+	contents, err = util.ReadCompressedFile(compressedFileName, codec)
+	if err != nil {
+		return
+	}
+
+	worktrees, err := getLibraryWorktreesForXmlFile(compressedFileName)
+	if err != nil {
+		return
+	}
+
+	shouldCanonicalize := filePatternsConfig.IsCanonicalizedXmlFile(compressedFileName)
+	shouldSplit := filePatternsConfig.IsSplitXmlFile(compressedFileName)
+	if !shouldCanonicalize && !shouldSplit && len(worktrees.FilePaths) == 0 {
+		return
+	}
+
+	root, err := ableton.ParseElementFromBytes(contents)
+	if err != nil {
+		return
+	}
+
+	wasRelinked, err := relinkLibraryWorktreeFileRefs(root, worktrees, false)
+	if err != nil || (!shouldCanonicalize && !shouldSplit && !wasRelinked) {
+		return
+	}
+
+	if shouldCanonicalize {
+		canonicalizeXml(root, filePatternsConfig.VolatileXmlAttributes)
+	}
+
+	contents = root.AsXmlDocument()
+	return
+
+}
+
+// Changes the given XML element so that saving the same content always produces the same document.
+// Attributes are sorted by name, and the values of the given volatile attributes are replaced with
+// their canonical values.
+func canonicalizeXml(root *ableton.Element, volatileXmlAttributes []*applications.VolatileXmlAttribute) {
+
+	// Indexed by element name and then by attribute name.
+	canonicalValues := make(map[string]map[string]string)
+	for _, volatileXmlAttribute := range volatileXmlAttributes {
+		if canonicalValues[volatileXmlAttribute.ElementName] == nil {
+			canonicalValues[volatileXmlAttribute.ElementName] = make(map[string]string)
+		}
+		canonicalValues[volatileXmlAttribute.ElementName][volatileXmlAttribute.AttributeName] = volatileXmlAttribute.CanonicalValue
+	}
+
+	root.Walk(func(element *ableton.Element) bool {
+		for _, attr := range element.Attrs {
+			if canonicalValue, ok := canonicalValues[element.Name][attr.Name]; ok {
+				attr.Value = canonicalValue
+			}
+		}
+		sort.SliceStable(element.Attrs, func(i, j int) bool {
+			return element.Attrs[i].Name < element.Attrs[j].Name
+		})
+		return true
+	})
+
+}
+
+// Unzips all zip archives into sibling directories, skipping any that haven't changed since they were last extracted or restored.
+func extractAllZippedFiles(filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	for _, fileExtension := range filePatternsConfig.ZippedFileExtensions {
+
+		var fileNames []string
+		fileNames, err = util.GetAllFileNamesWithExtension(fileExtension)
+		if err != nil {
+			return
+		}
+
+		for _, originalFileName := range fileNames {
+
+			newDirectoryName := getUnzippedDirectoryName(originalFileName)
+			settings := config.GetExtractionSettings(originalFileName, filePatternsConfig)
+
+			var isUpToDate bool
+			isUpToDate, err = isUnzippedDirectoryUpToDate(manifest, originalFileName, settings)
+			if err != nil {
+				return
+			}
+			if isUpToDate {
+				continue
+			}
+
+			if isPreviewCommand {
+				printExtractPreviewMessage(util.Logger, originalFileName, newDirectoryName)
+				continue
+			}
+
+			// Remove any previously extracted files, in case they were removed from the archive.
+			err = util.RemoveFile(newDirectoryName)
+			if err != nil {
+				return
+			}
 
-			err = util.CopyFile(originalFileName, gzippedFileName)
+			err = util.UnzipFile(originalFileName, newDirectoryName)
 			if err != nil {
 				return
 			}
 
-			err = util.GunzipFile(gzippedFileName)
+			err = updateUnzippedDirectoryManifest(manifest, originalFileName, settings)
+			if err != nil {
+				return
+			}
+
+		}
+
+	}
+
+	if !isPreviewCommand {
+		err = manifest.Save()
+	}
+
+	return
+
+}
+
+// Same as isExtractedFileUpToDate, but for a zip archive and the files in its unzipped directory.
+func isUnzippedDirectoryUpToDate(manifest *config.ExtractionManifest, zipFileName string, settings *config.ExtractionSettings) (isUpToDate bool, err error) {
+	if isForceCommand {
+		return
+	}
+
+	unzippedFileNames, err := getUnzippedFileNames(zipFileName)
+	if err != nil {
+		return
+	}
+
+	isUpToDate, err = manifest.IsUpToDate(zipFileName, "", settings, unzippedFileNames...)
+	return
+}
+
+// Records the current states of the given zip archive and the files in its unzipped directory.
+func updateUnzippedDirectoryManifest(manifest *config.ExtractionManifest, zipFileName string, settings *config.ExtractionSettings) (err error) {
+
+	unzippedFileNames, err := getUnzippedFileNames(zipFileName)
+	if err != nil {
+		return
+	}
+
+	err = manifest.Update(zipFileName, "", settings, unzippedFileNames...)
+	return
+
+}
+
+// Returns the names of all files in the unzipped directory of the given zip archive, sorted by name.
+func getUnzippedFileNames(zipFileName string) (fileNames []string, err error) {
+
+	directoryName := getUnzippedDirectoryName(zipFileName)
+	if !util.DoesFileExist(directoryName) {
+		fileNames = make([]string, 0)
+		return
+	}
+
+	fileNames, err = util.GetAllFileNamesInDirectory(directoryName)
+	if err != nil {
+		return
+	}
+	sort.Strings(fileNames)
+
+	return
+
+}
+
+// Returns true if the given binary file and its extracted file haven't changed since they were
+// last extracted or restored with the given settings, unless the --force flag was given.
+func isExtractedFileUpToDate(manifest *config.ExtractionManifest, sourceFileName string, extractedFileName string, settings *config.ExtractionSettings) (isUpToDate bool, err error) {
+	if isForceCommand {
+		return
+	}
+
+	partFileNames, err := getSplitXmlPartFileNames(extractedFileName)
+	if err != nil {
+		return
+	}
+
+	isUpToDate, err = manifest.IsUpToDate(sourceFileName, extractedFileName, settings, partFileNames...)
+	return
+}
+
+func getUnzippedDirectoryName(zipFileName string) string {
+	return zipFileName + ".unzipped"
+}
+
+// Splits the plugin states out of all plain-text project files into split copies of the files.
+// The project files themselves are never changed, since they are the files that the application opens and saves.
+func splitAllPluginStateChunkFiles(filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	fileNames, err := util.GetAllFileNamesWithAnyExtension(filePatternsConfig.PluginStateChunkFileExtensions...)
+	if err != nil {
+		return
+	}
+	sort.Strings(fileNames)
+
+	for _, fileName := range fileNames {
+
+		splitFileName := getPluginStateSplitFileName(fileName)
+		settings := config.GetExtractionSettings(fileName, filePatternsConfig)
+
+		var isUpToDate bool
+		isUpToDate, err = isExtractedFileUpToDate(manifest, fileName, splitFileName, settings)
+		if err != nil {
+			return
+		}
+		if isUpToDate {
+			continue
+		}
+
+		if isPreviewCommand {
+			util.Println("Plugin states in " + fileName + " will be split into " + splitFileName + " and " + getPluginStateChunkDirectoryName(fileName))
+			continue
+		}
+
+		var wasSplit bool
+		wasSplit, err = splitPluginStateChunksInFile(fileName)
+		if err != nil {
+			return
+		}
+
+		if wasSplit {
+			err = manifest.Update(fileName, splitFileName, settings)
 			if err != nil {
 				return
 			}
+		}
+
+	}
+
+	if !isPreviewCommand {
+		err = manifest.Save()
+	}
+
+	return
+
+}
 
+// Writes the given project file with its plugin states replaced by references into its split copy,
+// and writes the plugin states into its chunk directory. Project files without any plugin states
+// are skipped, unless they were split before.
+func splitPluginStateChunksInFile(fileName string) (wasSplit bool, err error) {
+
+	contents, err := util.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+
+	splitFileName := getPluginStateSplitFileName(fileName)
+
+	splitContents, chunks := reaper.SplitPluginStateChunks(contents)
+	if len(chunks) == 0 && !util.DoesFileExist(splitFileName) {
+		return
+	}
+
+	// Remove the chunks from any previous split first, so that chunks that are no longer used are removed.
+	chunkDirectoryName := getPluginStateChunkDirectoryName(fileName)
+	err = util.RemoveFile(chunkDirectoryName)
+	if err != nil {
+		return
+	}
+
+	chunkIds := make([]string, 0, len(chunks))
+	for chunkId := range chunks {
+		chunkIds = append(chunkIds, chunkId)
+	}
+	sort.Strings(chunkIds)
+
+	for _, chunkId := range chunkIds {
+		err = util.WriteFile(util.JoinFilePath(chunkDirectoryName, chunkId), chunks[chunkId])
+		if err != nil {
+			return
 		}
+	}
 
+	err = util.WriteFile(splitFileName, splitContents)
+	if err != nil {
+		return
 	}
 
+	wasSplit = true
 	return
 
 }
 
-func printExtractPreviewMessage(originalFileName string, newFileName string) {
-	util.Println(originalFileName + " will be extracted to " + newFileName)
+// Returns the name of the copy of the given project file whose plugin states are split into separate files.
+func getPluginStateSplitFileName(fileName string) string {
+	return fileName + ".split"
+}
+
+func getPluginStateChunkDirectoryName(fileName string) string {
+	return fileName + ".chunks"
+}
+
+func printExtractPreviewMessage(printer util.WritePrinter, originalFileName string, newFileName string) {
+	printer.Println(originalFileName + " will be extracted to " + newFileName)
+}
+
+// Prints a warning if the given Ableton file was saved with a different major version of
+// Ableton Live than the one specified in the project config file, since the file patterns
+// used for the project may not match the ones needed by that version.
+func warnIfAbletonVersionDoesNotMatchProjectConfig(printer util.WritePrinter, originalFileName string, extractedContents []byte) (err error) {
+
+	if !applications.AbletonInfo.IsCompressedXmlFile(originalFileName) {
+		return
+	}
+
+	configuredVersion, ok, err := config.GetApplicationVersionFromProjectConfig(applications.AbletonInfo.Name)
+	if err != nil || !ok {
+		return
+	}
+
+	// Files that can't be parsed are still extracted, they just can't be checked.
+	detectedVersion, readErr := ableton.ReadMajorVersion(bytes.NewReader(extractedContents))
+	if readErr != nil {
+		return
+	}
+
+	if detectedVersion != "" && detectedVersion != string(configuredVersion) {
+		printer.Println(
+			fmt.Sprintf(
+				"WARNING: %s was saved with Ableton Live %s, but this project is configured for Ableton %s. To change this, update the Ableton version in %s.",
+				originalFileName,
+				detectedVersion,
+				configuredVersion,
+				config.MppmConfigFileName,
+			),
+		)
+	}
+
+	return
+
 }
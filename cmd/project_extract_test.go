@@ -5,9 +5,12 @@ import (
 	"testing"
 
 	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
 	"github.com/stevengt/mppm/config/configtest"
+	"github.com/stevengt/mppm/reaper/reapertest"
 
 	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util"
 	"github.com/stevengt/mppm/util/utiltest"
 )
 
@@ -43,6 +46,18 @@ func TestProjectExtractCmd(t *testing.T) {
 					utiltest.GetFakeAbletonLiveClipFileBuilder().
 						SetWasClosed(true),
 					utiltest.GetPlainTextFileBuilder(),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeAbletonLiveClipFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder(),
+						utiltest.GetFakeAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/2] Extracted fake-ableton-live-clip.alc\n[2/2] Extracted fake-ableton-live-set.als\n"),
 				),
 		},
 
@@ -82,6 +97,515 @@ func TestProjectExtractCmd(t *testing.T) {
 				),
 		},
 
+		&ProjectExtractCmdTestCase{
+			description: "Test that a warning is displayed if a file was saved with a different Ableton version than the project config.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLive11SetFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLive11SetFileBuilder().
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeAbletonLive11SetFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("WARNING: fake-ableton-live-11-set.als was saved with Ableton Live 11, but this project is configured for Ableton 10. To change this, update the Ableton version in .mppm.json.\n[1/1] Extracted fake-ableton-live-11-set.als\n"),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that files are extracted in a canonical form if XML canonicalization is enabled in the project config.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndCanonicalizedAbletonApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLive11SetFileBuilder().
+								SetContentsFromBytes(utiltest.GetGzippedContents(volatileAbletonLive11SetContents)),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndCanonicalizedAbletonApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder().
+						SetContentsFromString(canonicalizedAbletonLive11SetContents).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLive11SetFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(volatileAbletonLive11SetContents)).
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilderWithFilePatternsConfig(
+						applications.GetAllFilePatternsConfig().WithoutXmlSplitting(),
+						utiltest.GetFakeAbletonLive11SetFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents(volatileAbletonLive11SetContents)),
+						utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder().
+							SetContentsFromString(canonicalizedAbletonLive11SetContents),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Extracted fake-ableton-live-11-set.als\n"),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that references to files in the worktrees that the project has checked libraries out into are extracted as references to the libraries' own folders.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							getLibraryWorktreesFileBuilder(),
+							getAbletonLiveSetWithExternalFilesFileBuilder().
+								SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithLibraryWorktreeFilesContents)),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					getLibraryWorktreesFileBuilder().
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithLibraryWorktreeFilesContents)).
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						getAbletonLiveSetWithExternalFilesFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithLibraryWorktreeFilesContents)),
+						getUncompressedAbletonLiveSetWithExternalFilesFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"WARNING: song.als was saved with Ableton Live 11, but this project is configured for Ableton 10. To change this, update the Ableton version in .mppm.json.\n" +
+							"[1/1] Extracted song.als\n",
+					),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that the relative paths of references to files in worktrees are extracted unchanged.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							getLibraryWorktreesFileBuilder(),
+							getAbletonLiveSetWithExternalFilesFileBuilder().
+								SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithRelativeLibraryWorktreeFilesContents)),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					getLibraryWorktreesFileBuilder().
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithRelativeLibraryWorktreeFilesContents)).
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetContentsFromString(abletonLiveSetWithRelativeLibraryFilesContents).
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						getAbletonLiveSetWithExternalFilesFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithRelativeLibraryWorktreeFilesContents)),
+						getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+							SetContentsFromString(abletonLiveSetWithRelativeLibraryFilesContents),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"WARNING: song.als was saved with Ableton Live 11, but this project is configured for Ableton 10. To change this, update the Ableton version in .mppm.json.\n" +
+							"[1/1] Extracted song.als\n",
+					),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that Live Sets are extracted with each track in a separate file if XML splitting is enabled in the project config, and that previously split tracks are removed.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndSplitAbletonApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeSplitAbletonLiveSetFileBuilder(),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("fake-split-ableton-live-set.als.parts/tracks/Removed Track.xml").
+								SetContentsFromString("<AudioTrack />"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndSplitAbletonApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeSplitAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeSplitAbletonLiveSetGlobalSettingsFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeSplitAbletonLiveSetMidiTrackFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeSplitAbletonLiveSetMasterTrackFileBuilder().
+						SetWasClosed(true),
+					configtest.GetSplitExtractionManifestFileBuilder(
+						utiltest.GetFakeSplitAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeSplitAbletonLiveSetGlobalSettingsFileBuilder(),
+						utiltest.GetFakeSplitAbletonLiveSetMidiTrackFileBuilder(),
+						utiltest.GetFakeSplitAbletonLiveSetMasterTrackFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Extracted fake-split-ableton-live-set.als\n"),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that files which haven't changed since they were last extracted are skipped.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+								SetContentsFromString("<Ableton />"),
+							configtest.GetExtractionManifestFileBuilder(
+								utiltest.GetFakeAbletonLiveSetFileBuilder(),
+								utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+									SetContentsFromString("<Ableton />"),
+							),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveSetFileBuilder(),
+					utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+						SetContentsFromString("<Ableton />"),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+							SetContentsFromString("<Ableton />"),
+					).
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that files which haven't changed since they were last extracted are extracted again if --force is given.",
+			args:        []string{"project", "extract", "--force"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+								SetContentsFromString("<Ableton />"),
+							configtest.GetExtractionManifestFileBuilder(
+								utiltest.GetFakeAbletonLiveSetFileBuilder(),
+								utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+									SetContentsFromString("<Ableton />"),
+							),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Extracted fake-ableton-live-set.als\n"),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that files which haven't changed since they were last extracted are extracted again if they were extracted with different settings.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+								SetContentsFromString("<Ableton />"),
+							configtest.GetExtractionManifestFileBuilderWithFilePatternsConfig(
+								applications.GetAllFilePatternsConfig().WithoutXmlSplitting(),
+								utiltest.GetFakeAbletonLiveSetFileBuilder(),
+								utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+									SetContentsFromString("<Ableton />"),
+							),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Extracted fake-ableton-live-set.als\n"),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that files which have changed since they were last extracted are extracted again.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+								SetContentsFromString("<Ableton />"),
+							configtest.GetExtractionManifestFileBuilder(
+								utiltest.GetFakeAbletonLive11SetFileBuilder().
+									SetFilePath(utiltest.GetFakeAbletonLiveSetFileBuilder().FilePath),
+								utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+									SetContentsFromString("<Ableton />"),
+							),
+							configtest.GetMppmDirectoryGitIgnoreFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder(),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Extracted fake-ableton-live-set.als\n"),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that qCompressed XML files are extracted to uncompressed files.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeLmmsProjectFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeLmmsProjectFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeLmmsProjectFileBuilder(),
+						utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Extracted fake-lmms-project.mmpz\n"),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that zip archives are extracted into sibling directories, removing any previously extracted files.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeStudioOneSongFileBuilder(),
+							utiltest.GetPlainTextFileBuilder().
+								SetFilePath("fake-studio-one-song.song.unzipped/removed.xml"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder().
+						SetWasClosed(true),
+					configtest.GetUnzippedExtractionManifestFileBuilder(
+						utiltest.GetFakeStudioOneSongFileBuilder(),
+						utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+						utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that plugin states are split into a copy of plain-text project files if --split-plugin-state is given, without changing the project files.",
+			args:        []string{"project", "extract", "--split-plugin-state"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.rpp").
+								SetContentsFromString(reapertest.OriginalProjectRpp),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.rpp.chunks/unused").
+								SetContentsFromString("AQAAAAEAAAAAAAAA"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.rpp").
+						SetContentsFromString(reapertest.OriginalProjectRpp).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.rpp.split").
+						SetContentsFromString(reapertest.SplitProjectRpp).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.rpp.chunks/"+reapertest.ChunkId).
+						SetContentsFromString(reapertest.Chunk).
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.NewMockFileBuilder().
+							SetFilePath("song.rpp").
+							SetContentsFromString(reapertest.OriginalProjectRpp),
+						utiltest.NewMockFileBuilder().
+							SetFilePath("song.rpp.split").
+							SetContentsFromString(reapertest.SplitProjectRpp),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectExtractCmdTestCase{
+			description: "Test that plugin states are not split out of plain-text project files by default.",
+			args:        []string{"project", "extract"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.RPP").
+								SetContentsFromString(reapertest.OriginalProjectRpp),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.RPP").
+						SetContentsFromString(reapertest.OriginalProjectRpp),
+				),
+		},
+
 		&ProjectExtractCmdTestCase{
 			description: "Test that any error resulting from an invalid config file is properly raised.",
 			args:        []string{"project", "extract"},
@@ -146,7 +670,14 @@ func TestProjectExtractCmd(t *testing.T) {
 				),
 			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
 				SetExiterWasExited(true).
-				SetExiterError(utiltest.DefaultCreateFileError).
+				SetExiterError(
+					util.TaskErrors{
+						&util.TaskError{
+							TaskName: utiltest.GetFakeAbletonLiveSetFileBuilder().FilePath,
+							Err:      utiltest.DefaultCreateFileError,
+						},
+					},
+				).
 				SetMockFileBuilders(
 					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
 						SetFilePath(config.MppmConfigFileName).
@@ -163,6 +694,26 @@ func TestProjectExtractCmd(t *testing.T) {
 
 }
 
+// An Ableton Live 11 Set with attribute values that change every time it is saved.
+const volatileAbletonLive11SetContents = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+	"<Ableton Revision=\"1a2b3c\" MinorVersion=\"11.0_433\" Creator=\"Ableton Live 11.3.4\"><LiveSet><LomId Value=\"17\" /><Name Value=\"Set\" />" +
+	"<SequencerNavigator><ScrollerPos Y=\"1234\" X=\"-56\" /><ClientSize Y=\"518\" X=\"1152\" /></SequencerNavigator>" +
+	"<SessionScrollerPos Y=\"3\" X=\"2\" /></LiveSet></Ableton>"
+
+// The canonical form of volatileAbletonLive11SetContents.
+const canonicalizedAbletonLive11SetContents = "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n" +
+	"<Ableton Creator=\"Ableton Live 11.3.4\" MinorVersion=\"11.0_433\" Revision=\"\">\n" +
+	"\t<LiveSet>\n" +
+	"\t\t<LomId Value=\"0\" />\n" +
+	"\t\t<Name Value=\"Set\" />\n" +
+	"\t\t<SequencerNavigator>\n" +
+	"\t\t\t<ScrollerPos X=\"0\" Y=\"0\" />\n" +
+	"\t\t\t<ClientSize X=\"0\" Y=\"0\" />\n" +
+	"\t\t</SequencerNavigator>\n" +
+	"\t\t<SessionScrollerPos X=\"0\" Y=\"0\" />\n" +
+	"\t</LiveSet>\n" +
+	"</Ableton>\n"
+
 type ProjectExtractCmdTestCase struct {
 	description                              string
 	args                                     []string
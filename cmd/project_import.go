@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+
+	cobra.OnInitialize(
+		func() {
+			importedLibrariesDirectoryName, _ = ImportCmd.Flags().GetString("libraries")
+		},
+	)
+
+	ImportCmd.Flags().StringVar(
+		&importedLibrariesDirectoryName,
+		"libraries",
+		"",
+		`The folder to unpack the bundle's libraries into, with a folder for each library.
+Defaults to 'mppm-libraries' in your home folder.`,
+	)
+
+	ProjectCmd.AddCommand(ImportCmd)
+
+}
+
+var importedLibrariesDirectoryName string
+
+var ImportCmd = &cobra.Command{
+
+	Use: "import <bundle file>",
+
+	Short: "Unpacks a bundle created by 'mppm project export' into the current folder.",
+
+	Long: `Unpacks a bundle created by 'mppm project export' into the current folder.
+
+The project's files are unpacked into the current folder, which must not already contain a project.
+The files from each library are unpacked into a new folder for the library, which is added to the
+global config file as a library, and the project config file is updated to use it.
+
+The project's Ableton Live Sets still refer to the library files where they were on the system that
+created the bundle. To update them, run 'mppm project relink' after importing.`,
+
+	Args: cobra.ExactArgs(1),
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := importProject(args[0]); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func importProject(bundleFileName string) (err error) {
+
+	if util.DoesFileExist(config.MppmConfigFileName) {
+		err = errors.New("Unable to import the project because the current folder already contains one. Run 'mppm project import' in an empty folder instead.")
+		return
+	}
+
+	// The bundle is read twice, one file at a time, so that only one file is held in memory at a time.
+	// The first time, only the manifest is kept, so that everything can be checked before anything is unpacked.
+	var manifestAsJson []byte
+	bundledFileNames := make(map[string]bool)
+	err = util.ReadArchiveFileEach(bundleFileName, func(bundledFileName string, contents []byte) error {
+		bundledFileNames[bundledFileName] = true
+		if bundledFileName == config.BundleManifestFileName {
+			manifestAsJson = contents
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	if manifestAsJson == nil {
+		err = errors.New("Unable to import '" + bundleFileName + "' because it wasn't created by 'mppm project export'.")
+		return
+	}
+
+	manifest, err := config.NewBundleManifestFromJson(manifestAsJson)
+	if err != nil {
+		return
+	}
+
+	err = manifest.Validate()
+	if err != nil {
+		err = errors.New("Unable to import '" + bundleFileName + "'. " + err.Error() + ".")
+		return
+	}
+
+	if !bundledFileNames[config.GetBundledProjectFileName(config.MppmConfigFileName)] {
+		err = errors.New("Unable to import '" + bundleFileName + "' because it doesn't contain a project config file.")
+		return
+	}
+
+	librariesDirectoryName := importedLibrariesDirectoryName
+	if librariesDirectoryName == "" {
+		var homeDirectoryName string
+		homeDirectoryName, err = util.UserHomeDir()
+		if err != nil {
+			return
+		}
+		librariesDirectoryName = util.JoinFilePath(homeDirectoryName, "mppm-libraries")
+	}
+
+	// Check every library before unpacking anything, so that nothing is left half imported.
+	libraryFilePaths := make(map[*config.BundleLibrary]string)
+	unpackedLibraryFileNames := make(map[string]string) // The names of library files within the bundle, indexed by where they are unpacked to.
+	for _, bundledLibrary := range manifest.Libraries {
+
+		libraryFilePath := util.JoinFilePath(librariesDirectoryName, bundledLibrary.Name)
+		if util.DoesFileExist(libraryFilePath) {
+			err = errors.New("Unable to import the library '" + bundledLibrary.Name + "' because the folder '" + libraryFilePath + "' already exists. To unpack the libraries somewhere else, use --libraries.")
+			return
+		}
+		libraryFilePaths[bundledLibrary] = libraryFilePath
+
+		for _, fileName := range bundledLibrary.Files {
+			bundledFileName := bundledLibrary.GetBundledFileName(fileName)
+			if !bundledFileNames[bundledFileName] {
+				err = errors.New("Unable to import the library '" + bundledLibrary.Name + "' because '" + fileName + "' is missing from the bundle.")
+				return
+			}
+			unpackedLibraryFileNames[bundledFileName] = util.JoinFilePath(libraryFilePath, fileName)
+		}
+
+	}
+
+	projectFilePrefix := config.BundleProjectDirectoryName + "/"
+	numberOfProjectFiles := 0
+	err = util.ReadArchiveFileEach(bundleFileName, func(bundledFileName string, contents []byte) (err error) {
+		if strings.HasPrefix(bundledFileName, projectFilePrefix) {
+			err = util.WriteFile(strings.TrimPrefix(bundledFileName, projectFilePrefix), contents)
+			numberOfProjectFiles++
+		} else if unpackedLibraryFileName, ok := unpackedLibraryFileNames[bundledFileName]; ok {
+			err = util.WriteFile(unpackedLibraryFileName, contents)
+		}
+		return
+	})
+	if err != nil {
+		return
+	}
+
+	projectConfig, globalConfig, err := configManager.GetProjectAndGlobalConfigs()
+	if err != nil {
+		return
+	}
+
+	summaryLines := []string{getFileCountDescription(numberOfProjectFiles) + " from the project into the current folder"}
+
+	for _, bundledLibrary := range manifest.Libraries {
+
+		libraryFilePath := libraryFilePaths[bundledLibrary]
+
+		err = addLibrary(libraryFilePath)
+		if err != nil {
+			return
+		}
+
+		importedLibrary := getLibraryWithFilePath(libraryFilePath, globalConfig.Libraries)
+		if importedLibrary == nil {
+			err = errors.New("Unable to add the library '" + libraryFilePath + "' to the global config file.")
+			return
+		}
+
+		// Use the imported library in place of the original one, at its new version.
+		projectLibrary := getLibraryWithFilePath(bundledLibrary.OriginalFilePath, projectConfig.Libraries)
+		if projectLibrary == nil {
+			projectLibrary = &config.LibraryConfig{}
+			projectConfig.Libraries = append(projectConfig.Libraries, projectLibrary)
+		}
+		projectLibrary.FilePath = importedLibrary.FilePath
+		projectLibrary.MostRecentGitCommitId = importedLibrary.MostRecentGitCommitId
+		projectLibrary.CurrentGitCommitId = importedLibrary.CurrentGitCommitId
+
+		summaryLines = append(summaryLines, getFileCountDescription(len(bundledLibrary.Files))+" from "+bundledLibrary.OriginalFilePath+" into "+libraryFilePath)
+
+	}
+
+	err = configManager.SaveProjectConfig()
+	if err != nil {
+		return
+	}
+
+	printProjectStatusSection("Imported:", summaryLines)
+	if len(manifest.Libraries) > 0 {
+		util.Println("To update the locations of library files in the project's Live Sets, run 'mppm project relink'.")
+	}
+
+	return
+
+}
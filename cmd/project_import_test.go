@@ -0,0 +1,151 @@
+package cmd_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+func getImportedProjectConfigContents() string {
+	return fmt.Sprintf(
+		`{"version":"%s.0.0","applications":[{"name":"Ableton","version":"10"}],"libraries":[{"location":"/home/testuser/mppm-libraries/library","most-recent-version":"abcde","current-version":"abcde"}]}`,
+		config.GetCurrentlyInstalledMajorVersion(),
+	)
+}
+
+func getImportedGlobalConfigContents() string {
+	return fmt.Sprintf(
+		`{"version":"%s.0.0","applications":[{"name":"Ableton","version":"10"}],"libraries":[{"location":"/home/testuser/library","most-recent-version":"56789","current-version":"56789"},{"location":"/home/testuser/mppm-libraries/library","most-recent-version":"abcde","current-version":"abcde"}]}`,
+		config.GetCurrentlyInstalledMajorVersion(),
+	)
+}
+
+// Returns a builder for a bundle whose manifest tries to unpack a library file into the home folder.
+func getMaliciousBundleFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("bundle.zip").
+		SetContentsFromBytes(
+			utiltest.GetZipFileContents(
+				map[string]string{
+					"mppm-bundle.json":   `{"version":"` + config.Version + `","libraries":[{"name":"library","location":"/home/testuser/library","version":"56789","files":["../../.bashrc"]}]}`,
+					"project/.mppm.json": string(configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.ConfigAsJson),
+				},
+			),
+		)
+}
+
+func TestProjectImportCmd(t *testing.T) {
+
+	testCases := []*ProjectCmdTestCase{
+
+		&ProjectCmdTestCase{
+			description: "Test that the project is unpacked into the current folder, and its libraries are unpacked and added to the global and project config files.",
+			args:        []string{"project", "import", "bundle.zip"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							getExportedBundleFileBuilder(),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetRevParseStdout("abcde\n"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.NewMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetContentsFromString(getImportedGlobalConfigContents()).
+						SetWasClosed(true),
+					getExportedBundleFileBuilder().
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetContentsFromString(getImportedProjectConfigContents()).
+						SetWasClosed(true),
+					getAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					getUncompressedAbletonLiveSetWithExternalFilesFileBuilder().
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("/home/testuser/mppm-libraries/library/Drums/hat.wav").
+						SetContentsFromString("hat at version 56789").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						"/home/testuser/mppm-libraries/library": [][]string{
+							[]string{"rev-parse"},
+							[]string{"rev-parse", "HEAD"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Imported:\n" +
+							"\t3 files from the project into the current folder\n" +
+							"\t1 file from /home/testuser/library into /home/testuser/mppm-libraries/library\n" +
+							"To update the locations of library files in the project's Live Sets, run 'mppm project relink'.\n",
+					),
+				),
+		},
+
+		&ProjectCmdTestCase{
+			description: "Test that nothing is imported if the bundle's manifest has library files outside of the library's folder.",
+			args:        []string{"project", "import", "bundle.zip"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							getMaliciousBundleFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json"),
+					getMaliciousBundleFileBuilder().
+						SetWasClosed(true),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("Unable to import 'bundle.zip'. The bundle's manifest has a file outside of the library 'library': '../../.bashrc'.")),
+		},
+
+		&ProjectCmdTestCase{
+			description: "Test that nothing is imported if the current folder already contains a project.",
+			args:        []string{"project", "import", "bundle.zip"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							getExportedBundleFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName),
+					getExportedBundleFileBuilder(),
+				).
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("Unable to import the project because the current folder already contains one. Run 'mppm project import' in an empty folder instead.")),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
@@ -2,7 +2,7 @@ package cmd
 
 import (
 	"bytes"
-	"io"
+	"errors"
 	"strings"
 
 	"github.com/spf13/cobra"
@@ -12,16 +12,40 @@ import (
 )
 
 func init() {
+
+	cobra.OnInitialize(
+		func() {
+			projectApplicationNames, _ = InitCmd.Flags().GetStringSlice("application")
+		},
+	)
+
+	InitCmd.Flags().StringSliceVar(
+		&projectApplicationNames,
+		"application",
+		nil,
+		`The application the project is made with, such as 'Ableton' or 'REAPER'.
+Can be given more than once. If it isn't given, the applications are detected from the
+project files in the current folder, or Ableton is used if there aren't any.
+Supported applications are: `+strings.Join(applications.GetSupportedApplicationNames(), ", ")+`.`,
+	)
+
 	ProjectCmd.AddCommand(InitCmd)
+
 }
 
+// The applications to configure a new project for.
+var projectApplicationNames []string
+
 var InitCmd = &cobra.Command{
 
 	Use: "init",
 
 	Short: "Initializes version control settings for a project using git and git-lfs.",
 
-	Long: "Initializes version control settings for a project using git and git-lfs.",
+	Long: `Initializes version control settings for a project using git and git-lfs.
+
+Only the file patterns of the project's applications are added to .gitignore and tracked with git-lfs.
+These applications are saved in the project config file, where more can be added later.`,
 
 	Args: cobra.NoArgs,
 
@@ -37,7 +61,12 @@ func initProject() (err error) {
 	gitRepoFilePath := "."
 	gitManager := util.NewGitManager(gitRepoFilePath)
 
-	err = createMppmProjectConfigFile()
+	applicationNames, err := getProjectApplicationNames()
+	if err != nil {
+		return
+	}
+
+	err = createMppmProjectConfigFile(applicationNames...)
 	if err != nil {
 		return
 	}
@@ -52,7 +81,10 @@ func initProject() (err error) {
 		return
 	}
 
-	filePatternsConfig := applications.GetAllFilePatternsConfig()
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
 
 	err = createGitIgnoreFile(filePatternsConfig.GitIgnorePatterns...)
 	if err != nil {
@@ -64,6 +96,11 @@ func initProject() (err error) {
 		return
 	}
 
+	err = registerMergeDriver(gitManager, applications.AbletonInfo.FilterCompressedXmlFileExtensions(filePatternsConfig.CompressedXmlFileExtensions))
+	if err != nil {
+		return
+	}
+
 	err = gitManager.Add(".gitignore", ".gitattributes", config.MppmConfigFileName)
 	if err != nil {
 		return
@@ -82,20 +119,118 @@ func createGitIgnoreFile(filePatterns ...string) (err error) {
 
 	fileName := ".gitignore"
 	fileContents := strings.Join(filePatterns, "\n")
-	fileContentsAsReader := bytes.NewReader([]byte(fileContents))
 
-	file, err := util.CreateFile(fileName)
+	err = util.WriteFile(fileName, []byte(fileContents))
+	return
+}
+
+// Configures git to merge extracted XML files using 'mppm merge-driver'. This can be run more than once.
+// The merge driver is stored in the repository's own git config, which isn't shared by cloning it,
+// so 'mppm project setup' runs this again in each clone. Nothing is registered for projects that don't
+// have any Ableton files, since the merge driver only understands Ableton XML.
+func registerMergeDriver(gitManager util.GitManager, abletonXmlFileExtensions []string) (err error) {
+
+	if len(abletonXmlFileExtensions) == 0 {
+		return
+	}
+
+	err = gitManager.Config("merge."+MergeDriverName+".name", "mppm structure-aware merge driver for Ableton XML files")
 	if err != nil {
 		return
 	}
-	defer file.Close()
 
-	io.Copy(file, fileContentsAsReader)
+	err = gitManager.Config("merge."+MergeDriverName+".driver", MergeDriverCommand)
+	if err != nil {
+		return
+	}
+
+	err = appendToGitAttributesFile(getMergeDriverGitAttributes(abletonXmlFileExtensions)...)
+	if err != nil {
+		return
+	}
 
 	return
+
+}
+
+// Adds the given lines to the .gitattributes file, skipping any that it already contains.
+func appendToGitAttributesFile(gitAttributes ...string) (err error) {
+
+	fileName := ".gitattributes"
+	fileContents := make([]byte, 0)
+
+	if util.DoesFileExist(fileName) {
+		fileContents, err = util.ReadFile(fileName)
+		if err != nil {
+			return
+		}
+	}
+
+	existingGitAttributes := strings.Split(string(fileContents), "\n")
+	for i, existingGitAttribute := range existingGitAttributes {
+		existingGitAttributes[i] = strings.TrimSpace(existingGitAttribute)
+	}
+
+	missingGitAttributes := make([]string, 0)
+	for _, gitAttribute := range gitAttributes {
+		if !containsString(existingGitAttributes, gitAttribute) {
+			missingGitAttributes = append(missingGitAttributes, gitAttribute)
+		}
+	}
+
+	if len(missingGitAttributes) == 0 {
+		return
+	}
+
+	if len(fileContents) > 0 && !bytes.HasSuffix(fileContents, []byte("\n")) {
+		fileContents = append(fileContents, '\n')
+	}
+
+	fileContents = append(fileContents, []byte(strings.Join(missingGitAttributes, "\n")+"\n")...)
+
+	err = util.WriteFile(fileName, fileContents)
+	return
+
+}
+
+// Returns the applications given with --application, or else the applications that made
+// any project files in the current folder. If there aren't any, then the default applications are used.
+func getProjectApplicationNames() (applicationNames []string, err error) {
+
+	if len(projectApplicationNames) > 0 {
+		for _, applicationName := range projectApplicationNames {
+			if _, ok := applications.SupportedApplications[applicationName]; !ok {
+				err = errors.New(
+					"Unsupported application '" + applicationName + "'. Supported applications are: " +
+						strings.Join(applications.GetSupportedApplicationNames(), ", ") + ".",
+				)
+				return
+			}
+		}
+		applicationNames = projectApplicationNames
+		return
+	}
+
+	projectFileExtensions := make([]string, 0)
+	for _, supportedApplication := range applications.SupportedApplications {
+		projectFileExtensions = append(projectFileExtensions, supportedApplication.ProjectFileExtensions...)
+	}
+
+	projectFileNames, err := util.GetAllFileNamesWithAnyExtension(projectFileExtensions...)
+	if err != nil {
+		return
+	}
+
+	applicationNames = applications.GetApplicationNamesForProjectFiles(projectFileNames)
+	if len(applicationNames) == 0 {
+		applicationNames = applications.DefaultApplicationNames
+	}
+
+	return
+
 }
 
-func createMppmProjectConfigFile() (err error) {
-	err = configManager.SaveDefaultProjectConfig()
+func createMppmProjectConfigFile(applicationNames ...string) (err error) {
+	err = configManager.SaveDefaultProjectConfig(applicationNames...)
 	return
 }
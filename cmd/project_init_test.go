@@ -1,6 +1,7 @@
 package cmd_test
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -31,16 +32,19 @@ func TestProjectInitCmd(t *testing.T) {
 					utiltest.NewMockFileBuilder().
 						SetFilePath(".gitignore").
 						SetContentsFromString(
-							strings.Join(applications.GetAllFilePatternsConfig().GitIgnorePatterns, "\n"),
+							strings.Join(getExpectedFilePatternsConfig().GitIgnorePatterns, "\n"),
 						).
 						SetWasClosed(true),
+					getExpectedGitAttributesFileBuilder(),
 				).
 				SetGitManagerInputHistoriesIndexedByRepoPath(
 					map[string][][]string{
 						".": [][]string{
 							[]string{"init"},
 							[]string{"lfs", "install"},
-							append([]string{"lfs", "track"}, applications.GetAllFilePatternsConfig().GitLfsTrackPatterns...),
+							append([]string{"lfs", "track"}, getExpectedFilePatternsConfig().GitLfsTrackPatterns...),
+							[]string{"config", "merge.mppm.name", "mppm structure-aware merge driver for Ableton XML files"},
+							[]string{"config", "merge.mppm.driver", "mppm merge-driver %O %A %B %P"},
 							[]string{"add", ".gitignore", ".gitattributes", config.MppmConfigFileName},
 							[]string{"commit", "-m", "Initial commit."},
 						},
@@ -48,6 +52,95 @@ func TestProjectInitCmd(t *testing.T) {
 				),
 		},
 
+		&ProjectInitCmdTestCase{
+			description:                     "Test that only the applications given with --application are configured, without the merge driver if none of them are Ableton.",
+			args:                            []string{"--application", "REAPER", "--application", "LMMS"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder(),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.NewMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetContentsFromBytes(
+							configtest.GetDefaultMppmConfigAsJson("REAPER", "LMMS"),
+						).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath(".gitignore").
+						SetContentsFromString(
+							strings.Join(getExpectedFilePatternsConfig("REAPER", "LMMS").GitIgnorePatterns, "\n"),
+						).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"init"},
+							[]string{"lfs", "install"},
+							append([]string{"lfs", "track"}, getExpectedFilePatternsConfig("REAPER", "LMMS").GitLfsTrackPatterns...),
+							[]string{"add", ".gitignore", ".gitattributes", config.MppmConfigFileName},
+							[]string{"commit", "-m", "Initial commit."},
+						},
+					},
+				),
+		},
+
+		&ProjectInitCmdTestCase{
+			description: "Test that the applications are detected from the project files in the current folder.",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.GetPlainTextFileBuilder().
+								SetFilePath("Song/Song.RPP"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.GetPlainTextFileBuilder().
+						SetFilePath("Song/Song.RPP"),
+					utiltest.NewMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetContentsFromBytes(
+							configtest.GetDefaultMppmConfigAsJson("REAPER"),
+						).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath(".gitignore").
+						SetContentsFromString(
+							strings.Join(getExpectedFilePatternsConfig("REAPER").GitIgnorePatterns, "\n"),
+						).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"init"},
+							[]string{"lfs", "install"},
+							append([]string{"lfs", "track"}, getExpectedFilePatternsConfig("REAPER").GitLfsTrackPatterns...),
+							[]string{"add", ".gitignore", ".gitattributes", config.MppmConfigFileName},
+							[]string{"commit", "-m", "Initial commit."},
+						},
+					},
+				),
+		},
+
+		&ProjectInitCmdTestCase{
+			description:                     "Test that an unsupported application given with --application is reported.",
+			args:                            []string{"--application", "FL Studio"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder(),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New("Unsupported application 'FL Studio'. Supported applications are: " +
+						strings.Join(applications.GetSupportedApplicationNames(), ", ") + "."),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": make([][]string, 0),
+					},
+				),
+		},
+
 		&ProjectInitCmdTestCase{
 			description: "Test that any error from os.Create() is properly raised.",
 			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
@@ -140,7 +233,7 @@ func TestProjectInitCmd(t *testing.T) {
 					utiltest.NewMockFileBuilder().
 						SetFilePath(".gitignore").
 						SetContentsFromString(
-							strings.Join(applications.GetAllFilePatternsConfig().GitIgnorePatterns, "\n"),
+							strings.Join(getExpectedFilePatternsConfig().GitIgnorePatterns, "\n"),
 						).
 						SetWasClosed(true),
 				).
@@ -149,7 +242,43 @@ func TestProjectInitCmd(t *testing.T) {
 						".": [][]string{
 							[]string{"init"},
 							[]string{"lfs", "install"},
-							append([]string{"lfs", "track"}, applications.GetAllFilePatternsConfig().GitLfsTrackPatterns...),
+							append([]string{"lfs", "track"}, getExpectedFilePatternsConfig().GitLfsTrackPatterns...),
+						},
+					},
+				),
+		},
+
+		&ProjectInitCmdTestCase{
+			description: "Test that any error from running 'git config' is properly raised.",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetUseDefaultConfigError(true),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterWasExited(true).
+				SetExiterError(utiltest.DefaultConfigError).
+				SetMockFileBuilders(
+					utiltest.NewMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetContentsFromBytes(
+							configtest.GetDefaultMppmConfigAsJson(),
+						).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath(".gitignore").
+						SetContentsFromString(
+							strings.Join(getExpectedFilePatternsConfig().GitIgnorePatterns, "\n"),
+						).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"init"},
+							[]string{"lfs", "install"},
+							append([]string{"lfs", "track"}, getExpectedFilePatternsConfig().GitLfsTrackPatterns...),
+							[]string{"config", "merge.mppm.name", "mppm structure-aware merge driver for Ableton XML files"},
 						},
 					},
 				),
@@ -175,16 +304,19 @@ func TestProjectInitCmd(t *testing.T) {
 					utiltest.NewMockFileBuilder().
 						SetFilePath(".gitignore").
 						SetContentsFromString(
-							strings.Join(applications.GetAllFilePatternsConfig().GitIgnorePatterns, "\n"),
+							strings.Join(getExpectedFilePatternsConfig().GitIgnorePatterns, "\n"),
 						).
 						SetWasClosed(true),
+					getExpectedGitAttributesFileBuilder(),
 				).
 				SetGitManagerInputHistoriesIndexedByRepoPath(
 					map[string][][]string{
 						".": [][]string{
 							[]string{"init"},
 							[]string{"lfs", "install"},
-							append([]string{"lfs", "track"}, applications.GetAllFilePatternsConfig().GitLfsTrackPatterns...),
+							append([]string{"lfs", "track"}, getExpectedFilePatternsConfig().GitLfsTrackPatterns...),
+							[]string{"config", "merge.mppm.name", "mppm structure-aware merge driver for Ableton XML files"},
+							[]string{"config", "merge.mppm.driver", "mppm merge-driver %O %A %B %P"},
 							[]string{"add", ".gitignore", ".gitattributes", config.MppmConfigFileName},
 						},
 					},
@@ -211,16 +343,19 @@ func TestProjectInitCmd(t *testing.T) {
 					utiltest.NewMockFileBuilder().
 						SetFilePath(".gitignore").
 						SetContentsFromString(
-							strings.Join(applications.GetAllFilePatternsConfig().GitIgnorePatterns, "\n"),
+							strings.Join(getExpectedFilePatternsConfig().GitIgnorePatterns, "\n"),
 						).
 						SetWasClosed(true),
+					getExpectedGitAttributesFileBuilder(),
 				).
 				SetGitManagerInputHistoriesIndexedByRepoPath(
 					map[string][][]string{
 						".": [][]string{
 							[]string{"init"},
 							[]string{"lfs", "install"},
-							append([]string{"lfs", "track"}, applications.GetAllFilePatternsConfig().GitLfsTrackPatterns...),
+							append([]string{"lfs", "track"}, getExpectedFilePatternsConfig().GitLfsTrackPatterns...),
+							[]string{"config", "merge.mppm.name", "mppm structure-aware merge driver for Ableton XML files"},
+							[]string{"config", "merge.mppm.driver", "mppm merge-driver %O %A %B %P"},
 							[]string{"add", ".gitignore", ".gitattributes", config.MppmConfigFileName},
 							[]string{"commit", "-m", "Initial commit."},
 						},
@@ -235,8 +370,37 @@ func TestProjectInitCmd(t *testing.T) {
 
 }
 
+// Returns the file patterns of a project that is only configured for the given applications,
+// or for the default applications if none are given.
+func getExpectedFilePatternsConfig(applicationNames ...string) *applications.FilePatternsConfig {
+	if len(applicationNames) == 0 {
+		applicationNames = applications.DefaultApplicationNames
+	}
+	filePatternsConfig := applications.NewFilePatternsConfig().AppendAll(applications.AudioFilePatternsConfig)
+	for _, applicationName := range applicationNames {
+		supportedApplication := applications.SupportedApplications[applicationName]
+		applicationFilePatternsConfig := supportedApplication.FilePatternConfigs[supportedApplication.DefaultVersion]
+		filePatternsConfig = filePatternsConfig.AppendAll(
+			applicationFilePatternsConfig.WithoutXmlCanonicalization().WithoutXmlSplitting(),
+		)
+	}
+	return filePatternsConfig
+}
+
+func getExpectedGitAttributesFileBuilder() *utiltest.MockFileBuilder {
+	gitAttributes := make([]string, 0)
+	for _, fileExtension := range getExpectedFilePatternsConfig().CompressedXmlFileExtensions {
+		gitAttributes = append(gitAttributes, "*."+fileExtension+".xml merge=mppm\n")
+	}
+	return utiltest.NewMockFileBuilder().
+		SetFilePath(".gitattributes").
+		SetContentsFromString(strings.Join(gitAttributes, "")).
+		SetWasClosed(true)
+}
+
 type ProjectInitCmdTestCase struct {
 	description                              string
+	args                                     []string
 	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
 	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
 }
@@ -245,7 +409,7 @@ func (testCase *ProjectInitCmdTestCase) Run(t *testing.T) {
 
 	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
 
-	cmd.RootCmd.SetArgs([]string{"project", "init"})
+	cmd.RootCmd.SetArgs(append([]string{"project", "init"}, testCase.args...))
 	cmd.RootCmd.Execute()
 
 	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
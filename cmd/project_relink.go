@@ -0,0 +1,320 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	ProjectCmd.AddCommand(RelinkCmd)
+}
+
+var RelinkCmd = &cobra.Command{
+
+	Use: "relink [folder...]",
+
+	Short: "Finds missing files that the project's Ableton Live Sets refer to, and updates their locations.",
+
+	Long: `Finds missing files that the project's Ableton Live Sets refer to, and updates their locations.
+
+Every missing audio sample, preset, and Max device (see 'mppm project deps') is searched for
+in the libraries in the global config file, and in any of the given folders. A file matches if
+it has the same name, and the same size as when it was last saved by Live, if Live recorded it.
+The CRC that Live records for each file is not checked, so a matching file may still be a
+different file with the same name and size.
+
+Because of this, the matching files are only listed by default. After checking that they are
+the right files, run this again with --force to relink them. Then for each file with exactly one
+match, its location is written to the extracted .xml file, and the binary file is restored from it.
+Files with no matches, or more than one, are listed instead.
+Only references in Live Sets saved by Live 11 and later can be updated.
+
+If a binary file has changes that haven't been extracted yet, then nothing is relinked.
+To keep the changes, run 'mppm project extract' first.`,
+
+	Args: cobra.ArbitraryArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := relinkMissingFiles(args); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func relinkMissingFiles(searchDirectoryNames []string) (err error) {
+
+	for _, directoryName := range searchDirectoryNames {
+		if !util.DoesFileExist(directoryName) {
+			err = errors.New("The folder '" + directoryName + "' does not exist.")
+			return
+		}
+	}
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	globalConfig, err := configManager.GetGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	manifest, err := config.LoadExtractionManifest()
+	if err != nil {
+		return
+	}
+
+	dependencies, err := getProjectDependencies(filePatternsConfig, globalConfig.Libraries)
+	if err != nil {
+		return
+	}
+
+	missingDependencies := make([]*projectDependency, 0)
+	for _, dependency := range dependencies {
+		if dependency.Location == missingDependencyLocation {
+			missingDependencies = append(missingDependencies, dependency)
+		}
+	}
+
+	if len(missingDependencies) == 0 {
+		util.Println("There are no missing files to relink.")
+		return
+	}
+
+	for _, library := range globalConfig.Libraries {
+		if util.DoesFileExist(library.FilePath) {
+			searchDirectoryNames = append(searchDirectoryNames, library.FilePath)
+		}
+	}
+
+	candidateFileNames, err := getRelinkCandidateFileNames(searchDirectoryNames)
+	if err != nil {
+		return
+	}
+
+	relinkedLines := make([]string, 0)
+	unrelinkedLines := make([]string, 0)
+	relinkedExtractedFileNames := make([]string, 0)
+	relinkedExtractedRoots := make(map[string]*ableton.Element)
+
+	for _, dependency := range missingDependencies {
+
+		description := dependency.FilePath + " (used by " + strings.TrimSuffix(dependency.ExtractedFileName, ".xml") + ")"
+
+		var matchingFileNames []string
+		matchingFileNames, err = findRelinkCandidates(dependency.FileRef, candidateFileNames)
+		if err != nil {
+			return
+		}
+
+		if len(matchingFileNames) == 0 {
+			unrelinkedLines = appendIfMissing(unrelinkedLines, description+": No matching files were found.")
+			continue
+		} else if len(matchingFileNames) > 1 {
+			unrelinkedLines = appendIfMissing(unrelinkedLines, description+": More than one matching file was found: "+strings.Join(matchingFileNames, ", "))
+			continue
+		}
+
+		setPathErr := relinkProjectDependency(dependency, matchingFileNames[0])
+		if setPathErr != nil {
+			unrelinkedLines = appendIfMissing(unrelinkedLines, description+": "+setPathErr.Error())
+			continue
+		}
+
+		relinkedLines = appendIfMissing(relinkedLines, dependency.FilePath+" -> "+matchingFileNames[0]+" (used by "+strings.TrimSuffix(dependency.ExtractedFileName, ".xml")+")")
+		relinkedExtractedFileNames = appendIfMissing(relinkedExtractedFileNames, dependency.ExtractedFileName)
+		relinkedExtractedRoots[dependency.ExtractedFileName] = dependency.ExtractedRoot
+
+	}
+
+	// The matches aren't verified by their CRCs, so they must be confirmed with --force before anything is changed.
+	isRelinkConfirmed := isForceCommand && !isPreviewCommand
+
+	if isRelinkConfirmed {
+		printProjectStatusSection("Relinked files:", relinkedLines)
+	} else {
+		printProjectStatusSection("Files that will be relinked:", relinkedLines)
+	}
+	printProjectStatusSection("Missing files that could not be relinked:", unrelinkedLines)
+
+	if !isRelinkConfirmed || len(relinkedExtractedFileNames) == 0 {
+		if !isPreviewCommand && len(relinkedExtractedFileNames) > 0 {
+			util.Println("Nothing was changed. The files are matched by name and size only, so check that they are the right files, then run 'mppm project relink --force' to relink them.")
+		}
+		return
+	}
+
+	err = checkForUnextractedChanges(relinkedExtractedFileNames, filePatternsConfig, manifest)
+	if err != nil {
+		return
+	}
+
+	err = writeAndRestoreExtractedFiles(relinkedExtractedFileNames, relinkedExtractedRoots, filePatternsConfig, manifest)
+
+	// Record any files that were restored, even if others couldn't be.
+	if saveErr := manifest.Save(); err == nil {
+		err = saveErr
+	}
+
+	return
+
+}
+
+// Returns the names of all files in the given directories and their subdirectories, indexed by their
+// lowercase base names. Files inside of git repositories' .git directories are skipped.
+func getRelinkCandidateFileNames(directoryNames []string) (candidateFileNames map[string][]string, err error) {
+
+	candidateFileNames = make(map[string][]string)
+
+	for _, directoryName := range directoryNames {
+
+		var fileNames []string
+		fileNames, err = util.GetAllFileNamesInDirectory(directoryName)
+		if err != nil {
+			return
+		}
+
+		for _, fileName := range fileNames {
+			if strings.Contains(filepath.ToSlash(fileName), "/.git/") {
+				continue
+			}
+			baseName := strings.ToLower(filepath.Base(fileName))
+			candidateFileNames[baseName] = appendIfMissing(candidateFileNames[baseName], fileName)
+		}
+
+	}
+
+	return
+
+}
+
+// Returns the sorted names of all candidate files with the same name as the given reference,
+// ignoring case, and the same size, if it is known. The reference's CRC is not checked.
+func findRelinkCandidates(fileRef *ableton.FileRef, candidateFileNames map[string][]string) (matchingFileNames []string, err error) {
+
+	matchingFileNames = make([]string, 0)
+
+	for _, fileName := range candidateFileNames[strings.ToLower(fileRef.FileName())] {
+
+		if fileRef.OriginalFileSize > 0 {
+			var fileInfo os.FileInfo
+			fileInfo, err = util.StatFile(fileName)
+			if err != nil {
+				return
+			}
+			if fileInfo.Size() != fileRef.OriginalFileSize {
+				continue
+			}
+		}
+
+		matchingFileNames = append(matchingFileNames, fileName)
+
+	}
+
+	sort.Strings(matchingFileNames)
+	return
+
+}
+
+// Changes the given reference to refer to the given file. The file is referred to relative to
+// the Live Set if it is inside the project, so that the project can still be moved.
+func relinkProjectDependency(dependency *projectDependency, fileName string) (err error) {
+
+	absoluteFileName, err := filepath.Abs(fileName)
+	if err != nil {
+		return
+	}
+
+	relativeFileName := ""
+	if !filepath.IsAbs(fileName) && isFileInDirectory(fileName, ".") {
+		relativeFileName, err = filepath.Rel(filepath.Dir(dependency.ExtractedFileName), fileName)
+		if err != nil {
+			return
+		}
+	}
+
+	err = dependency.FileRef.SetPath(filepath.ToSlash(absoluteFileName), filepath.ToSlash(relativeFileName))
+	return
+
+}
+
+// Returns an error listing the binary files of the given extracted files that have changes that haven't been
+// extracted, and would be lost by restoring them, or nil if there are none.
+func checkForUnextractedChanges(extractedFileNames []string, filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	conflictingFileNames := make([]string, 0)
+
+	for _, extractedFileName := range extractedFileNames {
+		var isConflict bool
+		compressedXmlFile := newRestoredGzippedXmlFile(extractedFileName, filePatternsConfig)
+		isConflict, err = compressedXmlFile.isRestoreConflict(filePatternsConfig, manifest)
+		if err != nil {
+			return
+		}
+		if isConflict {
+			conflictingFileNames = append(conflictingFileNames, compressedXmlFile.newFileName)
+		}
+	}
+
+	if len(conflictingFileNames) == 0 {
+		return
+	}
+
+	errorMessage := "Changing the files that these Live Sets refer to would overwrite changes that have not been extracted:\n"
+	for _, fileName := range conflictingFileNames {
+		errorMessage += "\t" + fileName + "\n"
+	}
+	errorMessage += "To keep the changes, run 'mppm project extract' first, and then try again."
+	err = errors.New(errorMessage)
+	return
+
+}
+
+// Writes the given contents of the given extracted files, and restores their binary files.
+func writeAndRestoreExtractedFiles(extractedFileNames []string, extractedRoots map[string]*ableton.Element, filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	tasks := make([]*util.Task, 0, len(extractedFileNames))
+
+	for _, extractedFileName := range extractedFileNames {
+		compressedXmlFile := newRestoredGzippedXmlFile(extractedFileName, filePatternsConfig)
+		contents := extractedRoots[extractedFileName].AsXmlDocument()
+		err = writeExtractedXmlFile(compressedXmlFile.newFileName, extractedFileName, contents, filePatternsConfig)
+		if err != nil {
+			return
+		}
+		tasks = append(tasks, compressedXmlFile.getRestoreTask(manifest))
+	}
+
+	err = util.RunTasks(numberOfJobs, tasks)
+	return
+
+}
+
+// Returns the gzipped binary file, such as an Ableton Live Set, that will be restored from the given extracted file.
+func newRestoredGzippedXmlFile(extractedFileName string, filePatternsConfig *applications.FilePatternsConfig) *restoredCompressedXmlFile {
+	newFileName := strings.TrimSuffix(extractedFileName, ".xml")
+	return &restoredCompressedXmlFile{
+		originalFileName: extractedFileName,
+		newFileName:      newFileName,
+		codec:            util.GzipCodec,
+		settings:         config.GetExtractionSettings(newFileName, filePatternsConfig),
+	}
+}
+
+// Appends the given value to the given list, unless it is already in it.
+func appendIfMissing(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
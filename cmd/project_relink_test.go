@@ -0,0 +1,346 @@
+package cmd_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+var abletonLiveSetWithMissingFilesContents string = `<?xml version="1.0" encoding="UTF-8"?>
+<Ableton MajorVersion="5" MinorVersion="11.0_433" Creator="Ableton Live 11.3.4">
+	<LiveSet>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="3" />
+					<RelativePath Value="Samples/snare.wav" />
+					<Path Value="/Users/other/Song Project/Samples/snare.wav" />
+					<OriginalFileSize Value="4" />
+					<OriginalCrc Value="1234" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/Users/other/Drums/hat.wav" />
+					<OriginalFileSize Value="0" />
+					<OriginalCrc Value="0" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+		<AudioClip>
+			<SampleRef>
+				<FileRef>
+					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/Users/other/Drums/clap.wav" />
+					<OriginalFileSize Value="0" />
+					<OriginalCrc Value="0" />
+				</FileRef>
+			</SampleRef>
+		</AudioClip>
+	</LiveSet>
+</Ableton>
+`
+
+var relinkedAbletonLiveSetWithMissingFilesContents string = strings.Replace(
+	abletonLiveSetWithMissingFilesContents,
+	`					<RelativePathType Value="3" />
+					<RelativePath Value="Samples/snare.wav" />
+					<Path Value="/Users/other/Song Project/Samples/snare.wav" />`,
+	`					<RelativePathType Value="0" />
+					<RelativePath Value="" />
+					<Path Value="/home/testuser/library/Drums/snare.wav" />`,
+	1,
+)
+
+func getAbletonLiveSetWithMissingFilesFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("song.als").
+		SetContentsFromBytes(utiltest.GetGzippedContents(abletonLiveSetWithMissingFilesContents))
+}
+
+func getUncompressedAbletonLiveSetWithMissingFilesFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("song.als.xml").
+		SetContentsFromString(abletonLiveSetWithMissingFilesContents)
+}
+
+// Returns builders for files in the library from the global config file, including a file with the same name
+// as a missing file but a different size, and two files with the same name as another missing file.
+func getRelinkLibraryFileBuilders() []*utiltest.MockFileBuilder {
+	return []*utiltest.MockFileBuilder{
+		utiltest.NewMockFileBuilder().
+			SetFilePath("/home/testuser/library/Drums/snare.wav").
+			SetContentsFromString("abcd"),
+		utiltest.NewMockFileBuilder().
+			SetFilePath("/home/testuser/library/Old/Snare.wav").
+			SetContentsFromString("ab"),
+		utiltest.GetEmptyFileBuilder().
+			SetFilePath("/home/testuser/library/Acoustic/hat.wav"),
+		utiltest.GetEmptyFileBuilder().
+			SetFilePath("/home/testuser/library/Electronic/hat.wav"),
+	}
+}
+
+func TestProjectRelinkCmd(t *testing.T) {
+
+	testCases := []*ProjectRelinkCmdTestCase{
+
+		&ProjectRelinkCmdTestCase{
+			description: "Test that missing files with exactly one match in a library are relinked if --force is given, and that the binary file is restored.",
+			args:        []string{"project", "relink", "--force"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							append(
+								getRelinkLibraryFileBuilders(),
+								configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+									SetFilePath(config.MppmConfigFileName),
+								configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+									SetFilePath("/home/testuser/.mppm.json"),
+								getAbletonLiveSetWithMissingFilesFileBuilder(),
+								getUncompressedAbletonLiveSetWithMissingFilesFileBuilder(),
+							)...,
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					append(
+						getRelinkLibraryFileBuilders(),
+						configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+							SetFilePath(config.MppmConfigFileName).
+							SetWasClosed(true),
+						configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+							SetFilePath("/home/testuser/.mppm.json").
+							SetWasClosed(true),
+						getAbletonLiveSetWithMissingFilesFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents(relinkedAbletonLiveSetWithMissingFilesContents)).
+							SetWasClosed(true),
+						getUncompressedAbletonLiveSetWithMissingFilesFileBuilder().
+							SetContentsFromString(relinkedAbletonLiveSetWithMissingFilesContents).
+							SetWasClosed(true),
+						configtest.GetExtractionManifestFileBuilder(
+							getAbletonLiveSetWithMissingFilesFileBuilder().
+								SetContentsFromBytes(utiltest.GetGzippedContents(relinkedAbletonLiveSetWithMissingFilesContents)),
+							getUncompressedAbletonLiveSetWithMissingFilesFileBuilder().
+								SetContentsFromString(relinkedAbletonLiveSetWithMissingFilesContents),
+						).
+							SetWasClosed(true),
+						configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+							SetWasClosed(true),
+					)...,
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Relinked files:\n" +
+							"\t/Users/other/Song Project/Samples/snare.wav -> /home/testuser/library/Drums/snare.wav (used by song.als)\n" +
+							"Missing files that could not be relinked:\n" +
+							"\t/Users/other/Drums/hat.wav (used by song.als): More than one matching file was found: /home/testuser/library/Acoustic/hat.wav, /home/testuser/library/Electronic/hat.wav\n" +
+							"\t/Users/other/Drums/clap.wav (used by song.als): No matching files were found.\n" +
+							"[1/1] Restored song.als\n",
+					),
+				),
+		},
+
+		&ProjectRelinkCmdTestCase{
+			description: "Test that nothing is changed if --preview is given.",
+			args:        []string{"project", "relink", "--preview"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							append(
+								getRelinkLibraryFileBuilders(),
+								configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+									SetFilePath(config.MppmConfigFileName),
+								configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+									SetFilePath("/home/testuser/.mppm.json"),
+								getAbletonLiveSetWithMissingFilesFileBuilder(),
+								getUncompressedAbletonLiveSetWithMissingFilesFileBuilder(),
+							)...,
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					append(
+						getRelinkLibraryFileBuilders(),
+						configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+							SetFilePath(config.MppmConfigFileName).
+							SetWasClosed(true),
+						configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+							SetFilePath("/home/testuser/.mppm.json").
+							SetWasClosed(true),
+						getAbletonLiveSetWithMissingFilesFileBuilder(),
+						getUncompressedAbletonLiveSetWithMissingFilesFileBuilder().
+							SetWasClosed(true),
+					)...,
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Files that will be relinked:\n" +
+							"\t/Users/other/Song Project/Samples/snare.wav -> /home/testuser/library/Drums/snare.wav (used by song.als)\n" +
+							"Missing files that could not be relinked:\n" +
+							"\t/Users/other/Drums/hat.wav (used by song.als): More than one matching file was found: /home/testuser/library/Acoustic/hat.wav, /home/testuser/library/Electronic/hat.wav\n" +
+							"\t/Users/other/Drums/clap.wav (used by song.als): No matching files were found.\n",
+					),
+				),
+		},
+
+		&ProjectRelinkCmdTestCase{
+			description: "Test that the matching files are only listed if --force is not given, since their CRCs are not checked.",
+			args:        []string{"project", "relink"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							append(
+								getRelinkLibraryFileBuilders(),
+								configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+									SetFilePath(config.MppmConfigFileName),
+								configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+									SetFilePath("/home/testuser/.mppm.json"),
+								getAbletonLiveSetWithMissingFilesFileBuilder(),
+								getUncompressedAbletonLiveSetWithMissingFilesFileBuilder(),
+							)...,
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					append(
+						getRelinkLibraryFileBuilders(),
+						configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+							SetFilePath(config.MppmConfigFileName).
+							SetWasClosed(true),
+						configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+							SetFilePath("/home/testuser/.mppm.json").
+							SetWasClosed(true),
+						getAbletonLiveSetWithMissingFilesFileBuilder(),
+						getUncompressedAbletonLiveSetWithMissingFilesFileBuilder().
+							SetWasClosed(true),
+					)...,
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Files that will be relinked:\n" +
+							"\t/Users/other/Song Project/Samples/snare.wav -> /home/testuser/library/Drums/snare.wav (used by song.als)\n" +
+							"Missing files that could not be relinked:\n" +
+							"\t/Users/other/Drums/hat.wav (used by song.als): More than one matching file was found: /home/testuser/library/Acoustic/hat.wav, /home/testuser/library/Electronic/hat.wav\n" +
+							"\t/Users/other/Drums/clap.wav (used by song.als): No matching files were found.\n" +
+							"Nothing was changed. The files are matched by name and size only, so check that they are the right files, then run 'mppm project relink --force' to relink them.\n",
+					),
+				),
+		},
+
+		&ProjectRelinkCmdTestCase{
+			description: "Test that nothing is changed if the binary file has changes that haven't been extracted.",
+			args:        []string{"project", "relink", "--force"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							append(
+								getRelinkLibraryFileBuilders(),
+								configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+									SetFilePath(config.MppmConfigFileName),
+								configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+									SetFilePath("/home/testuser/.mppm.json"),
+								getAbletonLiveSetWithMissingFilesFileBuilder().
+									SetContentsFromBytes(utiltest.GetGzippedContents("<Ableton />")),
+								getUncompressedAbletonLiveSetWithMissingFilesFileBuilder(),
+							)...,
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterError(
+					errors.New(
+						"Changing the files that these Live Sets refer to would overwrite changes that have not been extracted:\n" +
+							"\tsong.als\n" +
+							"To keep the changes, run 'mppm project extract' first, and then try again.",
+					),
+				).
+				SetExiterWasExited(true).
+				SetMockFileBuilders(
+					append(
+						getRelinkLibraryFileBuilders(),
+						configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+							SetFilePath(config.MppmConfigFileName).
+							SetWasClosed(true),
+						configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+							SetFilePath("/home/testuser/.mppm.json").
+							SetWasClosed(true),
+						getAbletonLiveSetWithMissingFilesFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents("<Ableton />")).
+							SetWasClosed(true),
+						getUncompressedAbletonLiveSetWithMissingFilesFileBuilder().
+							SetWasClosed(true),
+					)...,
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Relinked files:\n" +
+							"\t/Users/other/Song Project/Samples/snare.wav -> /home/testuser/library/Drums/snare.wav (used by song.als)\n" +
+							"Missing files that could not be relinked:\n" +
+							"\t/Users/other/Drums/hat.wav (used by song.als): More than one matching file was found: /home/testuser/library/Acoustic/hat.wav, /home/testuser/library/Electronic/hat.wav\n" +
+							"\t/Users/other/Drums/clap.wav (used by song.als): No matching files were found.\n",
+					),
+				),
+		},
+
+		&ProjectRelinkCmdTestCase{
+			description: "Test that an error is raised if a given folder doesn't exist.",
+			args:        []string{"project", "relink", "/home/testuser/missing-folder"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterError(errors.New("The folder '/home/testuser/missing-folder' does not exist.")).
+				SetExiterWasExited(true).
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName),
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+// ------------------------------------------------------------------------------
+
+type ProjectRelinkCmdTestCase struct {
+	description                              string
+	args                                     []string
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *ProjectRelinkCmdTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	cmd.RootCmd.SetArgs(testCase.args)
+	cmd.RootCmd.Execute()
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
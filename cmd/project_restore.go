@@ -1,19 +1,50 @@
 package cmd
 
 import (
+	"bytes"
+	"errors"
+	"sort"
 	"strings"
 
 	"github.com/stevengt/mppm/config"
 	"github.com/stevengt/mppm/config/applications"
 
 	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/ableton"
+	"github.com/stevengt/mppm/reaper"
 	"github.com/stevengt/mppm/util"
 )
 
 func init() {
+
+	cobra.OnInitialize(
+		func() {
+			restoreConflictAction, _ = RestoreCmd.Flags().GetString("on-conflict")
+		},
+	)
+
+	RestoreCmd.Flags().StringVar(
+		&restoreConflictAction,
+		"on-conflict",
+		restoreConflictActionAbort,
+		`What to do if a binary file has changes that haven't been extracted, and would be lost by restoring it:
+  abort      Restores nothing, and lists the files with changes.
+  backup     Copies the files into .mppm/backups/ before restoring them.
+  overwrite  Restores the files anyway.`,
+	)
+
 	ProjectCmd.AddCommand(RestoreCmd)
+
 }
 
+const (
+	restoreConflictActionAbort     = "abort"
+	restoreConflictActionBackup    = "backup"
+	restoreConflictActionOverwrite = "overwrite"
+)
+
+var restoreConflictAction string
+
 var RestoreCmd = &cobra.Command{
 
 	Use: "restore",
@@ -23,11 +54,22 @@ var RestoreCmd = &cobra.Command{
 	Long: `Restores all plain-text files of supported types to their original binary files.
 			
 Note that the original files are not stored in git directly.
-To extract them into plain-text files for use in git, run 'mppm project extract'.`,
+To extract them into plain-text files for use in git, run 'mppm project extract'.
+Files that were extracted with each track in a separate file are joined together again.
+
+If a binary file has changed since it was last extracted or restored, and its contents are
+different from its plain-text file, then restoring it would lose those changes. By default,
+nothing is restored in that case. See --on-conflict for other options.`,
 
 	Args: cobra.NoArgs,
 
 	Run: func(cmd *cobra.Command, args []string) {
+		switch restoreConflictAction {
+		case restoreConflictActionAbort, restoreConflictActionBackup, restoreConflictActionOverwrite:
+		default:
+			util.ExitWithErrorMessage("Invalid value for --on-conflict: '" + restoreConflictAction + "'. Please use 'abort', 'backup', or 'overwrite'.")
+			return
+		}
 		if err := restoreAllUncompressedFilesToOriginalCompressedFiles(); err != nil {
 			util.ExitWithError(err)
 		}
@@ -41,7 +83,30 @@ func restoreAllUncompressedFilesToOriginalCompressedFiles() (err error) {
 		return
 	}
 
-	err = restoreAllGzippedXmlFiles(filePatternsConfig)
+	manifest, err := config.LoadExtractionManifest()
+	if err != nil {
+		return
+	}
+
+	err = restoreAllCompressedXmlFiles(filePatternsConfig, manifest)
+
+	// Record any files that were restored, even if others couldn't be.
+	if !isPreviewCommand {
+		if saveErr := manifest.Save(); err == nil {
+			err = saveErr
+		}
+	}
+
+	if err != nil {
+		return
+	}
+
+	err = restoreAllZippedFiles(filePatternsConfig, manifest)
+	if err != nil {
+		return
+	}
+
+	err = joinAllPluginStateChunkFiles(filePatternsConfig, manifest)
 	if err != nil {
 		return
 	}
@@ -49,50 +114,599 @@ func restoreAllUncompressedFilesToOriginalCompressedFiles() (err error) {
 	return
 }
 
-func restoreAllGzippedXmlFiles(filePatternsConfig *applications.FilePatternsConfig) (err error) {
-	gzippedXmlFileExtensions := filePatternsConfig.GzippedXmlFileExtensions
-	for _, fileExtension := range gzippedXmlFileExtensions {
-		err = restoreAllGzippedXmlFilesWithExtension(fileExtension)
+// Restores all compressed XML files concurrently, using up to the number of jobs given by --jobs.
+//
+// If any binary files have changes that would be lost by restoring them, then those files are handled
+// as given by --on-conflict before anything is restored.
+func restoreAllCompressedXmlFiles(filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	compressedXmlFiles := make([]*restoredCompressedXmlFile, 0)
+
+	for _, fileExtension := range filePatternsConfig.CompressedXmlFileExtensions {
+		var extensionFiles []*restoredCompressedXmlFile
+		codec := filePatternsConfig.GetCompressedXmlFileCodec(fileExtension)
+		extensionFiles, err = getRestoredCompressedXmlFiles(fileExtension, codec, filePatternsConfig, manifest)
 		if err != nil {
 			return
 		}
+		compressedXmlFiles = append(compressedXmlFiles, extensionFiles...)
+	}
+
+	conflictingFileNames, err := findRestoreConflicts(compressedXmlFiles, filePatternsConfig, manifest)
+	if err != nil {
+		return
+	}
+
+	if len(conflictingFileNames) > 0 {
+		switch restoreConflictAction {
+		case restoreConflictActionAbort:
+			err = getRestoreConflictError(conflictingFileNames)
+			return
+		case restoreConflictActionBackup:
+			if !isPreviewCommand {
+				err = config.WriteMppmDirectoryGitIgnoreFile()
+				if err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	tasks := make([]*util.Task, 0, len(compressedXmlFiles))
+	for _, compressedXmlFile := range compressedXmlFiles {
+		tasks = append(tasks, compressedXmlFile.getRestoreTask(manifest))
 	}
+
+	err = util.RunTasks(numberOfJobs, tasks)
 	return
+
 }
 
-func restoreAllGzippedXmlFilesWithExtension(fileExtension string) (err error) {
+// A binary file that will be restored from its extracted XML file.
+type restoredCompressedXmlFile struct {
+	originalFileName string // The extracted XML file.
+	newFileName      string // The binary file.
+	codec            util.CompressionCodec
+	settings         *config.ExtractionSettings // The settings to record for the binary file once it is restored.
+	hasConflict      bool                       // True if restoring the binary file would lose changes that haven't been extracted.
+}
+
+// Returns all binary files with the given extension that need to be restored.
+func getRestoredCompressedXmlFiles(fileExtension string, codec util.CompressionCodec, filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (compressedXmlFiles []*restoredCompressedXmlFile, err error) {
 
 	fileNames, err := util.GetAllFileNamesWithExtension(fileExtension + ".xml")
 	if err != nil {
 		return
 	}
+	sort.Strings(fileNames)
+
+	compressedXmlFiles = make([]*restoredCompressedXmlFile, 0, len(fileNames))
 
-	for _, originalFileName := range fileNames {
+	for _, fileName := range fileNames {
 
+		originalFileName := fileName
 		newFileName := strings.TrimSuffix(originalFileName, ".xml")
 
-		if isPreviewCommand {
-			printRestorePreviewMessage(originalFileName, newFileName)
-		} else {
+		settings := config.GetExtractionSettings(newFileName, filePatternsConfig)
+
+		var isUpToDate bool
+		isUpToDate, err = isExtractedFileUpToDate(manifest, newFileName, originalFileName, settings)
+		if err != nil {
+			return
+		}
+		if isUpToDate {
+			continue
+		}
+
+		// The extracted file was made with the settings recorded when it was last extracted,
+		// which may be different from the current settings if they were changed since then.
+		if recordedSettings := manifest.GetSettings(newFileName); recordedSettings != nil {
+			settings = recordedSettings
+		}
+
+		compressedXmlFiles = append(
+			compressedXmlFiles,
+			&restoredCompressedXmlFile{
+				originalFileName: originalFileName,
+				newFileName:      newFileName,
+				codec:            codec,
+				settings:         settings,
+			},
+		)
+
+	}
+
+	return
+
+}
+
+// Concurrently checks which of the given binary files have changes that would be lost by restoring them,
+// and returns their names. Nothing is checked if --on-conflict=overwrite is given.
+func findRestoreConflicts(compressedXmlFiles []*restoredCompressedXmlFile, filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (conflictingFileNames []string, err error) {
+
+	conflictingFileNames = make([]string, 0)
+
+	if restoreConflictAction == restoreConflictActionOverwrite {
+		return
+	}
+
+	tasks := make([]*util.Task, 0, len(compressedXmlFiles))
+	for _, compressedXmlFile := range compressedXmlFiles {
+		compressedXmlFile := compressedXmlFile
+		tasks = append(
+			tasks,
+			&util.Task{
+				Name: compressedXmlFile.newFileName,
+				Run: func(printer util.WritePrinter) (err error) {
+					compressedXmlFile.hasConflict, err = compressedXmlFile.isRestoreConflict(filePatternsConfig, manifest)
+					return
+				},
+			},
+		)
+	}
+
+	err = util.RunTasks(numberOfJobs, tasks)
+	if err != nil {
+		return
+	}
+
+	for _, compressedXmlFile := range compressedXmlFiles {
+		if compressedXmlFile.hasConflict {
+			conflictingFileNames = append(conflictingFileNames, compressedXmlFile.newFileName)
+		}
+	}
+
+	return
+
+}
+
+// Returns true if the binary file exists, has changed since it was last extracted or restored,
+// and its contents are different from the extracted file.
+func (compressedXmlFile *restoredCompressedXmlFile) isRestoreConflict(filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (isConflict bool, err error) {
+
+	if !util.DoesFileExist(compressedXmlFile.newFileName) {
+		return
+	}
+
+	hasChanged, err := manifest.HasSourceChanged(compressedXmlFile.newFileName)
+	if err != nil || !hasChanged {
+		return
+	}
+
+	binaryContents, err := readExtractedXmlContents(compressedXmlFile.newFileName, compressedXmlFile.codec, filePatternsConfig)
+	if err != nil {
+		return
+	}
 
-			err = util.GzipFile(originalFileName)
+	extractedContents, err := readExtractedXmlFile(compressedXmlFile.originalFileName)
+	if err != nil {
+		return
+	}
+
+	isConflict = !bytes.Equal(binaryContents, extractedContents)
+	return
+
+}
+
+func (compressedXmlFile *restoredCompressedXmlFile) getRestoreTask(manifest *config.ExtractionManifest) *util.Task {
+
+	originalFileName := compressedXmlFile.originalFileName
+	newFileName := compressedXmlFile.newFileName
+	shouldBackUp := compressedXmlFile.hasConflict && restoreConflictAction == restoreConflictActionBackup
+
+	task := &util.Task{
+		Name: originalFileName,
+		Run: func(printer util.WritePrinter) (err error) {
+
+			if shouldBackUp {
+				err = backUpBinaryFile(printer, newFileName)
+				if err != nil {
+					return
+				}
+			}
+
+			if isPreviewCommand {
+				printRestorePreviewMessage(printer, originalFileName, newFileName)
+				return
+			}
+
+			var worktrees *config.ProjectLibraryWorktrees
+			worktrees, err = getLibraryWorktreesForXmlFile(newFileName)
 			if err != nil {
 				return
 			}
 
-			err = util.RenameFile(originalFileName+".gz", newFileName)
+			if util.DoesFileExist(getSplitXmlPartsDirectoryName(originalFileName)) || len(worktrees.FilePaths) > 0 {
+				err = restoreJoinedXmlFile(originalFileName, newFileName, compressedXmlFile.codec, worktrees)
+			} else {
+				err = restoreCompressedXmlFile(originalFileName, newFileName, compressedXmlFile.codec)
+			}
 			if err != nil {
 				return
 			}
 
+			err = updateExtractionManifest(manifest, newFileName, originalFileName, compressedXmlFile.settings)
+			return
+
+		},
+	}
+	if !isPreviewCommand {
+		task.ProgressMessage = "Restored " + newFileName
+	}
+
+	return task
+
+}
+
+// Copies the given binary file into a backup directory named after the time it was last modified.
+func backUpBinaryFile(printer util.WritePrinter, fileName string) (err error) {
+
+	fileInfo, err := util.StatFile(fileName)
+	if err != nil {
+		return
+	}
+
+	backupFileName := util.JoinFilePath(config.GetBackupDirectoryName(fileInfo.ModTime()), fileName)
+
+	if isPreviewCommand {
+		printer.Println(fileName + " will be backed up to " + backupFileName)
+		return
+	}
+
+	err = util.CopyFile(fileName, backupFileName)
+	if err != nil {
+		return
+	}
+
+	printer.Println("Backed up " + fileName + " to " + backupFileName)
+	return
+
+}
+
+// Handles the given files, whose changes would be lost by restoring them, as given by --on-conflict.
+// Returns an error if --on-conflict=abort is given, or backs the files up if --on-conflict=backup is given.
+func resolveRestoreConflicts(conflictingFileNames []string) (err error) {
+
+	if len(conflictingFileNames) == 0 {
+		return
+	}
+
+	switch restoreConflictAction {
+	case restoreConflictActionAbort:
+		err = getRestoreConflictError(conflictingFileNames)
+		return
+	case restoreConflictActionBackup:
+		if !isPreviewCommand {
+			err = config.WriteMppmDirectoryGitIgnoreFile()
+			if err != nil {
+				return
+			}
+		}
+		for _, fileName := range conflictingFileNames {
+			err = backUpBinaryFile(util.Logger, fileName)
+			if err != nil {
+				return
+			}
 		}
+	}
 
+	return
+
+}
+
+func getRestoreConflictError(conflictingFileNames []string) error {
+	errorMessage := "Restoring would overwrite changes that have not been extracted in these files:\n"
+	for _, fileName := range conflictingFileNames {
+		errorMessage += "\t" + fileName + "\n"
+	}
+	errorMessage += "To keep the changes, run 'mppm project extract' first, or run 'mppm project restore --on-conflict=backup'.\n" +
+		"To discard the changes, run 'mppm project restore --on-conflict=overwrite'."
+	return errors.New(errorMessage)
+}
+
+// Compresses the given extracted XML file into the given binary file using the given codec.
+func restoreCompressedXmlFile(originalFileName string, newFileName string, codec util.CompressionCodec) (err error) {
+
+	if codec != util.GzipCodec {
+		err = util.CompressFile(originalFileName, newFileName, codec)
+		return
+	}
+
+	err = util.GzipFile(originalFileName)
+	if err != nil {
+		return
 	}
 
+	err = util.RenameFile(originalFileName+".gz", newFileName)
 	return
 
 }
 
-func printRestorePreviewMessage(originalFileName string, newFileName string) {
-	util.Println(newFileName + " will be restored from " + originalFileName)
+// Joins the parts of the given XML file back together if it was split, changes any references to files in libraries
+// to refer to the worktrees that the project has checked the libraries out into, and compresses the result into the
+// given binary file.
+func restoreJoinedXmlFile(originalFileName string, newFileName string, codec util.CompressionCodec, worktrees *config.ProjectLibraryWorktrees) (err error) {
+
+	contents, err := readExtractedXmlFile(originalFileName)
+	if err != nil {
+		return
+	}
+
+	if len(worktrees.FilePaths) > 0 {
+		var root *ableton.Element
+		root, err = ableton.ParseElementFromBytes(contents)
+		if err != nil {
+			return
+		}
+		var wasRelinked bool
+		wasRelinked, err = relinkLibraryWorktreeFileRefs(root, worktrees, true)
+		if err != nil {
+			return
+		}
+		if wasRelinked {
+			contents = root.AsXmlDocument()
+		}
+	}
+
+	compressedContents, err := util.CompressBytes(contents, codec)
+	if err != nil {
+		return
+	}
+
+	err = util.WriteFile(newFileName, compressedContents)
+	return
+
+}
+
+// Zips all unzipped directories back into their zip archives, skipping any that haven't changed since they were last extracted or restored.
+//
+// If any zip archives have changes that would be lost by restoring them, then those files are handled
+// as given by --on-conflict before anything is restored.
+func restoreAllZippedFiles(filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	fileNames := make([]string, 0)
+	conflictingFileNames := make([]string, 0)
+
+	for _, fileExtension := range filePatternsConfig.ZippedFileExtensions {
+
+		var directoryNames []string
+		directoryNames, err = util.GetAllDirectoryNamesWithExtension(getUnzippedDirectoryName(fileExtension))
+		if err != nil {
+			return
+		}
+		sort.Strings(directoryNames)
+
+		for _, directoryName := range directoryNames {
+
+			fileName := strings.TrimSuffix(directoryName, getUnzippedDirectoryName(""))
+
+			var isUpToDate bool
+			isUpToDate, err = isUnzippedDirectoryUpToDate(manifest, fileName, config.GetExtractionSettings(fileName, filePatternsConfig))
+			if err != nil {
+				return
+			}
+			if isUpToDate {
+				continue
+			}
+
+			var isConflict bool
+			isConflict, err = isZipRestoreConflict(fileName, manifest)
+			if err != nil {
+				return
+			}
+			if isConflict {
+				conflictingFileNames = append(conflictingFileNames, fileName)
+			}
+
+			fileNames = append(fileNames, fileName)
+
+		}
+
+	}
+
+	err = resolveRestoreConflicts(conflictingFileNames)
+	if err != nil {
+		return
+	}
+
+	for _, fileName := range fileNames {
+
+		directoryName := getUnzippedDirectoryName(fileName)
+
+		if isPreviewCommand {
+			printRestorePreviewMessage(util.Logger, directoryName, fileName)
+			continue
+		}
+
+		err = util.ZipDirectory(directoryName, fileName)
+		if err != nil {
+			return
+		}
+
+		err = updateUnzippedDirectoryManifest(manifest, fileName, config.GetExtractionSettings(fileName, filePatternsConfig))
+		if err != nil {
+			return
+		}
+
+	}
+
+	if !isPreviewCommand {
+		err = manifest.Save()
+	}
+
+	return
+
+}
+
+// Returns true if the given zip archive exists, has changed since it was last extracted or restored,
+// and the files in it are different from the files in its unzipped directory.
+// Nothing is checked if --on-conflict=overwrite is given.
+func isZipRestoreConflict(fileName string, manifest *config.ExtractionManifest) (isConflict bool, err error) {
+
+	if restoreConflictAction == restoreConflictActionOverwrite || !util.DoesFileExist(fileName) {
+		return
+	}
+
+	hasChanged, err := manifest.HasSourceChanged(fileName)
+	if err != nil || !hasChanged {
+		return
+	}
+
+	zippedFileContents, err := util.ReadZipFile(fileName)
+	if err != nil {
+		return
+	}
+
+	unzippedFileContents, err := util.ReadDirectoryAsZipContents(getUnzippedDirectoryName(fileName))
+	if err != nil {
+		return
+	}
+
+	if len(zippedFileContents) != len(unzippedFileContents) {
+		isConflict = true
+		return
+	}
+
+	for zippedFileName, contents := range zippedFileContents {
+		if unzippedContents, ok := unzippedFileContents[zippedFileName]; !ok || !bytes.Equal(contents, unzippedContents) {
+			isConflict = true
+			return
+		}
+	}
+
+	return
+
+}
+
+// Recreates all plain-text project files from their split copies made by 'mppm project extract --split-plugin-state'.
+//
+// If any project files have changes that would be lost by recreating them, then those files are handled
+// as given by --on-conflict before anything is recreated.
+func joinAllPluginStateChunkFiles(filePatternsConfig *applications.FilePatternsConfig, manifest *config.ExtractionManifest) (err error) {
+
+	splitFileExtensions := make([]string, 0, len(filePatternsConfig.PluginStateChunkFileExtensions))
+	for _, fileExtension := range filePatternsConfig.PluginStateChunkFileExtensions {
+		splitFileExtensions = append(splitFileExtensions, getPluginStateSplitFileName(fileExtension))
+	}
+
+	splitFileNames, err := util.GetAllFileNamesWithAnyExtension(splitFileExtensions...)
+	if err != nil {
+		return
+	}
+	sort.Strings(splitFileNames)
+
+	fileNames := make([]string, 0, len(splitFileNames))
+	joinedContents := make(map[string][]byte)
+	conflictingFileNames := make([]string, 0)
+
+	for _, splitFileName := range splitFileNames {
+
+		fileName := strings.TrimSuffix(splitFileName, getPluginStateSplitFileName(""))
+
+		var isUpToDate bool
+		isUpToDate, err = isExtractedFileUpToDate(manifest, fileName, splitFileName, config.GetExtractionSettings(fileName, filePatternsConfig))
+		if err != nil {
+			return
+		}
+		if isUpToDate {
+			continue
+		}
+
+		var splitContents []byte
+		splitContents, err = util.ReadFile(splitFileName)
+		if err != nil {
+			return
+		}
+
+		var contents []byte
+		contents, err = joinPluginStateChunks(fileName, splitContents)
+		if err != nil {
+			return
+		}
+
+		var isConflict bool
+		isConflict, err = isPluginStateRestoreConflict(fileName, contents, manifest)
+		if err != nil {
+			return
+		}
+		if isConflict {
+			conflictingFileNames = append(conflictingFileNames, fileName)
+		}
+
+		fileNames = append(fileNames, fileName)
+		joinedContents[fileName] = contents
+
+	}
+
+	err = resolveRestoreConflicts(conflictingFileNames)
+	if err != nil {
+		return
+	}
+
+	for _, fileName := range fileNames {
+
+		splitFileName := getPluginStateSplitFileName(fileName)
+
+		if isPreviewCommand {
+			printRestorePreviewMessage(util.Logger, splitFileName, fileName)
+			continue
+		}
+
+		err = util.WriteFile(fileName, joinedContents[fileName])
+		if err != nil {
+			return
+		}
+
+		err = manifest.Update(fileName, splitFileName, config.GetExtractionSettings(fileName, filePatternsConfig))
+		if err != nil {
+			return
+		}
+
+	}
+
+	if !isPreviewCommand {
+		err = manifest.Save()
+	}
+
+	return
+
+}
+
+// Returns true if the given project file exists, has changed since it was last split or recreated,
+// and its contents are different from the given contents joined from its split copy.
+// Nothing is checked if --on-conflict=overwrite is given.
+func isPluginStateRestoreConflict(fileName string, joinedContents []byte, manifest *config.ExtractionManifest) (isConflict bool, err error) {
+
+	if restoreConflictAction == restoreConflictActionOverwrite || !util.DoesFileExist(fileName) {
+		return
+	}
+
+	hasChanged, err := manifest.HasSourceChanged(fileName)
+	if err != nil || !hasChanged {
+		return
+	}
+
+	contents, err := util.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+
+	isConflict = !bytes.Equal(contents, joinedContents)
+	return
+
+}
+
+func joinPluginStateChunks(fileName string, splitContents []byte) (contents []byte, err error) {
+	chunkDirectoryName := getPluginStateChunkDirectoryName(fileName)
+	contents, err = reaper.JoinPluginStateChunks(
+		splitContents,
+		func(chunkId string) ([]byte, error) {
+			return util.ReadFile(util.JoinFilePath(chunkDirectoryName, chunkId))
+		},
+	)
+	return
+}
+
+func printRestorePreviewMessage(printer util.WritePrinter, originalFileName string, newFileName string) {
+	printer.Println(newFileName + " will be restored from " + originalFileName)
 }
@@ -1,13 +1,17 @@
 package cmd_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
 	"github.com/stevengt/mppm/config/configtest"
+	"github.com/stevengt/mppm/reaper/reapertest"
 
 	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util"
 	"github.com/stevengt/mppm/util/utiltest"
 )
 
@@ -43,6 +47,18 @@ func TestProjectRestoreCmd(t *testing.T) {
 					utiltest.GetFakeAbletonLiveClipFileBuilder().
 						SetWasClosed(true),
 					utiltest.GetPlainTextFileBuilder(),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeAbletonLiveClipFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder(),
+						utiltest.GetFakeAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/2] Restored fake-ableton-live-clip.alc\n[2/2] Restored fake-ableton-live-set.als\n"),
 				),
 		},
 
@@ -82,6 +98,605 @@ func TestProjectRestoreCmd(t *testing.T) {
 				),
 		},
 
+		&ProjectRestoreCmdTestCase{
+			description: "Test that files which haven't changed since they were last extracted or restored are skipped.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeLmmsProjectFileBuilder(),
+							utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+							configtest.GetExtractionManifestFileBuilder(
+								utiltest.GetFakeLmmsProjectFileBuilder(),
+								utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+							),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeLmmsProjectFileBuilder(),
+					utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeLmmsProjectFileBuilder(),
+						utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+					).
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that nothing is restored if a binary file has changes that haven't been extracted.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeLmmsProjectFileBuilder(),
+							utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+								SetContentsFromString("<lmms-project />"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterError(
+					errors.New(
+						"Restoring would overwrite changes that have not been extracted in these files:\n"+
+							"\tfake-lmms-project.mmpz\n"+
+							"To keep the changes, run 'mppm project extract' first, or run 'mppm project restore --on-conflict=backup'.\n"+
+							"To discard the changes, run 'mppm project restore --on-conflict=overwrite'.",
+					),
+				).
+				SetExiterWasExited(true).
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeLmmsProjectFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+						SetContentsFromString("<lmms-project />").
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that binary files with changes that haven't been extracted are backed up before they are restored.",
+			args:        []string{"project", "restore", "--on-conflict=backup"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeLmmsProjectFileBuilder(),
+							utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+								SetContentsFromString("<lmms-project />"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeLmmsProjectFileBuilder().
+						SetContentsFromBytes(utiltest.GetQCompressedContents("<lmms-project />")).
+						SetWasClosed(true),
+					utiltest.GetFakeLmmsProjectFileBuilder().
+						SetFilePath(".mppm/backups/20210101-000000/fake-lmms-project.mmpz").
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+						SetContentsFromString("<lmms-project />").
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeLmmsProjectFileBuilder().
+							SetContentsFromBytes(utiltest.GetQCompressedContents("<lmms-project />")),
+						utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+							SetContentsFromString("<lmms-project />"),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Backed up fake-lmms-project.mmpz to .mppm/backups/20210101-000000/fake-lmms-project.mmpz\n" +
+							"[1/1] Restored fake-lmms-project.mmpz\n",
+					),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that binary files with changes that haven't been extracted are overwritten if --on-conflict=overwrite is given.",
+			args:        []string{"project", "restore", "--on-conflict=overwrite"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeLmmsProjectFileBuilder(),
+							utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+								SetContentsFromString("<lmms-project />"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeLmmsProjectFileBuilder().
+						SetContentsFromBytes(utiltest.GetQCompressedContents("<lmms-project />")).
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+						SetContentsFromString("<lmms-project />").
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeLmmsProjectFileBuilder().
+							SetContentsFromBytes(utiltest.GetQCompressedContents("<lmms-project />")),
+						utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+							SetContentsFromString("<lmms-project />"),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Restored fake-lmms-project.mmpz\n"),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that binary files which haven't changed since they were last extracted are restored from changed extracted files.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeLmmsProjectFileBuilder(),
+							utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+								SetContentsFromString("<lmms-project />"),
+							configtest.GetExtractionManifestFileBuilder(
+								utiltest.GetFakeLmmsProjectFileBuilder(),
+								utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+							),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeLmmsProjectFileBuilder().
+						SetContentsFromBytes(utiltest.GetQCompressedContents("<lmms-project />")).
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+						SetContentsFromString("<lmms-project />").
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeLmmsProjectFileBuilder().
+							SetContentsFromBytes(utiltest.GetQCompressedContents("<lmms-project />")),
+						utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+							SetContentsFromString("<lmms-project />"),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Restored fake-lmms-project.mmpz\n"),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that an error is raised for an invalid --on-conflict value.",
+			args:        []string{"project", "restore", "--on-conflict=ask"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterError(errors.New("Invalid value for --on-conflict: 'ask'. Please use 'abort', 'backup', or 'overwrite'.")).
+				SetExiterWasExited(true).
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName),
+					utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that the tracks of split Live Sets are joined back together when they are restored.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndSplitAbletonApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeSplitAbletonLiveSetGlobalSettingsFileBuilder(),
+							utiltest.GetFakeSplitAbletonLiveSetMidiTrackFileBuilder(),
+							utiltest.GetFakeSplitAbletonLiveSetMasterTrackFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndSplitAbletonApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeSplitAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeSplitAbletonLiveSetGlobalSettingsFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeSplitAbletonLiveSetMidiTrackFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeSplitAbletonLiveSetMasterTrackFileBuilder().
+						SetWasClosed(true),
+					configtest.GetSplitExtractionManifestFileBuilder(
+						utiltest.GetFakeSplitAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeSplitAbletonLiveSetGlobalSettingsFileBuilder(),
+						utiltest.GetFakeSplitAbletonLiveSetMidiTrackFileBuilder(),
+						utiltest.GetFakeSplitAbletonLiveSetMasterTrackFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Restored fake-split-ableton-live-set.als\n"),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that Live Sets extracted in a canonical form are restored with the canonical values of their volatile attributes.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndCanonicalizedAbletonApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder().
+								SetContentsFromString(canonicalizedAbletonLive11SetContents),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndCanonicalizedAbletonApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder().
+						SetContentsFromString(canonicalizedAbletonLive11SetContents).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLive11SetFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(canonicalizedAbletonLive11SetContents)).
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilderWithFilePatternsConfig(
+						applications.GetAllFilePatternsConfig().WithoutXmlSplitting(),
+						utiltest.GetFakeAbletonLive11SetFileBuilder().
+							SetContentsFromBytes(utiltest.GetGzippedContents(canonicalizedAbletonLive11SetContents)),
+						utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder().
+							SetContentsFromString(canonicalizedAbletonLive11SetContents),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Restored fake-ableton-live-11-set.als\n"),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that uncompressed XML files are restored to their original qCompressed files.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedLmmsProjectFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeLmmsProjectFileBuilder().
+						SetContentsFromBytes(
+							utiltest.GetQCompressedContents(string(utiltest.GetFakeUncompressedLmmsProjectFileBuilder().Contents)),
+						).
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeLmmsProjectFileBuilder().
+							SetContentsFromBytes(
+								utiltest.GetQCompressedContents(string(utiltest.GetFakeUncompressedLmmsProjectFileBuilder().Contents)),
+							),
+						utiltest.GetFakeUncompressedLmmsProjectFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/1] Restored fake-lmms-project.mmpz\n"),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that extracted zip archives are zipped back into their original files.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+							utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					configtest.GetUnzippedExtractionManifestFileBuilder(
+						utiltest.GetFakeStudioOneSongFileBuilder(),
+						utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+						utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that all affected zip archives are displayed without actually making the changes.",
+			args:        []string{"project", "restore", "--preview"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+							utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+					utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("fake-studio-one-song.song will be restored from fake-studio-one-song.song.unzipped\n"),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that zip archives which haven't changed since they were last extracted or restored are skipped.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeStudioOneSongFileBuilder(),
+							utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+							utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+							configtest.GetUnzippedExtractionManifestFileBuilder(
+								utiltest.GetFakeStudioOneSongFileBuilder(),
+								utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+								utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+							),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeStudioOneSongFileBuilder(),
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+					utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+					configtest.GetUnzippedExtractionManifestFileBuilder(
+						utiltest.GetFakeStudioOneSongFileBuilder(),
+						utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+						utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+					).
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that zip archives with changes that haven't been extracted are not overwritten.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeStudioOneSongFileBuilder(),
+							utiltest.GetFakeUnzippedStudioOneSongFileBuilder().
+								SetContentsFromString("<Song Tempo=\"120\" />"),
+							utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New("Restoring would overwrite changes that have not been extracted in these files:\n"+
+						"\tfake-studio-one-song.song\n"+
+						"To keep the changes, run 'mppm project extract' first, or run 'mppm project restore --on-conflict=backup'.\n"+
+						"To discard the changes, run 'mppm project restore --on-conflict=overwrite'."),
+				).
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder().
+						SetContentsFromString("<Song Tempo=\"120\" />").
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder().
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that plain-text project files are recreated from their copies with split plugin states.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.RPP.split").
+								SetContentsFromString(reapertest.SplitProjectRpp),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.RPP.chunks/"+reapertest.ChunkId).
+								SetContentsFromString(reapertest.Chunk),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.RPP").
+						SetContentsFromString(reapertest.OriginalProjectRpp).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.RPP.split").
+						SetContentsFromString(reapertest.SplitProjectRpp).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.RPP.chunks/"+reapertest.ChunkId).
+						SetContentsFromString(reapertest.Chunk).
+						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.NewMockFileBuilder().
+							SetFilePath("song.RPP").
+							SetContentsFromString(reapertest.OriginalProjectRpp),
+						utiltest.NewMockFileBuilder().
+							SetFilePath("song.RPP.split").
+							SetContentsFromString(reapertest.SplitProjectRpp),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that plain-text project files with changes that weren't split yet are not overwritten.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.RPP").
+								SetContentsFromString(reapertest.OriginalProjectRpp+"<EXTENSIONS\n>\n"),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.RPP.split").
+								SetContentsFromString(reapertest.SplitProjectRpp),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.RPP.chunks/"+reapertest.ChunkId).
+								SetContentsFromString(reapertest.Chunk),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterWasExited(true).
+				SetExiterError(
+					errors.New("Restoring would overwrite changes that have not been extracted in these files:\n"+
+						"\tsong.RPP\n"+
+						"To keep the changes, run 'mppm project extract' first, or run 'mppm project restore --on-conflict=backup'.\n"+
+						"To discard the changes, run 'mppm project restore --on-conflict=overwrite'."),
+				).
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.RPP").
+						SetContentsFromString(reapertest.OriginalProjectRpp+"<EXTENSIONS\n>\n").
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.RPP.split").
+						SetContentsFromString(reapertest.SplitProjectRpp).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.RPP.chunks/"+reapertest.ChunkId).
+						SetContentsFromString(reapertest.Chunk).
+						SetWasClosed(true),
+				),
+		},
+
+		&ProjectRestoreCmdTestCase{
+			description: "Test that any error from reading a missing plugin state chunk is properly raised.",
+			args:        []string{"project", "restore"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.NewMockFileBuilder().
+								SetFilePath("song.RPP.split").
+								SetContentsFromString(reapertest.SplitProjectRpp),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterWasExited(true).
+				SetExiterError(errors.New("Unable to open file song.RPP.chunks/"+reapertest.ChunkId)).
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndReaperApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath("song.RPP.split").
+						SetContentsFromString(reapertest.SplitProjectRpp).
+						SetWasClosed(true),
+				),
+		},
+
 		&ProjectRestoreCmdTestCase{
 			description: "Test that any error resulting from an invalid config file is properly raised.",
 			args:        []string{"project", "restore"},
@@ -146,7 +761,14 @@ func TestProjectRestoreCmd(t *testing.T) {
 				),
 			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
 				SetExiterWasExited(true).
-				SetExiterError(utiltest.DefaultCreateFileError).
+				SetExiterError(
+					util.TaskErrors{
+						&util.TaskError{
+							TaskName: utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().FilePath,
+							Err:      utiltest.DefaultCreateFileError,
+						},
+					},
+				).
 				SetMockFileBuilders(
 					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
 						SetFilePath(config.MppmConfigFileName).
@@ -171,7 +793,14 @@ func TestProjectRestoreCmd(t *testing.T) {
 				),
 			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
 				SetExiterWasExited(true).
-				SetExiterError(utiltest.DefaultRenameFileError).
+				SetExiterError(
+					util.TaskErrors{
+						&util.TaskError{
+							TaskName: utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().FilePath,
+							Err:      utiltest.DefaultRenameFileError,
+						},
+					},
+				).
 				SetMockFileBuilders(
 					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
 						SetFilePath(config.MppmConfigFileName).
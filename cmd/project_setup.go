@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	ProjectCmd.AddCommand(SetupCmd)
+}
+
+var SetupCmd = &cobra.Command{
+
+	Use: "setup",
+
+	Short: "Configures git for a project that was cloned, such as to merge extracted Ableton files with mppm.",
+
+	Long: `Configures git for a project that was cloned, such as to merge extracted Ableton files with mppm.
+
+'mppm project init' registers mppm's merge driver in the project's own git config, which isn't
+copied when the project is cloned. Without it, git merges extracted Ableton files line by line.
+Run this once in each clone of the project. It can safely be run more than once.
+
+If any lines are added to .gitattributes, commit them so that other clones use them too.`,
+
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := setupProject(); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func setupProject() (err error) {
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	gitRepoFilePath := "."
+	gitManager := util.NewGitManager(gitRepoFilePath)
+
+	err = gitManager.LfsInstall()
+	if err != nil {
+		return
+	}
+
+	abletonXmlFileExtensions := applications.AbletonInfo.FilterCompressedXmlFileExtensions(filePatternsConfig.CompressedXmlFileExtensions)
+	err = registerMergeDriver(gitManager, abletonXmlFileExtensions)
+	if err != nil {
+		return
+	}
+
+	if len(abletonXmlFileExtensions) == 0 {
+		util.Println("Configured git for the project. The merge driver wasn't registered, since the project doesn't use Ableton.")
+		return
+	}
+
+	util.Println("Configured git to merge extracted Ableton files with 'mppm merge-driver'.")
+	return
+
+}
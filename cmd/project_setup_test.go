@@ -0,0 +1,144 @@
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+var clonedProjectGitAttributes string = "*.wav filter=lfs diff=lfs merge=lfs -text\n*.als.xml merge=mppm\n"
+
+func TestProjectSetupCmd(t *testing.T) {
+
+	testCases := []*ProjectSetupCmdTestCase{
+
+		&ProjectSetupCmdTestCase{
+			description: "Test that the merge driver is registered, and that only missing lines are added to .gitattributes.",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.NewMockFileBuilder().
+								SetFilePath(".gitattributes").
+								SetContentsFromString(clonedProjectGitAttributes),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath(".gitattributes").
+						SetContentsFromString(clonedProjectGitAttributes+"*.adg.xml merge=mppm\n*.adv.xml merge=mppm\n*.alc.xml merge=mppm\n").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"lfs", "install"},
+							[]string{"config", "merge.mppm.name", "mppm structure-aware merge driver for Ableton XML files"},
+							[]string{"config", "merge.mppm.driver", "mppm merge-driver %O %A %B %P"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Configured git to merge extracted Ableton files with 'mppm merge-driver'.\n"),
+				),
+		},
+
+		&ProjectSetupCmdTestCase{
+			description: "Test that .gitattributes isn't changed if it already has all of the merge driver's lines.",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.NewMockFileBuilder().
+								SetFilePath(".gitattributes").
+								SetContentsFromString("*.adg.xml merge=mppm\n*.adv.xml merge=mppm\n*.alc.xml merge=mppm\n*.als.xml merge=mppm"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.NewMockFileBuilder().
+						SetFilePath(".gitattributes").
+						SetContentsFromString("*.adg.xml merge=mppm\n*.adv.xml merge=mppm\n*.alc.xml merge=mppm\n*.als.xml merge=mppm").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"lfs", "install"},
+							[]string{"config", "merge.mppm.name", "mppm structure-aware merge driver for Ableton XML files"},
+							[]string{"config", "merge.mppm.driver", "mppm merge-driver %O %A %B %P"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Configured git to merge extracted Ableton files with 'mppm merge-driver'.\n"),
+				),
+		},
+
+		&ProjectSetupCmdTestCase{
+			description: "Test that the merge driver isn't registered for projects that don't use Ableton.",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndLmmsApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"lfs", "install"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Configured git for the project. The merge driver wasn't registered, since the project doesn't use Ableton.\n"),
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+type ProjectSetupCmdTestCase struct {
+	description                              string
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *ProjectSetupCmdTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	cmd.RootCmd.SetArgs([]string{"project", "setup"})
+	cmd.RootCmd.Execute()
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
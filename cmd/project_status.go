@@ -0,0 +1,406 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	ProjectCmd.AddCommand(StatusCmd)
+}
+
+var StatusCmd = &cobra.Command{
+
+	Use: "status",
+
+	Short: "Shows which files and libraries are out of date with the project.",
+
+	Long: `Shows which files and libraries are out of date with the project, including:
+
+	- Binary files of supported types that have not been extracted yet.
+	- Extracted files whose contents no longer match their binary files.
+	- Untracked files that match the project's git lfs patterns.
+	- Libraries whose versions in the project config file are different from the global config file,
+	  or from the worktrees they are checked out into by 'mppm library checkout --project --worktree'.
+
+To update the extracted files, run 'mppm project extract'.
+To update the library versions, run 'mppm project --update-libraries'.
+To update the worktrees of libraries checked out with --worktree, run 'mppm library checkout --project --worktree'.`,
+
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printProjectStatus(); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func printProjectStatus() (err error) {
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	unextractedFileNames, staleFileNames, err := getUnextractedAndStaleFileNames(filePatternsConfig)
+	if err != nil {
+		return
+	}
+
+	untrackedLfsFileNames, err := getUntrackedFileNamesMatchingGitLfsPatterns(filePatternsConfig)
+	if err != nil {
+		return
+	}
+
+	outdatedLibraries, err := getLibrariesWithDifferentVersionsThanGlobalConfig()
+	if err != nil {
+		return
+	}
+
+	isUpToDate := len(unextractedFileNames) == 0 &&
+		len(staleFileNames) == 0 &&
+		len(untrackedLfsFileNames) == 0 &&
+		len(outdatedLibraries) == 0
+
+	if isUpToDate {
+		util.Println("Everything is up to date.")
+		return
+	}
+
+	printProjectStatusSection("Binary files that have not been extracted:", unextractedFileNames)
+	printProjectStatusSection("Extracted files that are out of date:", staleFileNames)
+
+	if len(untrackedLfsFileNames) > 0 {
+		lines := make([]string, 0)
+		for _, gitLfsTrackPattern := range filePatternsConfig.GitLfsTrackPatterns {
+			if fileNames, ok := untrackedLfsFileNames[gitLfsTrackPattern]; ok {
+				lines = append(lines, gitLfsTrackPattern)
+				for _, fileName := range fileNames {
+					lines = append(lines, "\t"+fileName)
+				}
+			}
+		}
+		printProjectStatusSection("Untracked files matching git lfs patterns:", lines)
+	}
+
+	if len(outdatedLibraries) > 0 {
+		lines := make([]string, 0)
+		for _, library := range outdatedLibraries {
+			checkedOutVersionName := "global-version"
+			if library.IsWorktree {
+				checkedOutVersionName = "worktree-version"
+			}
+			lines = append(
+				lines,
+				library.FilePath,
+				"\tproject-version=\""+library.ProjectGitCommitId+"\"",
+				"\t"+checkedOutVersionName+"=\""+library.GlobalGitCommitId+"\"",
+			)
+		}
+		printProjectStatusSection("Libraries with different versions than the global config:", lines)
+	}
+
+	return
+
+}
+
+func printProjectStatusSection(title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	util.Println(title)
+	for _, line := range lines {
+		util.Println("\t" + line)
+	}
+}
+
+// ------------------------------------------------------------------------------
+
+// Returns the names of all binary files of supported types that have no extracted files,
+// and the names of all extracted files whose contents are different from their binary files.
+func getUnextractedAndStaleFileNames(filePatternsConfig *applications.FilePatternsConfig) (unextractedFileNames []string, staleFileNames []string, err error) {
+
+	unextractedFileNames = make([]string, 0)
+	staleFileNames = make([]string, 0)
+
+	for _, fileExtension := range filePatternsConfig.CompressedXmlFileExtensions {
+
+		codec := filePatternsConfig.GetCompressedXmlFileCodec(fileExtension)
+
+		var fileNames []string
+		fileNames, err = util.GetAllFileNamesWithExtension(fileExtension)
+		if err != nil {
+			return
+		}
+
+		for _, fileName := range fileNames {
+
+			extractedFileName := fileName + ".xml"
+			if !util.DoesFileExist(extractedFileName) {
+				unextractedFileNames = append(unextractedFileNames, fileName)
+				continue
+			}
+
+			var isStale bool
+			isStale, err = isExtractedXmlFileStale(fileName, extractedFileName, codec, filePatternsConfig)
+			if err != nil {
+				return
+			}
+			if isStale {
+				staleFileNames = append(staleFileNames, extractedFileName)
+			}
+
+		}
+
+	}
+
+	for _, fileExtension := range filePatternsConfig.ZippedFileExtensions {
+
+		var fileNames []string
+		fileNames, err = util.GetAllFileNamesWithExtension(fileExtension)
+		if err != nil {
+			return
+		}
+
+		var directoryNames []string
+		directoryNames, err = util.GetAllDirectoryNamesWithExtension(getUnzippedDirectoryName(fileExtension))
+		if err != nil {
+			return
+		}
+
+		for _, fileName := range fileNames {
+
+			directoryName := getUnzippedDirectoryName(fileName)
+			if !containsString(directoryNames, directoryName) {
+				unextractedFileNames = append(unextractedFileNames, fileName)
+				continue
+			}
+
+			var isStale bool
+			isStale, err = isUnzippedDirectoryStale(fileName, directoryName)
+			if err != nil {
+				return
+			}
+			if isStale {
+				staleFileNames = append(staleFileNames, directoryName)
+			}
+
+		}
+
+	}
+
+	sort.Strings(unextractedFileNames)
+	sort.Strings(staleFileNames)
+
+	return
+
+}
+
+func isExtractedXmlFileStale(compressedFileName string, extractedFileName string, codec util.CompressionCodec, filePatternsConfig *applications.FilePatternsConfig) (isStale bool, err error) {
+
+	uncompressedContents, err := readExtractedXmlContents(compressedFileName, codec, filePatternsConfig)
+	if err != nil {
+		return
+	}
+
+	extractedContents, err := readExtractedXmlFile(extractedFileName)
+	if err != nil {
+		return
+	}
+
+	isStale = sha1.Sum(uncompressedContents) != sha1.Sum(extractedContents)
+	return
+
+}
+
+func isUnzippedDirectoryStale(zipFileName string, directoryName string) (isStale bool, err error) {
+
+	zippedFileContents, err := util.ReadZipFile(zipFileName)
+	if err != nil {
+		return
+	}
+
+	unzippedFileNames, err := util.GetAllFileNamesInDirectory(directoryName)
+	if err != nil {
+		return
+	}
+
+	if len(unzippedFileNames) != len(zippedFileContents) {
+		isStale = true
+		return
+	}
+
+	for zippedFileName, zippedFileContent := range zippedFileContents {
+
+		unzippedFileName := util.JoinFilePath(directoryName, zippedFileName)
+		if !util.DoesFileExist(unzippedFileName) {
+			isStale = true
+			return
+		}
+
+		var unzippedFileContent []byte
+		unzippedFileContent, err = util.ReadFile(unzippedFileName)
+		if err != nil {
+			return
+		}
+
+		if sha1.Sum(zippedFileContent) != sha1.Sum(unzippedFileContent) {
+			isStale = true
+			return
+		}
+
+	}
+
+	return
+
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------------------------------------------------------------------
+
+// Returns the names of all untracked files that match any of the git lfs patterns,
+// indexed by the patterns that they match. The names are separated by NUL characters,
+// so that git doesn't quote names with non-ASCII characters.
+func getUntrackedFileNamesMatchingGitLfsPatterns(filePatternsConfig *applications.FilePatternsConfig) (untrackedFileNames map[string][]string, err error) {
+
+	gitRepoFilePath := "."
+	gitManager := util.NewGitManager(gitRepoFilePath)
+
+	stdout, err := gitManager.LsFiles("--others", "--exclude-standard", "-z")
+	if err != nil {
+		return
+	}
+
+	untrackedFileNames = make(map[string][]string)
+
+	for _, fileName := range strings.Split(stdout, "\x00") {
+		if fileName == "" {
+			continue
+		}
+		for _, gitLfsTrackPattern := range filePatternsConfig.GitLfsTrackPatterns {
+			if doesFileNameMatchGitPattern(fileName, gitLfsTrackPattern) {
+				untrackedFileNames[gitLfsTrackPattern] = append(untrackedFileNames[gitLfsTrackPattern], fileName)
+			}
+		}
+	}
+
+	for _, fileNames := range untrackedFileNames {
+		sort.Strings(fileNames)
+	}
+
+	return
+
+}
+
+// Returns true if the given slash-separated file name, relative to the root of the git repository,
+// matches the given .gitattributes-style pattern.
+//
+// Patterns without a slash match the file's base name in any directory.
+// Otherwise, '**' matches any number of directories, '*' matches anything except a slash,
+// and '?' matches any single character except a slash.
+func doesFileNameMatchGitPattern(fileName string, pattern string) bool {
+
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var expression strings.Builder
+	if strings.Contains(pattern, "/") {
+		expression.WriteString("^")
+	} else {
+		expression.WriteString("^(.*/)?")
+	}
+
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			expression.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			expression.WriteString(".*")
+			i += 1
+		case pattern[i] == '*':
+			expression.WriteString("[^/]*")
+		case pattern[i] == '?':
+			expression.WriteString("[^/]")
+		default:
+			expression.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+
+	expression.WriteString("$")
+
+	isMatch, err := regexp.MatchString(expression.String(), fileName)
+	return err == nil && isMatch
+
+}
+
+// ------------------------------------------------------------------------------
+
+type libraryVersions struct {
+	FilePath           string
+	ProjectGitCommitId string
+	GlobalGitCommitId  string
+	IsWorktree         bool // Whether GlobalGitCommitId is the version of the project's worktree of the library.
+}
+
+// Returns all libraries in the project config file whose versions are different
+// from the current versions of the same libraries in the global config file.
+func getLibrariesWithDifferentVersionsThanGlobalConfig() (outdatedLibraries []*libraryVersions, err error) {
+
+	projectConfig, globalConfig, err := configManager.GetProjectAndGlobalConfigs()
+	if err != nil {
+		return
+	}
+
+	worktrees, err := config.LoadProjectLibraryWorktrees()
+	if err != nil {
+		return
+	}
+
+	outdatedLibraries = make([]*libraryVersions, 0)
+	for _, projectLibrary := range projectConfig.Libraries {
+		globalLibrary := getLibraryWithFilePath(projectLibrary.FilePath, globalConfig.Libraries)
+		if globalLibrary == nil {
+			continue
+		}
+
+		// Projects with the library checked out into a worktree don't depend on the version in the library's folder.
+		checkedOutGitCommitId := globalLibrary.CurrentGitCommitId
+		worktreeFilePath := worktrees.Get(projectLibrary.FilePath)
+		if worktreeFilePath != "" {
+			checkedOutGitCommitId = filepath.Base(worktreeFilePath)
+		}
+
+		// The project's version can also be the tag of a snapshot.
+		if checkedOutGitCommitId != globalLibrary.GetGitCommitId(projectLibrary.CurrentGitCommitId) {
+			outdatedLibraries = append(
+				outdatedLibraries,
+				&libraryVersions{
+					FilePath:           projectLibrary.FilePath,
+					ProjectGitCommitId: projectLibrary.CurrentGitCommitId,
+					GlobalGitCommitId:  checkedOutGitCommitId,
+					IsWorktree:         worktreeFilePath != "",
+				},
+			)
+		}
+	}
+
+	return
+
+}
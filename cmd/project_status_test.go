@@ -0,0 +1,217 @@
+package cmd_test
+
+import (
+	"testing"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+func TestProjectStatusCmd(t *testing.T) {
+
+	testCases := []*ProjectStatusCmdTestCase{
+
+		&ProjectStatusCmdTestCase{
+			description: "Test that a message is displayed if all files and libraries are up to date.",
+			args:        []string{"project", "status"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							utiltest.GetFakeAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetLsFilesStdout("notes.txt\x00"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"ls-files", "--others", "--exclude-standard", "-z"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Everything is up to date.\n"),
+				),
+		},
+
+		&ProjectStatusCmdTestCase{
+			description: "Test that unextracted files, out of date extracted files, untracked git lfs files, including ones with non-ASCII names, and out of date libraries are displayed.",
+			args:        []string{"project", "status"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							utiltest.GetFakeAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeAbletonLiveClipFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder().
+								SetContentsFromString("<Ableton />"),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetLsFilesStdout("Samples/kick.wav\x00Samples/Ñew.wav\x00notes.txt\x00"),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndPreviousLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveSetFileBuilder(),
+					utiltest.GetFakeAbletonLiveClipFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder().
+						SetContentsFromString("<Ableton />").
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"ls-files", "--others", "--exclude-standard", "-z"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"Binary files that have not been extracted:\n" +
+							"\tfake-ableton-live-set.als\n" +
+							"Extracted files that are out of date:\n" +
+							"\tfake-ableton-live-clip.alc.xml\n" +
+							"Untracked files matching git lfs patterns:\n" +
+							"\t*.wav\n" +
+							"\t\tSamples/kick.wav\n" +
+							"\t\tSamples/Ñew.wav\n" +
+							"Libraries with different versions than the global config:\n" +
+							"\t/home/testuser/library\n" +
+							"\t\tproject-version=\"01234\"\n" +
+							"\t\tglobal-version=\"56789\"\n",
+					),
+				),
+		},
+
+		&ProjectStatusCmdTestCase{
+			description: "Test that unzipped directories are displayed if their files are different from the zip archive.",
+			args:        []string{"project", "status"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+								SetFilePath("/home/testuser/.mppm.json"),
+							utiltest.GetFakeStudioOneSongFileBuilder(),
+							utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					configtest.ConfigWithValidVersionAndStudioOneApplication.AsMockFileBuilder().
+						SetFilePath("/home/testuser/.mppm.json").
+						SetWasClosed(true),
+					utiltest.GetFakeStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"ls-files", "--others", "--exclude-standard", "-z"},
+						},
+					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("Extracted files that are out of date:\n\tfake-studio-one-song.song.unzipped\n"),
+				),
+		},
+
+		&ProjectStatusCmdTestCase{
+			description: "Test that any error from 'git ls-files' is properly raised.",
+			args:        []string{"project", "status"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						),
+				).
+				SetMockGitManagerCreatorBuilder(
+					utiltest.NewMockGitManagerCreatorBuilder().
+						SetUseDefaultLsFilesError(true),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterError(utiltest.DefaultLsFilesError).
+				SetExiterWasExited(true).
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndApplicationNameAndApplicationVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+				).
+				SetGitManagerInputHistoriesIndexedByRepoPath(
+					map[string][][]string{
+						".": [][]string{
+							[]string{"ls-files", "--others", "--exclude-standard", "-z"},
+						},
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+type ProjectStatusCmdTestCase struct {
+	description                              string
+	args                                     []string
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *ProjectStatusCmdTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	cmd.RootCmd.SetArgs(testCase.args)
+	cmd.RootCmd.Execute()
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
@@ -12,7 +12,7 @@ import (
 	"github.com/stevengt/mppm/util/utiltest"
 )
 
-var projectCmdHelpMessage string = "Provides utilities for managing a specific project.\n\nUsage:\n  mppm project [flags]\n  mppm project [command]\n\nAvailable Commands:\n  extract     Extracts all binary files of supported types into plain-text files, such as XML.\n  init        Initializes version control settings for a project using git and git-lfs.\n  restore     Restores all plain-text files of supported types to their original binary files.\n\nFlags:\n  -c, --commit-all         Equivalent to running 'mppm project extract; git add . -A; git commit -m '<commit message>'.\n  -h, --help               help for project\n  -p, --preview            Shows what files will be affected without actually making changes.\n  -u, --update-libraries   Updates the library versions in the project config file to match the\n                           current versions in the global config file.\n                           To see the global current versions, run 'mppm library --list'.\n\nUse \"mppm project [command] --help\" for more information about a command.\n"
+var projectCmdHelpMessage string = "Provides utilities for managing a specific project.\n\nUsage:\n  mppm project [flags]\n  mppm project [command]\n\nAvailable Commands:\n  collect     Copies samples from outside of the project into it, and commits them.\n  deps        Lists the external files that the project's Ableton Live Sets refer to.\n  diff        Shows the musical changes made to extracted Ableton Live Sets between two revisions.\n  export      Creates a self-contained bundle of the project, including the library files it uses.\n  extract     Extracts all binary files of supported types into plain-text files, such as XML.\n  import      Unpacks a bundle created by 'mppm project export' into the current folder.\n  init        Initializes version control settings for a project using git and git-lfs.\n  relink      Finds missing files that the project's Ableton Live Sets refer to, and updates their locations.\n  restore     Restores all plain-text files of supported types to their original binary files.\n  setup       Configures git for a project that was cloned, such as to merge extracted Ableton files with mppm.\n  status      Shows which files and libraries are out of date with the project.\n  verify      Checks that restoring the project would not lose any changes in its binary files.\n\nFlags:\n  -c, --commit-all         Equivalent to running 'mppm project extract; git add . -A; git commit -m '<commit message>'.\n  -f, --force              Extracts or restores all files, including files that haven't changed since they were\n                           last extracted or restored. For 'mppm project relink', relinks the matching files\n                           instead of only listing them.\n  -h, --help               help for project\n  -j, --jobs int           The number of files to extract or restore at the same time.\n                           Defaults to the number of CPUs.\n  -p, --preview            Shows what files will be affected without actually making changes.\n  -u, --update-libraries   Updates the library versions in the project config file to match the\n                           current versions in the global config file.\n                           To see the global current versions, run 'mppm library --list'.\n\nUse \"mppm project [command] --help\" for more information about a command.\n"
 
 func TestProjectCmd(t *testing.T) {
 
@@ -101,6 +101,15 @@ func TestProjectCmd(t *testing.T) {
 						SetWasClosed(true),
 					utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder().
 						SetWasClosed(true),
+					configtest.GetExtractionManifestFileBuilder(
+						utiltest.GetFakeAbletonLiveClipFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder(),
+						utiltest.GetFakeAbletonLiveSetFileBuilder(),
+						utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+					).
+						SetWasClosed(true),
+					configtest.GetMppmDirectoryGitIgnoreFileBuilder().
+						SetWasClosed(true),
 				).
 				SetGitManagerInputHistoriesIndexedByRepoPath(
 					map[string][][]string{
@@ -109,6 +118,9 @@ func TestProjectCmd(t *testing.T) {
 							[]string{"commit", "-m", "Made changes"},
 						},
 					},
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[1/2] Extracted fake-ableton-live-clip.alc\n[2/2] Extracted fake-ableton-live-set.als\n"),
 				),
 		},
 
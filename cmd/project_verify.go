@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+func init() {
+	ProjectCmd.AddCommand(VerifyCmd)
+}
+
+var VerifyCmd = &cobra.Command{
+
+	Use: "verify",
+
+	Short: "Checks that restoring the project would not lose any changes in its binary files.",
+
+	Long: `Checks that restoring the project would not lose any changes in its binary files.
+
+For every compressed XML file of a supported type, such as an Ableton Live Set,
+the binary file is decompressed in memory and compared with its extracted .xml file.
+If XML canonicalization is enabled for the file, then the canonical form is compared instead.
+
+Zipped binary files, such as Studio One songs, and plain-text project files with split
+plugin states, such as REAPER projects, aren't verified.
+
+Nothing is written to disk. If any files don't match, then they are listed and
+mppm exits with an error, so that this can be run before 'mppm project restore'.`,
+
+	Args: cobra.NoArgs,
+
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := verifyAllCompressedXmlFiles(); err != nil {
+			util.ExitWithError(err)
+		}
+	},
+}
+
+func verifyAllCompressedXmlFiles() (err error) {
+
+	filePatternsConfig, err := config.GetAllFilePatternsConfigFromProjectConfig()
+	if err != nil {
+		return
+	}
+
+	tasks, err := getVerifyCompressedXmlFileTasks(filePatternsConfig)
+	if err != nil {
+		return
+	}
+
+	err = util.RunTasks(numberOfJobs, tasks)
+	if err != nil {
+		return
+	}
+
+	util.Println("All files can be restored without losing any changes.")
+	return
+
+}
+
+func getVerifyCompressedXmlFileTasks(filePatternsConfig *applications.FilePatternsConfig) (tasks []*util.Task, err error) {
+
+	tasks = make([]*util.Task, 0)
+
+	for _, fileExtension := range filePatternsConfig.CompressedXmlFileExtensions {
+		var extensionTasks []*util.Task
+		codec := filePatternsConfig.GetCompressedXmlFileCodec(fileExtension)
+		extensionTasks, err = getVerifyCompressedXmlFileTasksForExtension(fileExtension, codec, filePatternsConfig)
+		if err != nil {
+			return
+		}
+		tasks = append(tasks, extensionTasks...)
+	}
+
+	return
+
+}
+
+// Returns a task for every binary file with the given extension, and for every
+// extracted file whose binary file doesn't exist yet.
+func getVerifyCompressedXmlFileTasksForExtension(fileExtension string, codec util.CompressionCodec, filePatternsConfig *applications.FilePatternsConfig) (tasks []*util.Task, err error) {
+
+	binaryFileNames, err := util.GetAllFileNamesWithExtension(fileExtension)
+	if err != nil {
+		return
+	}
+
+	extractedFileNames, err := util.GetAllFileNamesWithExtension(fileExtension + ".xml")
+	if err != nil {
+		return
+	}
+
+	for _, extractedFileName := range extractedFileNames {
+		binaryFileName := strings.TrimSuffix(extractedFileName, ".xml")
+		if !containsString(binaryFileNames, binaryFileName) {
+			binaryFileNames = append(binaryFileNames, binaryFileName)
+		}
+	}
+	sort.Strings(binaryFileNames)
+
+	tasks = make([]*util.Task, 0, len(binaryFileNames))
+
+	for _, binaryFileName := range binaryFileNames {
+		binaryFileName := binaryFileName
+		tasks = append(
+			tasks,
+			&util.Task{
+				Name:            binaryFileName,
+				ProgressMessage: "Verified " + binaryFileName,
+				Run: func(printer util.WritePrinter) error {
+					return verifyCompressedXmlFile(printer, binaryFileName, binaryFileName+".xml", codec, filePatternsConfig)
+				},
+			},
+		)
+	}
+
+	return
+
+}
+
+// Returns an error if restoring the given extracted file would change the contents of the given binary file.
+func verifyCompressedXmlFile(printer util.WritePrinter, binaryFileName string, extractedFileName string, codec util.CompressionCodec, filePatternsConfig *applications.FilePatternsConfig) (err error) {
+
+	doesBinaryFileExist := util.DoesFileExist(binaryFileName)
+	doesExtractedFileExist := util.DoesFileExist(extractedFileName)
+
+	if !doesExtractedFileExist {
+		printer.Println(binaryFileName + " has not been extracted, so it will not be changed by 'mppm project restore'.")
+		return
+	}
+
+	extractedContents, err := readExtractedXmlFile(extractedFileName)
+	if err != nil {
+		return
+	}
+
+	if doesBinaryFileExist {
+
+		var binaryContents []byte
+		binaryContents, err = readExtractedXmlContents(binaryFileName, codec, filePatternsConfig)
+		if err != nil {
+			return
+		}
+
+		if !bytes.Equal(binaryContents, extractedContents) {
+			err = errors.New(
+				"The contents of " + extractedFileName + " are different from " + binaryFileName + ", so restoring it would overwrite " + binaryFileName + ".\n" +
+					"To update " + extractedFileName + " from " + binaryFileName + ", run 'mppm project extract'.",
+			)
+			return
+		}
+
+	}
+
+	return
+
+}
@@ -0,0 +1,211 @@
+package cmd_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/configtest"
+
+	"github.com/stevengt/mppm/cmd"
+	"github.com/stevengt/mppm/util"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+func TestProjectVerifyCmd(t *testing.T) {
+
+	testCases := []*ProjectVerifyCmdTestCase{
+
+		&ProjectVerifyCmdTestCase{
+			description: "Test that all files are verified if their extracted files match their binary files.",
+			args:        []string{"project", "verify"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeAbletonLiveClipFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveClipFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder().
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"[1/2] Verified fake-ableton-live-clip.alc\n" +
+							"[2/2] Verified fake-ableton-live-set.als\n" +
+							"All files can be restored without losing any changes.\n",
+					),
+				),
+		},
+
+		&ProjectVerifyCmdTestCase{
+			description: "Test that volatile XML attributes are ignored if XML canonicalization is enabled in the project config.",
+			args:        []string{"project", "verify"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithValidVersionAndCanonicalizedAbletonApplication.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLive11SetFileBuilder().
+								SetContentsFromBytes(utiltest.GetGzippedContents(volatileAbletonLive11SetContents)),
+							utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder().
+								SetContentsFromString(canonicalizedAbletonLive11SetContents),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithValidVersionAndCanonicalizedAbletonApplication.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLive11SetFileBuilder().
+						SetContentsFromBytes(utiltest.GetGzippedContents(volatileAbletonLive11SetContents)).
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLive11SetFileBuilder().
+						SetContentsFromString(canonicalizedAbletonLive11SetContents).
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"[1/1] Verified fake-ableton-live-11-set.als\n" +
+							"All files can be restored without losing any changes.\n",
+					),
+				),
+		},
+
+		&ProjectVerifyCmdTestCase{
+			description: "Test that an error is raised for every extracted file that is different from its binary file, and that extracted files without binary files are still verified.",
+			args:        []string{"project", "verify"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder(),
+							utiltest.GetFakeAbletonLiveClipFileBuilder(),
+							utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder().
+								SetContentsFromString("<Ableton />"),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterError(
+					util.TaskErrors{
+						&util.TaskError{
+							TaskName: "fake-ableton-live-clip.alc",
+							Err: errors.New(
+								"The contents of fake-ableton-live-clip.alc.xml are different from fake-ableton-live-clip.alc, so restoring it would overwrite fake-ableton-live-clip.alc.\n" +
+									"To update fake-ableton-live-clip.alc.xml from fake-ableton-live-clip.alc, run 'mppm project extract'.",
+							),
+						},
+					},
+				).
+				SetExiterWasExited(true).
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveSetFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveClipFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUncompressedAbletonLiveClipFileBuilder().
+						SetContentsFromString("<Ableton />").
+						SetWasClosed(true),
+				).
+				SetWritePrinterOutputContents(
+					[]byte("[2/2] Verified fake-ableton-live-set.als\n"),
+				),
+		},
+
+		&ProjectVerifyCmdTestCase{
+			description: "Test that binary files without extracted files are not read, since restoring won't change them.",
+			args:        []string{"project", "verify"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+							utiltest.GetFakeAbletonLiveSetFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+					utiltest.GetFakeAbletonLiveSetFileBuilder(),
+				).
+				SetWritePrinterOutputContents(
+					[]byte(
+						"fake-ableton-live-set.als has not been extracted, so it will not be changed by 'mppm project restore'.\n" +
+							"[1/1] Verified fake-ableton-live-set.als\n" +
+							"All files can be restored without losing any changes.\n",
+					),
+				),
+		},
+
+		&ProjectVerifyCmdTestCase{
+			description: "Test that any error from filepath.Walk() is properly raised.",
+			args:        []string{"project", "verify"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+								SetFilePath(config.MppmConfigFileName),
+						).
+						SetUseDefaultWalkFilePathError(true),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetExiterError(utiltest.DefaultWalkFilePathError).
+				SetExiterWasExited(true).
+				SetMockFileBuilders(
+					configtest.ConfigWithAllValidInfoAndMostRecentLibraryVersion.AsMockFileBuilder().
+						SetFilePath(config.MppmConfigFileName).
+						SetWasClosed(true),
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+type ProjectVerifyCmdTestCase struct {
+	description                              string
+	args                                     []string
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *ProjectVerifyCmdTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	cmd.RootCmd.SetArgs(testCase.args)
+	cmd.RootCmd.Execute()
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
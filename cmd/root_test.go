@@ -7,7 +7,7 @@ import (
 	"github.com/stevengt/mppm/util/utiltest"
 )
 
-var rootCmdHelpMessage string = "Short for 'Music Production Project Manager', mppm provides utilities for managing music production projects, such as:\n\n\t- Simplified version control using 'git' and 'git-lfs'.\n\t- Extraction of 'Ableton Live Set' files to/from raw XML files.\n\nUsage:\n  mppm [flags]\n  mppm [command]\n\nAvailable Commands:\n  help        Help about any command\n  library     Provides utilities for globally managing multiple libraries (folders).\n  project     Provides utilities for managing a specific project.\n\nFlags:\n  -h, --help             help for mppm\n  -s, --show-supported   Shows what file types are supported by mppm.\n  -v, --version          version for mppm\n\nUse \"mppm [command] --help\" for more information about a command.\n"
+var rootCmdHelpMessage string = "Short for 'Music Production Project Manager', mppm provides utilities for managing music production projects, such as:\n\n\t- Simplified version control using 'git' and 'git-lfs'.\n\t- Extraction of 'Ableton Live Set' files to/from raw XML files.\n\nUsage:\n  mppm [flags]\n  mppm [command]\n\nAvailable Commands:\n  help         Help about any command\n  library      Provides utilities for globally managing multiple libraries (folders).\n  merge-driver Merges changes to extracted Ableton files by track, device, and clip. Used by git during 'git merge'.\n  project      Provides utilities for managing a specific project.\n\nFlags:\n  -h, --help             help for mppm\n  -s, --show-supported   Shows what file types are supported by mppm.\n  -v, --version          version for mppm\n\nUse \"mppm [command] --help\" for more information about a command.\n"
 
 func TestRootCmd(t *testing.T) {
 
@@ -36,7 +36,7 @@ func TestRootCmd(t *testing.T) {
 			args:        []string{"--show-supported"},
 			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
 				SetWritePrinterOutputContents(
-					[]byte("Audio\n\n\tGit Ignore Patterns\n\t\t\n\tGit LFS Track Patterns\n\t\t*.3gp\n\t\t*.aa\n\t\t*.aac\n\t\t*.aax\n\t\t*.act\n\t\t*.aiff\n\t\t*.alac\n\t\t*.amr\n\t\t*.ape\n\t\t*.au\n\t\t*.awb\n\t\t*.dct\n\t\t*.dss\n\t\t*.dvf\n\t\t*.flac\n\t\t*.gsm\n\t\t*.iklax\n\t\t*.ivs\n\t\t*.m4a\n\t\t*.m4b\n\t\t*.m4p\n\t\t*.mmf\n\t\t*.mp3\n\t\t*.mpc\n\t\t*.msv\n\t\t*.nmf\n\t\t*.nsf\n\t\t*.ogg\n\t\t*.oga\n\t\t*.mogg\n\t\t*.opus\n\t\t*.ra\n\t\t*.rm\n\t\t*.raw\n\t\t*.rf64\n\t\t*.sln\n\t\t*.tta\n\t\t*.voc\n\t\t*.vox\n\t\t*.wav\n\t\t*.wma\n\t\t*.wv\n\t\t*.webm\n\t\t*.8svx\n\t\t*.cda\n\tGzipped XML File Types\n\t\t\nAbleton 10\n\n\tGit Ignore Patterns\n\t\tBackup/\n\t\t*.als\n\t\t*.alc\n\t\t*.adv\n\t\t*.adg\n\tGit LFS Track Patterns\n\t\t*.alp\n\t\t*.asd\n\t\t*.agr\n\t\t*.ams\n\t\t*.amxd\n\tGzipped XML File Types\n\t\tals\n\t\talc\n\t\tadv\n\t\tadg\n"),
+					[]byte("Audio\n\n\tGit Ignore Patterns\n\t\t\n\tGit LFS Track Patterns\n\t\t*.3gp\n\t\t*.aa\n\t\t*.aac\n\t\t*.aax\n\t\t*.act\n\t\t*.aiff\n\t\t*.alac\n\t\t*.amr\n\t\t*.ape\n\t\t*.au\n\t\t*.awb\n\t\t*.dct\n\t\t*.dss\n\t\t*.dvf\n\t\t*.flac\n\t\t*.gsm\n\t\t*.iklax\n\t\t*.ivs\n\t\t*.m4a\n\t\t*.m4b\n\t\t*.m4p\n\t\t*.mmf\n\t\t*.mp3\n\t\t*.mpc\n\t\t*.msv\n\t\t*.nmf\n\t\t*.nsf\n\t\t*.ogg\n\t\t*.oga\n\t\t*.mogg\n\t\t*.opus\n\t\t*.ra\n\t\t*.rm\n\t\t*.raw\n\t\t*.rf64\n\t\t*.sln\n\t\t*.tta\n\t\t*.voc\n\t\t*.vox\n\t\t*.wav\n\t\t*.wma\n\t\t*.wv\n\t\t*.webm\n\t\t*.8svx\n\t\t*.cda\n\tCompressed XML File Types\n\t\t\n\tZipped File Types\n\t\t\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\t\n\tVolatile XML Attributes\n\t\t\n\tSplit XML File Types\n\t\t\nAbleton 10\n\n\tGit Ignore Patterns\n\t\tBackup/\n\t\t*.als\n\t\t*.alc\n\t\t*.adv\n\t\t*.adg\n\tGit LFS Track Patterns\n\t\t*.alp\n\t\t*.asd\n\t\t*.agr\n\t\t*.ams\n\t\t*.amxd\n\tCompressed XML File Types\n\t\tals (gzip)\n\t\talc (gzip)\n\t\tadv (gzip)\n\t\tadg (gzip)\n\tZipped File Types\n\t\t\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\tals\n\t\talc\n\t\tadv\n\t\tadg\n\tVolatile XML Attributes\n\t\tAbleton/@Revision\n\t\tClientSize/@X\n\t\tClientSize/@Y\n\t\tLomId/@Value\n\t\tLomIdView/@Value\n\t\tScrollerPos/@X\n\t\tScrollerPos/@Y\n\t\tSessionScrollerPos/@X\n\t\tSessionScrollerPos/@Y\n\tSplit XML File Types\n\t\tals\nAbleton 11\n\n\tGit Ignore Patterns\n\t\tBackup/\n\t\t*.als\n\t\t*.alc\n\t\t*.adv\n\t\t*.adg\n\tGit LFS Track Patterns\n\t\t*.alp\n\t\t*.asd\n\t\t*.agr\n\t\t*.ams\n\t\t*.amxd\n\t\t*.ablbundle\n\t\t*.ascl\n\tCompressed XML File Types\n\t\tals (gzip)\n\t\talc (gzip)\n\t\tadv (gzip)\n\t\tadg (gzip)\n\tZipped File Types\n\t\t\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\tals\n\t\talc\n\t\tadv\n\t\tadg\n\tVolatile XML Attributes\n\t\tAbleton/@Revision\n\t\tClientSize/@X\n\t\tClientSize/@Y\n\t\tLomId/@Value\n\t\tLomIdView/@Value\n\t\tScrollerPos/@X\n\t\tScrollerPos/@Y\n\t\tSessionScrollerPos/@X\n\t\tSessionScrollerPos/@Y\n\tSplit XML File Types\n\t\tals\nAbleton 12\n\n\tGit Ignore Patterns\n\t\tBackup/\n\t\t*.als\n\t\t*.alc\n\t\t*.adv\n\t\t*.adg\n\tGit LFS Track Patterns\n\t\t*.alp\n\t\t*.asd\n\t\t*.agr\n\t\t*.ams\n\t\t*.amxd\n\t\t*.ablbundle\n\t\t*.ascl\n\tCompressed XML File Types\n\t\tals (gzip)\n\t\talc (gzip)\n\t\tadv (gzip)\n\t\tadg (gzip)\n\tZipped File Types\n\t\t\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\tals\n\t\talc\n\t\tadv\n\t\tadg\n\tVolatile XML Attributes\n\t\tAbleton/@Revision\n\t\tClientSize/@X\n\t\tClientSize/@Y\n\t\tLomId/@Value\n\t\tLomIdView/@Value\n\t\tScrollerPos/@X\n\t\tScrollerPos/@Y\n\t\tSessionScrollerPos/@X\n\t\tSessionScrollerPos/@Y\n\tSplit XML File Types\n\t\tals\nArdour 8\n\n\tGit Ignore Patterns\n\t\t/peaks/\n\t\t/analysis/\n\t\t/dead/\n\t\t/*.pending\n\t\t/*.bak\n\t\t/*.history\n\tGit LFS Track Patterns\n\t\tinterchange/*/audiofiles/**\n\tCompressed XML File Types\n\t\t\n\tZipped File Types\n\t\t\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\t\n\tVolatile XML Attributes\n\t\t\n\tSplit XML File Types\n\t\t\nBitwig 5\n\n\tGit Ignore Patterns\n\t\tauto-backups/\n\t\t*.bwpreset\n\tGit LFS Track Patterns\n\t\t*.bwproject\n\t\t*.bwclip\n\t\t*.bwscene\n\tCompressed XML File Types\n\t\t\n\tZipped File Types\n\t\tbwpreset\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\t\n\tVolatile XML Attributes\n\t\t\n\tSplit XML File Types\n\t\t\nLMMS 1\n\n\tGit Ignore Patterns\n\t\t*.mmpz\n\tGit LFS Track Patterns\n\t\t\n\tCompressed XML File Types\n\t\tmmpz (qCompress)\n\tZipped File Types\n\t\t\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\t\n\tVolatile XML Attributes\n\t\t\n\tSplit XML File Types\n\t\t\nMuseScore 4\n\n\tGit Ignore Patterns\n\t\t*.mscz\n\tGit LFS Track Patterns\n\t\t\n\tCompressed XML File Types\n\t\t\n\tZipped File Types\n\t\tmscz\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\t\n\tVolatile XML Attributes\n\t\t\n\tSplit XML File Types\n\t\t\nREAPER 7\n\n\tGit Ignore Patterns\n\t\t*.RPP-bak\n\t\t*.rpp-bak\n\t\t*.reapeaks\n\t\t*-undo.rpl\n\tGit LFS Track Patterns\n\t\t*.RfxChain\n\t\t*.ReaperThemeZip\n\tCompressed XML File Types\n\t\t\n\tZipped File Types\n\t\t\n\tPlugin State Chunk File Types\n\t\tRPP\n\tCanonicalized XML File Types\n\t\t\n\tVolatile XML Attributes\n\t\t\n\tSplit XML File Types\n\t\t\nRenoise 3\n\n\tGit Ignore Patterns\n\t\t*.xrns\n\tGit LFS Track Patterns\n\t\t\n\tCompressed XML File Types\n\t\t\n\tZipped File Types\n\t\txrns\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\t\n\tVolatile XML Attributes\n\t\t\n\tSplit XML File Types\n\t\t\nStudio One 6\n\n\tGit Ignore Patterns\n\t\tHistory/\n\t\t*.song\n\tGit LFS Track Patterns\n\t\t\n\tCompressed XML File Types\n\t\t\n\tZipped File Types\n\t\tsong\n\tPlugin State Chunk File Types\n\t\t\n\tCanonicalized XML File Types\n\t\t\n\tVolatile XML Attributes\n\t\t\n\tSplit XML File Types\n\t\t\n"),
 				),
 		},
 	}
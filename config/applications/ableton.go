@@ -6,39 +6,97 @@ var AbletonInfo = &ApplicationInfo{
 
 	SupportedVersions: []ApplicationVersion{
 		"10",
+		"11",
+		"12",
 	},
 
 	DefaultVersion: "10",
 
 	FilePatternConfigs: map[ApplicationVersion]*FilePatternsConfig{
 		"10": Ableton10FilePatternsConfig,
+		"11": Ableton11FilePatternsConfig,
+		"12": Ableton12FilePatternsConfig,
+	},
+
+	ProjectFileExtensions: []string{
+		"als",
 	},
 }
 
-var Ableton10FilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
+var Ableton10FilePatternsConfig *FilePatternsConfig = newAbletonFilePatternsConfig("Ableton 10")
 
-	Name: "Ableton 10",
+var Ableton11FilePatternsConfig *FilePatternsConfig = newAbletonFilePatternsConfig("Ableton 11", abletonLive11GitLfsTrackPatterns...)
 
-	GitIgnorePatterns: []string{
-		"Backup/",
-		"*.als",
-		"*.alc",
-		"*.adv",
-		"*.adg",
-	},
+var Ableton12FilePatternsConfig *FilePatternsConfig = newAbletonFilePatternsConfig("Ableton 12", abletonLive11GitLfsTrackPatterns...)
 
-	GitLfsTrackPatterns: []string{
-		"*.alp",
-		"*.asd",
-		"*.agr",
-		"*.ams",
-		"*.amxd",
-	},
+// File types that Live 10 doesn't save. Set bundles (*.ablbundle) are used to move sets to and from
+// Push 3 since Live 11.3, and tuning systems (*.ascl) are shared between sets like other presets.
+var abletonLive11GitLfsTrackPatterns = []string{
+	"*.ablbundle",
+	"*.ascl",
+}
 
-	GzippedXmlFileExtensions: []string{
-		"als",
-		"alc",
-		"adv",
-		"adg",
-	},
+// Returns the file patterns shared by all versions of Ableton Live, with any file types that
+// were introduced in a later version tracked with git lfs.
+func newAbletonFilePatternsConfig(name string, additionalGitLfsTrackPatterns ...string) *FilePatternsConfig {
+	return &FilePatternsConfig{
+
+		Name: name,
+
+		GitIgnorePatterns: []string{
+			"Backup/",
+			"*.als",
+			"*.alc",
+			"*.adv",
+			"*.adg",
+		},
+
+		GitLfsTrackPatterns: append(
+			[]string{
+				"*.alp",
+				"*.asd",
+				"*.agr",
+				"*.ams",
+				"*.amxd",
+			},
+			additionalGitLfsTrackPatterns...,
+		),
+
+		CompressedXmlFileExtensions: []string{
+			"als",
+			"alc",
+			"adv",
+			"adg",
+		},
+
+		CanonicalizedXmlFileExtensions: []string{
+			"als",
+			"alc",
+			"adv",
+			"adg",
+		},
+
+		VolatileXmlAttributes: abletonVolatileXmlAttributes,
+
+		SplitXmlFileExtensions: []string{
+			"als",
+		},
+	}
+}
+
+// Attributes that Live changes on every save. The revision identifies the build of Live that saved
+// the file, and LomIds are assigned to objects by the Live Object Model while a set is open, so both
+// can be reset. The scroll positions and sizes of the Arrangement and Session views only record how
+// the window looked when the set was saved, and Live recalculates them when the set is opened.
+// Pointee IDs are left unchanged, since other elements in the file refer to them.
+var abletonVolatileXmlAttributes = []*VolatileXmlAttribute{
+	&VolatileXmlAttribute{ElementName: "Ableton", AttributeName: "Revision", CanonicalValue: ""},
+	&VolatileXmlAttribute{ElementName: "ClientSize", AttributeName: "X", CanonicalValue: "0"},
+	&VolatileXmlAttribute{ElementName: "ClientSize", AttributeName: "Y", CanonicalValue: "0"},
+	&VolatileXmlAttribute{ElementName: "LomId", AttributeName: "Value", CanonicalValue: "0"},
+	&VolatileXmlAttribute{ElementName: "LomIdView", AttributeName: "Value", CanonicalValue: "0"},
+	&VolatileXmlAttribute{ElementName: "ScrollerPos", AttributeName: "X", CanonicalValue: "0"},
+	&VolatileXmlAttribute{ElementName: "ScrollerPos", AttributeName: "Y", CanonicalValue: "0"},
+	&VolatileXmlAttribute{ElementName: "SessionScrollerPos", AttributeName: "X", CanonicalValue: "0"},
+	&VolatileXmlAttribute{ElementName: "SessionScrollerPos", AttributeName: "Y", CanonicalValue: "0"},
 }
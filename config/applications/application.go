@@ -1,29 +1,108 @@
 package applications
 
+import (
+	"sort"
+	"strings"
+)
+
 type ApplicationInfo struct {
 	Name               ApplicationName
 	SupportedVersions  []ApplicationVersion
 	DefaultVersion     ApplicationVersion
 	FilePatternConfigs map[ApplicationVersion]*FilePatternsConfig
+
+	// List of file extensions of the application's project files, used to detect
+	// which applications an existing project folder was made with.
+	ProjectFileExtensions []string
 }
 
 type ApplicationConfig struct {
 	Name    ApplicationName    `json:"name"`
 	Version ApplicationVersion `json:"version"`
+
+	// If true, then the application's compressed XML files are extracted in a canonical form.
+	// See FilePatternsConfig.CanonicalizedXmlFileExtensions.
+	CanonicalizeXml bool `json:"canonicalize-xml,omitempty"`
+
+	// If true, then the application's compressed XML files are extracted with each track in a separate file.
+	// See FilePatternsConfig.SplitXmlFileExtensions.
+	SplitXml bool `json:"split-xml,omitempty"`
 }
 
 type ApplicationName string
 type ApplicationVersion string
 
 var SupportedApplications = map[string]*ApplicationInfo{
-	"Ableton": AbletonInfo,
+	"Ableton":    AbletonInfo,
+	"Ardour":     ArdourInfo,
+	"Bitwig":     BitwigInfo,
+	"LMMS":       LmmsInfo,
+	"MuseScore":  MuseScoreInfo,
+	"REAPER":     ReaperInfo,
+	"Renoise":    RenoiseInfo,
+	"Studio One": StudioOneInfo,
+}
+
+// The applications that a new project is configured for if none are given or detected.
+var DefaultApplicationNames = []string{"Ableton"}
+
+// Returns the names of all supported applications, sorted alphabetically.
+func GetSupportedApplicationNames() (applicationNames []string) {
+	applicationNames = make([]string, 0)
+	for applicationName := range SupportedApplications {
+		applicationNames = append(applicationNames, applicationName)
+	}
+	sort.Strings(applicationNames)
+	return
 }
 
+// Returns the names of the supported applications that made any of the given project files, sorted alphabetically.
+func GetApplicationNamesForProjectFiles(fileNames []string) (applicationNames []string) {
+	applicationNames = make([]string, 0)
+	for _, applicationName := range GetSupportedApplicationNames() {
+		supportedApplication := SupportedApplications[applicationName]
+		for _, fileName := range fileNames {
+			if hasAnyExtension(strings.ToLower(fileName), supportedApplication.ProjectFileExtensions) {
+				applicationNames = append(applicationNames, applicationName)
+				break
+			}
+		}
+	}
+	return
+}
+
+// Returns the configs for every supported version of every supported application,
+// ordered by application name and then by version.
 func GetApplicationSpecificFilePatternsConfigList() (filePatternsConfigList []*FilePatternsConfig) {
 	filePatternsConfigList = make([]*FilePatternsConfig, 0)
-	for _, supprtedApplication := range SupportedApplications {
-		for _, supportedVersionConfig := range supprtedApplication.FilePatternConfigs {
-			filePatternsConfigList = append(filePatternsConfigList, supportedVersionConfig)
+	for _, applicationName := range GetSupportedApplicationNames() {
+		supportedApplication := SupportedApplications[applicationName]
+		for _, supportedVersion := range supportedApplication.SupportedVersions {
+			if supportedVersionConfig, ok := supportedApplication.FilePatternConfigs[supportedVersion]; ok {
+				filePatternsConfigList = append(filePatternsConfigList, supportedVersionConfig)
+			}
+		}
+	}
+	return
+}
+
+// Returns true if the given file is a compressed XML file saved by any supported version of the application.
+func (info *ApplicationInfo) IsCompressedXmlFile(fileName string) bool {
+	for _, filePatternsConfig := range info.FilePatternConfigs {
+		if hasAnyExtension(fileName, filePatternsConfig.CompressedXmlFileExtensions) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the given compressed XML file extensions that belong to files saved by any supported version
+// of the application, such as to only handle extracted Ableton Live Sets as Ableton XML.
+func (info *ApplicationInfo) FilterCompressedXmlFileExtensions(fileExtensions []string) (applicationFileExtensions []string) {
+	applicationFileExtensions = make([]string, 0)
+	for _, fileExtension := range fileExtensions {
+		if info.IsCompressedXmlFile("." + fileExtension) {
+			applicationFileExtensions = append(applicationFileExtensions, fileExtension)
 		}
 	}
 	return
@@ -0,0 +1,43 @@
+package applications
+
+var ArdourInfo = &ApplicationInfo{
+
+	Name: "Ardour",
+
+	SupportedVersions: []ApplicationVersion{
+		"8",
+	},
+
+	DefaultVersion: "8",
+
+	FilePatternConfigs: map[ApplicationVersion]*FilePatternsConfig{
+		"8": Ardour8FilePatternsConfig,
+	},
+
+	ProjectFileExtensions: []string{
+		"ardour",
+	},
+}
+
+// Ardour sessions (*.ardour) are plain XML, so they are committed to git directly.
+// Peak files, analysis data, and removed sources are regenerated or discarded by Ardour,
+// so they are ignored. The patterns are anchored to the session folder, which is the project folder.
+var Ardour8FilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
+
+	Name: "Ardour 8",
+
+	GitIgnorePatterns: []string{
+		"/peaks/",
+		"/analysis/",
+		"/dead/",
+		"/*.pending",
+		"/*.bak",
+		"/*.history",
+	},
+
+	GitLfsTrackPatterns: []string{
+		"interchange/*/audiofiles/**",
+	},
+
+	CompressedXmlFileExtensions: []string{},
+}
@@ -0,0 +1,44 @@
+package applications
+
+var BitwigInfo = &ApplicationInfo{
+
+	Name: "Bitwig",
+
+	SupportedVersions: []ApplicationVersion{
+		"5",
+	},
+
+	DefaultVersion: "5",
+
+	FilePatternConfigs: map[ApplicationVersion]*FilePatternsConfig{
+		"5": Bitwig5FilePatternsConfig,
+	},
+
+	ProjectFileExtensions: []string{
+		"bwproject",
+	},
+}
+
+// Bitwig Studio saves projects, clips, and scenes in a binary format, so they are
+// tracked with git-lfs rather than extracted. Presets are zip archives, so they are extracted.
+var Bitwig5FilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
+
+	Name: "Bitwig 5",
+
+	GitIgnorePatterns: []string{
+		"auto-backups/",
+		"*.bwpreset",
+	},
+
+	GitLfsTrackPatterns: []string{
+		"*.bwproject",
+		"*.bwclip",
+		"*.bwscene",
+	},
+
+	CompressedXmlFileExtensions: []string{},
+
+	ZippedFileExtensions: []string{
+		"bwpreset",
+	},
+}
@@ -30,18 +30,45 @@ func GetAllFilePatternsConfig() (allFilePatternsConfig *FilePatternsConfig) {
 // ------------------------------------------------------------------------------
 
 type FilePatternsConfig struct {
-	Name                     string
-	GitIgnorePatterns        []string
-	GitLfsTrackPatterns      []string
-	GzippedXmlFileExtensions []string // List of file extensions that represent Gzipped XML files.
+	Name                        string
+	GitIgnorePatterns           []string
+	GitLfsTrackPatterns         []string
+	CompressedXmlFileExtensions []string // List of file extensions that represent compressed XML files.
+	ZippedFileExtensions        []string // List of file extensions that represent zip archives, such as project bundles.
+
+	// The codecs of compressed XML files that aren't gzipped, indexed by file extension.
+	// See GetCompressedXmlFileCodec.
+	CompressedXmlFileCodecs map[string]util.CompressionCodec
+
+	// List of file extensions that represent plain-text files with embedded base64 plugin state chunks.
+	PluginStateChunkFileExtensions []string
+
+	// List of file extensions that represent compressed XML files which are extracted in a canonical form,
+	// if it is enabled for the application in the project config file.
+	CanonicalizedXmlFileExtensions []string
+
+	// Attributes in canonicalized XML files whose values change every time the file is saved.
+	VolatileXmlAttributes []*VolatileXmlAttribute
+
+	// List of file extensions that represent compressed XML files which are extracted with each track
+	// in a separate file, if it is enabled for the application in the project config file.
+	SplitXmlFileExtensions []string
 }
 
 func NewFilePatternsConfig() (filePatternsConfig *FilePatternsConfig) {
 	return &FilePatternsConfig{
-		Name:                     "",
-		GitIgnorePatterns:        make([]string, 0),
-		GitLfsTrackPatterns:      make([]string, 0),
-		GzippedXmlFileExtensions: make([]string, 0),
+		Name:                        "",
+		GitIgnorePatterns:           make([]string, 0),
+		GitLfsTrackPatterns:         make([]string, 0),
+		CompressedXmlFileExtensions: make([]string, 0),
+		ZippedFileExtensions:        make([]string, 0),
+
+		CompressedXmlFileCodecs: make(map[string]util.CompressionCodec),
+
+		PluginStateChunkFileExtensions: make([]string, 0),
+		CanonicalizedXmlFileExtensions: make([]string, 0),
+		VolatileXmlAttributes:          make([]*VolatileXmlAttribute, 0),
+		SplitXmlFileExtensions:         make([]string, 0),
 	}
 }
 
@@ -51,26 +78,146 @@ func (config *FilePatternsConfig) Print() {
 	util.Println("\t\t" + strings.Join(config.GitIgnorePatterns, "\n\t\t"))
 	util.Println("\tGit LFS Track Patterns")
 	util.Println("\t\t" + strings.Join(config.GitLfsTrackPatterns, "\n\t\t"))
-	util.Println("\tGzipped XML File Types")
-	util.Println("\t\t" + strings.Join(config.GzippedXmlFileExtensions, "\n\t\t"))
+	util.Println("\tCompressed XML File Types")
+	compressedXmlFileTypes := make([]string, 0, len(config.CompressedXmlFileExtensions))
+	for _, fileExtension := range config.CompressedXmlFileExtensions {
+		compressedXmlFileTypes = append(compressedXmlFileTypes, fileExtension+" ("+config.GetCompressedXmlFileCodec(fileExtension).Name()+")")
+	}
+	util.Println("\t\t" + strings.Join(compressedXmlFileTypes, "\n\t\t"))
+	util.Println("\tZipped File Types")
+	util.Println("\t\t" + strings.Join(config.ZippedFileExtensions, "\n\t\t"))
+	util.Println("\tPlugin State Chunk File Types")
+	util.Println("\t\t" + strings.Join(config.PluginStateChunkFileExtensions, "\n\t\t"))
+	util.Println("\tCanonicalized XML File Types")
+	util.Println("\t\t" + strings.Join(config.CanonicalizedXmlFileExtensions, "\n\t\t"))
+	util.Println("\tVolatile XML Attributes")
+	volatileXmlAttributeNames := make([]string, 0, len(config.VolatileXmlAttributes))
+	for _, volatileXmlAttribute := range config.VolatileXmlAttributes {
+		volatileXmlAttributeNames = append(volatileXmlAttributeNames, volatileXmlAttribute.String())
+	}
+	util.Println("\t\t" + strings.Join(volatileXmlAttributeNames, "\n\t\t"))
+	util.Println("\tSplit XML File Types")
+	util.Println("\t\t" + strings.Join(config.SplitXmlFileExtensions, "\n\t\t"))
 }
 
 func (config *FilePatternsConfig) SortAllLists() {
 	sort.Strings(config.GitIgnorePatterns)
 	sort.Strings(config.GitLfsTrackPatterns)
-	sort.Strings(config.GzippedXmlFileExtensions)
+	sort.Strings(config.CompressedXmlFileExtensions)
+	sort.Strings(config.ZippedFileExtensions)
+	sort.Strings(config.PluginStateChunkFileExtensions)
+	sort.Strings(config.CanonicalizedXmlFileExtensions)
+	sortVolatileXmlAttributes(config.VolatileXmlAttributes)
+	sort.Strings(config.SplitXmlFileExtensions)
 }
 
 func (config1 *FilePatternsConfig) AppendAll(config2 *FilePatternsConfig) (filePatternsConfig *FilePatternsConfig) {
 	config1.GitIgnorePatterns = appendUnique(config1.GitIgnorePatterns, config2.GitIgnorePatterns)
 	config1.GitLfsTrackPatterns = appendUnique(config1.GitLfsTrackPatterns, config2.GitLfsTrackPatterns)
-	config1.GzippedXmlFileExtensions = appendUnique(config1.GzippedXmlFileExtensions, config2.GzippedXmlFileExtensions)
+	config1.CompressedXmlFileExtensions = appendUnique(config1.CompressedXmlFileExtensions, config2.CompressedXmlFileExtensions)
+	config1.CompressedXmlFileCodecs = appendCompressedXmlFileCodecs(config1.CompressedXmlFileCodecs, config2.CompressedXmlFileCodecs)
+	config1.ZippedFileExtensions = appendUnique(config1.ZippedFileExtensions, config2.ZippedFileExtensions)
+	config1.PluginStateChunkFileExtensions = appendUnique(config1.PluginStateChunkFileExtensions, config2.PluginStateChunkFileExtensions)
+	config1.CanonicalizedXmlFileExtensions = appendUnique(config1.CanonicalizedXmlFileExtensions, config2.CanonicalizedXmlFileExtensions)
+	config1.VolatileXmlAttributes = appendUniqueVolatileXmlAttributes(config1.VolatileXmlAttributes, config2.VolatileXmlAttributes)
+	config1.SplitXmlFileExtensions = appendUnique(config1.SplitXmlFileExtensions, config2.SplitXmlFileExtensions)
 	filePatternsConfig = config1
 	return
 }
 
+// Returns a copy of this config that doesn't canonicalize any XML files.
+func (config *FilePatternsConfig) WithoutXmlCanonicalization() *FilePatternsConfig {
+	configCopy := *config
+	configCopy.CanonicalizedXmlFileExtensions = make([]string, 0)
+	configCopy.VolatileXmlAttributes = make([]*VolatileXmlAttribute, 0)
+	return &configCopy
+}
+
+// Returns a copy of this config that doesn't split any XML files.
+func (config *FilePatternsConfig) WithoutXmlSplitting() *FilePatternsConfig {
+	configCopy := *config
+	configCopy.SplitXmlFileExtensions = make([]string, 0)
+	return &configCopy
+}
+
+// Returns the codec of compressed XML files with the given extension. Files are gzipped unless
+// another codec is given in CompressedXmlFileCodecs.
+func (config *FilePatternsConfig) GetCompressedXmlFileCodec(fileExtension string) util.CompressionCodec {
+	if codec, ok := config.CompressedXmlFileCodecs[fileExtension]; ok {
+		return codec
+	}
+	return util.GzipCodec
+}
+
+// Returns true if the given compressed XML file should be extracted in a canonical form.
+func (config *FilePatternsConfig) IsCanonicalizedXmlFile(fileName string) bool {
+	return hasAnyExtension(fileName, config.CanonicalizedXmlFileExtensions)
+}
+
+// Returns true if the given compressed XML file should be extracted with each track in a separate file.
+func (config *FilePatternsConfig) IsSplitXmlFile(fileName string) bool {
+	return hasAnyExtension(fileName, config.SplitXmlFileExtensions)
+}
+
 // ------------------------------------------------------------------------------
 
+// An XML attribute whose value changes every time a file is saved, even if nothing meaningful changed,
+// such as an internal object ID. Its value is replaced with CanonicalValue in canonicalized XML files,
+// so CanonicalValue must be a value that the application accepts when the file is restored.
+type VolatileXmlAttribute struct {
+	ElementName    string
+	AttributeName  string
+	CanonicalValue string
+}
+
+func (attribute *VolatileXmlAttribute) String() string {
+	return attribute.ElementName + "/@" + attribute.AttributeName
+}
+
+// ------------------------------------------------------------------------------
+
+func appendUniqueVolatileXmlAttributes(list1 []*VolatileXmlAttribute, list2 []*VolatileXmlAttribute) (newList []*VolatileXmlAttribute) {
+	newList = make([]*VolatileXmlAttribute, 0)
+	uniqueVals := make(map[string]*VolatileXmlAttribute)
+	for _, list1Val := range list1 {
+		uniqueVals[list1Val.String()] = list1Val
+	}
+	for _, list2Val := range list2 {
+		uniqueVals[list2Val.String()] = list2Val
+	}
+	for _, val := range uniqueVals {
+		newList = append(newList, val)
+	}
+	sortVolatileXmlAttributes(newList)
+	return
+}
+
+func sortVolatileXmlAttributes(list []*VolatileXmlAttribute) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].String() < list[j].String()
+	})
+}
+
+func hasAnyExtension(fileName string, fileExtensions []string) bool {
+	for _, fileExtension := range fileExtensions {
+		if strings.HasSuffix(fileName, "."+fileExtension) {
+			return true
+		}
+	}
+	return false
+}
+
+func appendCompressedXmlFileCodecs(codecs1 map[string]util.CompressionCodec, codecs2 map[string]util.CompressionCodec) (newCodecs map[string]util.CompressionCodec) {
+	newCodecs = make(map[string]util.CompressionCodec)
+	for fileExtension, codec := range codecs1 {
+		newCodecs[fileExtension] = codec
+	}
+	for fileExtension, codec := range codecs2 {
+		newCodecs[fileExtension] = codec
+	}
+	return
+}
+
 func appendUnique(list1 []string, list2 []string) (newList []string) {
 	newList = make([]string, 0)
 	uniqueVals := make(map[string]bool)
@@ -60,5 +60,5 @@ var AudioFilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
 		"*.cda",
 	},
 
-	GzippedXmlFileExtensions: []string{},
+	CompressedXmlFileExtensions: []string{},
 }
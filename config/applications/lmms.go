@@ -0,0 +1,44 @@
+package applications
+
+import "github.com/stevengt/mppm/util"
+
+var LmmsInfo = &ApplicationInfo{
+
+	Name: "LMMS",
+
+	SupportedVersions: []ApplicationVersion{
+		"1",
+	},
+
+	DefaultVersion: "1",
+
+	FilePatternConfigs: map[ApplicationVersion]*FilePatternsConfig{
+		"1": Lmms1FilePatternsConfig,
+	},
+
+	ProjectFileExtensions: []string{
+		"mmp",
+		"mmpz",
+	},
+}
+
+// Uncompressed LMMS projects (*.mmp) and presets (*.xpf) are plain XML,
+// so they are committed to git directly.
+var Lmms1FilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
+
+	Name: "LMMS 1",
+
+	GitIgnorePatterns: []string{
+		"*.mmpz",
+	},
+
+	GitLfsTrackPatterns: []string{},
+
+	CompressedXmlFileExtensions: []string{
+		"mmpz",
+	},
+
+	CompressedXmlFileCodecs: map[string]util.CompressionCodec{
+		"mmpz": util.QCompressCodec,
+	},
+}
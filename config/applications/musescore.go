@@ -0,0 +1,37 @@
+package applications
+
+var MuseScoreInfo = &ApplicationInfo{
+
+	Name: "MuseScore",
+
+	SupportedVersions: []ApplicationVersion{
+		"4",
+	},
+
+	DefaultVersion: "4",
+
+	FilePatternConfigs: map[ApplicationVersion]*FilePatternsConfig{
+		"4": MuseScore4FilePatternsConfig,
+	},
+
+	ProjectFileExtensions: []string{
+		"mscz",
+	},
+}
+
+var MuseScore4FilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
+
+	Name: "MuseScore 4",
+
+	GitIgnorePatterns: []string{
+		"*.mscz",
+	},
+
+	GitLfsTrackPatterns: []string{},
+
+	CompressedXmlFileExtensions: []string{},
+
+	ZippedFileExtensions: []string{
+		"mscz",
+	},
+}
@@ -0,0 +1,46 @@
+package applications
+
+var ReaperInfo = &ApplicationInfo{
+
+	Name: "REAPER",
+
+	SupportedVersions: []ApplicationVersion{
+		"7",
+	},
+
+	DefaultVersion: "7",
+
+	FilePatternConfigs: map[ApplicationVersion]*FilePatternsConfig{
+		"7": Reaper7FilePatternsConfig,
+	},
+
+	ProjectFileExtensions: []string{
+		"rpp",
+	},
+}
+
+// REAPER project files (*.RPP) are plain text, so they are committed to git directly.
+// Copies of them with their embedded plugin states split into separate files can optionally
+// be committed instead, with 'mppm project extract --split-plugin-state'.
+var Reaper7FilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
+
+	Name: "REAPER 7",
+
+	GitIgnorePatterns: []string{
+		"*.RPP-bak",
+		"*.rpp-bak",
+		"*.reapeaks",
+		"*-undo.rpl",
+	},
+
+	GitLfsTrackPatterns: []string{
+		"*.RfxChain",
+		"*.ReaperThemeZip",
+	},
+
+	CompressedXmlFileExtensions: []string{},
+
+	PluginStateChunkFileExtensions: []string{
+		"RPP",
+	},
+}
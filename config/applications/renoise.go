@@ -0,0 +1,37 @@
+package applications
+
+var RenoiseInfo = &ApplicationInfo{
+
+	Name: "Renoise",
+
+	SupportedVersions: []ApplicationVersion{
+		"3",
+	},
+
+	DefaultVersion: "3",
+
+	FilePatternConfigs: map[ApplicationVersion]*FilePatternsConfig{
+		"3": Renoise3FilePatternsConfig,
+	},
+
+	ProjectFileExtensions: []string{
+		"xrns",
+	},
+}
+
+var Renoise3FilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
+
+	Name: "Renoise 3",
+
+	GitIgnorePatterns: []string{
+		"*.xrns",
+	},
+
+	GitLfsTrackPatterns: []string{},
+
+	CompressedXmlFileExtensions: []string{},
+
+	ZippedFileExtensions: []string{
+		"xrns",
+	},
+}
@@ -0,0 +1,38 @@
+package applications
+
+var StudioOneInfo = &ApplicationInfo{
+
+	Name: "Studio One",
+
+	SupportedVersions: []ApplicationVersion{
+		"6",
+	},
+
+	DefaultVersion: "6",
+
+	FilePatternConfigs: map[ApplicationVersion]*FilePatternsConfig{
+		"6": StudioOne6FilePatternsConfig,
+	},
+
+	ProjectFileExtensions: []string{
+		"song",
+	},
+}
+
+var StudioOne6FilePatternsConfig *FilePatternsConfig = &FilePatternsConfig{
+
+	Name: "Studio One 6",
+
+	GitIgnorePatterns: []string{
+		"History/",
+		"*.song",
+	},
+
+	GitLfsTrackPatterns: []string{},
+
+	CompressedXmlFileExtensions: []string{},
+
+	ZippedFileExtensions: []string{
+		"song",
+	},
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+
+	"github.com/stevengt/mppm/util"
+)
+
+// The name of the file within a project bundle that describes its contents.
+var BundleManifestFileName = "mppm-bundle.json"
+
+// The directory within a project bundle that contains the project's files.
+var BundleProjectDirectoryName = "project"
+
+// The directory within a project bundle that contains a directory of files for each library.
+var BundleLibrariesDirectoryName = "libraries"
+
+// ------------------------------------------------------------------------------
+
+// Describes a self-contained bundle of a project, created by 'mppm project export', including
+// the files from each library that the project refers to, at the library versions in its config file.
+type BundleManifest struct {
+	Version   string           `json:"version"` // The version of mppm that created the bundle.
+	Libraries []*BundleLibrary `json:"libraries"`
+}
+
+type BundleLibrary struct {
+	Name                string   `json:"name"`     // The name of the library's directory within the bundle.
+	OriginalFilePath    string   `json:"location"` // Where the library was on the system that created the bundle.
+	OriginalGitCommitId string   `json:"version"`
+	Files               []string `json:"files"` // Slash-separated, relative to the library.
+}
+
+func NewBundleManifest() *BundleManifest {
+	return &BundleManifest{
+		Version:   Version,
+		Libraries: make([]*BundleLibrary, 0),
+	}
+}
+
+func NewBundleManifestFromJson(manifestAsJson []byte) (manifest *BundleManifest, err error) {
+	manifest = &BundleManifest{}
+	err = json.Unmarshal(manifestAsJson, manifest)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Libraries == nil {
+		manifest.Libraries = make([]*BundleLibrary, 0)
+	}
+	return
+}
+
+// Returns an error if any library's name or files could refer to anything outside of the folders that
+// they are unpacked into, such as with absolute paths or "..", or if two libraries have the same name.
+func (manifest *BundleManifest) Validate() (err error) {
+
+	libraryNames := make(map[string]bool)
+
+	for _, library := range manifest.Libraries {
+
+		if !util.IsValidRelativeFileName(library.Name) || strings.Contains(library.Name, "/") {
+			err = errors.New("The bundle's manifest has a library with an invalid name: '" + library.Name + "'")
+			return
+		}
+
+		if libraryNames[library.Name] {
+			err = errors.New("The bundle's manifest has more than one library named '" + library.Name + "'")
+			return
+		}
+		libraryNames[library.Name] = true
+
+		for _, fileName := range library.Files {
+			if !util.IsValidRelativeFileName(fileName) {
+				err = errors.New("The bundle's manifest has a file outside of the library '" + library.Name + "': '" + fileName + "'")
+				return
+			}
+		}
+
+	}
+
+	return
+
+}
+
+func (manifest *BundleManifest) AsJson() (manifestAsJson []byte, err error) {
+	return json.MarshalIndent(manifest, "", "\t")
+}
+
+// Returns the slash-separated name within the bundle of the given project file.
+func GetBundledProjectFileName(fileName string) string {
+	return path.Join(BundleProjectDirectoryName, fileName)
+}
+
+// Returns the slash-separated name within the bundle of the given file, relative to the library.
+func (library *BundleLibrary) GetBundledFileName(fileName string) string {
+	return path.Join(BundleLibrariesDirectoryName, library.Name, fileName)
+}
@@ -35,6 +35,12 @@ func GetFilePatternsConfigListFromProjectConfig() (filePatternsConfigList []*app
 		for _, supportedApplication := range applications.SupportedApplications {
 			if supportedApplication.Name == projectApplicationConfig.Name {
 				if filePatternsConfig, ok := supportedApplication.FilePatternConfigs[projectApplicationConfig.Version]; ok {
+					if !projectApplicationConfig.CanonicalizeXml {
+						filePatternsConfig = filePatternsConfig.WithoutXmlCanonicalization()
+					}
+					if !projectApplicationConfig.SplitXml {
+						filePatternsConfig = filePatternsConfig.WithoutXmlSplitting()
+					}
 					filePatternsConfigList = append(filePatternsConfigList, filePatternsConfig)
 				}
 			}
@@ -65,6 +71,26 @@ func GetAllFilePatternsConfigFromProjectConfig() (allFilePatternsConfig *applica
 
 }
 
+// Returns the version of the given application specified in the project config file.
+// If the application is not specified, then ok is false.
+func GetApplicationVersionFromProjectConfig(applicationName applications.ApplicationName) (version applications.ApplicationVersion, ok bool, err error) {
+
+	projectConfig, err := MppmConfigFileManager.GetProjectConfig()
+	if err != nil {
+		return
+	}
+
+	for _, projectApplicationConfig := range projectConfig.Applications {
+		if projectApplicationConfig.Name == applicationName {
+			version, ok = projectApplicationConfig.Version, true
+			return
+		}
+	}
+
+	return
+
+}
+
 func GetCurrentlyInstalledMajorVersion() string {
 	return strings.Split(Version, ".")[0]
 }
@@ -108,15 +134,8 @@ func (config *MppmConfigInfo) save(filePath string) (err error) {
 	if err != nil {
 		return
 	}
-	configAsJsonReader := bytes.NewReader(configAsJson)
-
-	file, err := util.CreateFile(filePath)
-	if err != nil {
-		return
-	}
-	defer file.Close()
 
-	_, err = io.Copy(file, configAsJsonReader)
+	err = util.WriteFile(filePath, configAsJson)
 	if err != nil {
 		return
 	}
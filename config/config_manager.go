@@ -13,11 +13,11 @@ type MppmConfigManager interface {
 	GetProjectConfig() (projectConfig *MppmConfigInfo, err error)
 	GetGlobalConfig() (globalConfig *MppmConfigInfo, err error)
 	GetProjectAndGlobalConfigs() (projectConfig *MppmConfigInfo, globalConfig *MppmConfigInfo, err error)
-	GetDefaultMppmConfig() (mppmConfig *MppmConfigInfo)
+	GetDefaultMppmConfig(applicationNames ...string) (mppmConfig *MppmConfigInfo)
 	GetMppmGlobalConfigFilePath() (filePath string, err error)
 	SaveProjectConfig() (err error)
 	SaveGlobalConfig() (err error)
-	SaveDefaultProjectConfig() (err error)
+	SaveDefaultProjectConfig(applicationNames ...string) (err error)
 }
 
 type mppmConfigFileManager struct {
@@ -80,12 +80,22 @@ func (configFileManager *mppmConfigFileManager) GetProjectAndGlobalConfigs() (pr
 
 }
 
-func (configFileManager *mppmConfigFileManager) GetDefaultMppmConfig() (mppmConfig *MppmConfigInfo) {
+// Returns a config for the given supported applications, using the default version of each.
+// If no applications are given, then applications.DefaultApplicationNames is used.
+func (configFileManager *mppmConfigFileManager) GetDefaultMppmConfig(applicationNames ...string) (mppmConfig *MppmConfigInfo) {
 
 	applicationConfigList := make([]*applications.ApplicationConfig, 0)
 	libraryConfigList := make([]*LibraryConfig, 0)
 
-	for _, supportedApplication := range applications.SupportedApplications {
+	if len(applicationNames) == 0 {
+		applicationNames = applications.DefaultApplicationNames
+	}
+
+	for _, applicationName := range applicationNames {
+		supportedApplication, ok := applications.SupportedApplications[applicationName]
+		if !ok {
+			continue
+		}
 		applicationConfig := &applications.ApplicationConfig{
 			Name:    supportedApplication.Name,
 			Version: supportedApplication.DefaultVersion,
@@ -142,8 +152,8 @@ func (configFileManager *mppmConfigFileManager) SaveGlobalConfig() (err error) {
 
 }
 
-func (configFileManager *mppmConfigFileManager) SaveDefaultProjectConfig() (err error) {
-	err = configFileManager.GetDefaultMppmConfig().save(MppmConfigFileName)
+func (configFileManager *mppmConfigFileManager) SaveDefaultProjectConfig(applicationNames ...string) (err error) {
+	err = configFileManager.GetDefaultMppmConfig(applicationNames...).save(MppmConfigFileName)
 	return
 }
 
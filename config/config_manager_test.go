@@ -250,6 +250,9 @@ func TestGetGlobalConfig(t *testing.T) {
 
 func TestGetDefaultMppmConfig(t *testing.T) {
 
+	configManager := config.MppmConfigFileManager
+
+	// Test that only the default applications are used if none are given.
 	expectedConfigInfo := &config.MppmConfigInfo{
 		Version: config.Version,
 		Applications: []*applications.ApplicationConfig{
@@ -260,10 +263,25 @@ func TestGetDefaultMppmConfig(t *testing.T) {
 		},
 		Libraries: make([]*config.LibraryConfig, 0),
 	}
-
-	configManager := config.MppmConfigFileManager
 	actualConfigInfo := configManager.GetDefaultMppmConfig()
+	assert.Exactly(t, expectedConfigInfo, actualConfigInfo)
 
+	// Test that only the given applications are used, and that unsupported ones are skipped.
+	expectedConfigInfo = &config.MppmConfigInfo{
+		Version: config.Version,
+		Applications: []*applications.ApplicationConfig{
+			&applications.ApplicationConfig{
+				Name:    "REAPER",
+				Version: "7",
+			},
+			&applications.ApplicationConfig{
+				Name:    "Ardour",
+				Version: "8",
+			},
+		},
+		Libraries: make([]*config.LibraryConfig, 0),
+	}
+	actualConfigInfo = configManager.GetDefaultMppmConfig("REAPER", "FL Studio", "Ardour")
 	assert.Exactly(t, expectedConfigInfo, actualConfigInfo)
 
 }
@@ -10,6 +10,7 @@ import (
 	"github.com/stevengt/mppm/config"
 
 	"github.com/stevengt/mppm/config/configtest"
+	"github.com/stevengt/mppm/util"
 )
 
 func TestGetFilePatternsConfigListFromProjectConfig(t *testing.T) {
@@ -20,7 +21,7 @@ func TestGetFilePatternsConfigListFromProjectConfig(t *testing.T) {
 			description: "Test if all supported project-specific-applications and general file patterns are returned.",
 			expectedFilePatternsConfigList: []*applications.FilePatternsConfig{
 				applications.AudioFilePatternsConfig,
-				applications.Ableton10FilePatternsConfig,
+				applications.Ableton10FilePatternsConfig.WithoutXmlCanonicalization().WithoutXmlSplitting(),
 			},
 			mockMppmConfigManagerBuilder: configtest.NewMockMppmConfigManagerBuilder().
 				SetProjectConfigFromJson(
@@ -28,6 +29,30 @@ func TestGetFilePatternsConfigListFromProjectConfig(t *testing.T) {
 				),
 		},
 
+		&GetFilePatternsConfigListFromProjectConfigTestCase{
+			description: "Test if XML canonicalization is only included for applications that enable it in the project config file.",
+			expectedFilePatternsConfigList: []*applications.FilePatternsConfig{
+				applications.AudioFilePatternsConfig,
+				applications.Ableton11FilePatternsConfig.WithoutXmlSplitting(),
+			},
+			mockMppmConfigManagerBuilder: configtest.NewMockMppmConfigManagerBuilder().
+				SetProjectConfigFromJson(
+					configtest.ConfigWithValidVersionAndCanonicalizedAbletonApplication.ConfigAsJson,
+				),
+		},
+
+		&GetFilePatternsConfigListFromProjectConfigTestCase{
+			description: "Test if XML splitting is only included for applications that enable it in the project config file.",
+			expectedFilePatternsConfigList: []*applications.FilePatternsConfig{
+				applications.AudioFilePatternsConfig,
+				applications.Ableton10FilePatternsConfig.WithoutXmlCanonicalization(),
+			},
+			mockMppmConfigManagerBuilder: configtest.NewMockMppmConfigManagerBuilder().
+				SetProjectConfigFromJson(
+					configtest.ConfigWithValidVersionAndSplitAbletonApplication.ConfigAsJson,
+				),
+		},
+
 		&GetFilePatternsConfigListFromProjectConfigTestCase{
 			description: "Test if only general file patterns are returned if no applications are specified in the project config file.",
 			expectedFilePatternsConfigList: []*applications.FilePatternsConfig{
@@ -71,7 +96,13 @@ func TestGetAllFilePatternsConfigFromProjectConfig(t *testing.T) {
 					"*.rf64", "*.aiff", "*.amr", "*.vox", "*.wav", "*.8svx", "*.mp3", "*.ra",
 					"*.ams", "*.amxd", "*.alp", "*.asd", "*.agr",
 				},
-				GzippedXmlFileExtensions: []string{"adv", "adg", "als", "alc"},
+				CompressedXmlFileExtensions:    []string{"adv", "adg", "als", "alc"},
+				CompressedXmlFileCodecs:        map[string]util.CompressionCodec{},
+				ZippedFileExtensions:           []string{},
+				PluginStateChunkFileExtensions: []string{},
+				CanonicalizedXmlFileExtensions: []string{},
+				VolatileXmlAttributes:          []*applications.VolatileXmlAttribute{},
+				SplitXmlFileExtensions:         []string{},
 			},
 			mockMppmConfigManagerBuilder: configtest.NewMockMppmConfigManagerBuilder().
 				SetProjectConfigFromJson(
@@ -91,7 +122,13 @@ func TestGetAllFilePatternsConfigFromProjectConfig(t *testing.T) {
 					"*.wv", "*.m4p", "*.rm", "*.ape", "*.awb", "*.mmf", "*.oga", "*.opus",
 					"*.rf64", "*.aiff", "*.amr", "*.vox", "*.wav", "*.8svx", "*.mp3", "*.ra",
 				},
-				GzippedXmlFileExtensions: []string{},
+				CompressedXmlFileExtensions:    []string{},
+				CompressedXmlFileCodecs:        map[string]util.CompressionCodec{},
+				ZippedFileExtensions:           []string{},
+				PluginStateChunkFileExtensions: []string{},
+				CanonicalizedXmlFileExtensions: []string{},
+				VolatileXmlAttributes:          []*applications.VolatileXmlAttribute{},
+				SplitXmlFileExtensions:         []string{},
 			},
 			mockMppmConfigManagerBuilder: configtest.NewMockMppmConfigManagerBuilder().
 				SetProjectConfigFromJson(
@@ -99,6 +136,60 @@ func TestGetAllFilePatternsConfigFromProjectConfig(t *testing.T) {
 				),
 		},
 
+		&GetAllFilePatternsConfigFromProjectConfigTestCase{
+			description: "Test if set bundles and tuning systems are tracked for projects using Ableton 11.",
+			expectedFilePatternsConfig: &applications.FilePatternsConfig{
+				Name:              "",
+				GitIgnorePatterns: []string{"Backup/", "*.als", "*.alc", "*.adv", "*.adg"},
+				GitLfsTrackPatterns: []string{"*.flac", "*.iklax", "*.m4a", "*.alac", "*.au",
+					"*.mpc", "*.ogg", "*.mogg", "*.tta", "*.wma", "*.aax", "*.act", "*.ivs",
+					"*.aa", "*.dvf", "*.m4b", "*.nsf", "*.raw", "*.webm", "*.cda", "*.dct",
+					"*.gsm", "*.dss", "*.msv", "*.nmf", "*.sln", "*.3gp", "*.aac", "*.voc",
+					"*.wv", "*.m4p", "*.rm", "*.ape", "*.awb", "*.mmf", "*.oga", "*.opus",
+					"*.rf64", "*.aiff", "*.amr", "*.vox", "*.wav", "*.8svx", "*.mp3", "*.ra",
+					"*.ams", "*.amxd", "*.alp", "*.asd", "*.agr", "*.ablbundle", "*.ascl",
+				},
+				CompressedXmlFileExtensions:    []string{"adv", "adg", "als", "alc"},
+				CompressedXmlFileCodecs:        map[string]util.CompressionCodec{},
+				ZippedFileExtensions:           []string{},
+				PluginStateChunkFileExtensions: []string{},
+				CanonicalizedXmlFileExtensions: []string{"adv", "adg", "als", "alc"},
+				VolatileXmlAttributes:          applications.Ableton11FilePatternsConfig.VolatileXmlAttributes,
+				SplitXmlFileExtensions:         []string{},
+			},
+			mockMppmConfigManagerBuilder: configtest.NewMockMppmConfigManagerBuilder().
+				SetProjectConfigFromJson(
+					configtest.ConfigWithValidVersionAndCanonicalizedAbletonApplication.ConfigAsJson,
+				),
+		},
+
+		&GetAllFilePatternsConfigFromProjectConfigTestCase{
+			description: "Test if Ardour session files are committed as text, with audio files tracked and generated files ignored.",
+			expectedFilePatternsConfig: &applications.FilePatternsConfig{
+				Name:              "",
+				GitIgnorePatterns: []string{"/peaks/", "/analysis/", "/dead/", "/*.pending", "/*.bak", "/*.history"},
+				GitLfsTrackPatterns: []string{"*.flac", "*.iklax", "*.m4a", "*.alac", "*.au",
+					"*.mpc", "*.ogg", "*.mogg", "*.tta", "*.wma", "*.aax", "*.act", "*.ivs",
+					"*.aa", "*.dvf", "*.m4b", "*.nsf", "*.raw", "*.webm", "*.cda", "*.dct",
+					"*.gsm", "*.dss", "*.msv", "*.nmf", "*.sln", "*.3gp", "*.aac", "*.voc",
+					"*.wv", "*.m4p", "*.rm", "*.ape", "*.awb", "*.mmf", "*.oga", "*.opus",
+					"*.rf64", "*.aiff", "*.amr", "*.vox", "*.wav", "*.8svx", "*.mp3", "*.ra",
+					"interchange/*/audiofiles/**",
+				},
+				CompressedXmlFileExtensions:    []string{},
+				CompressedXmlFileCodecs:        map[string]util.CompressionCodec{},
+				ZippedFileExtensions:           []string{},
+				PluginStateChunkFileExtensions: []string{},
+				CanonicalizedXmlFileExtensions: []string{},
+				VolatileXmlAttributes:          []*applications.VolatileXmlAttribute{},
+				SplitXmlFileExtensions:         []string{},
+			},
+			mockMppmConfigManagerBuilder: configtest.NewMockMppmConfigManagerBuilder().
+				SetProjectConfigFromJson(
+					configtest.ConfigWithValidVersionAndArdourApplication.ConfigAsJson,
+				),
+		},
+
 		&GetAllFilePatternsConfigFromProjectConfigTestCase{
 			description:                "Test if errors from configManager.GetProjectConfig() are properly raised.",
 			expectedFilePatternsConfig: nil,
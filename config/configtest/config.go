@@ -22,6 +22,66 @@ var ConfigWithValidVersionAndApplicationNameAndApplicationVersion *MppmConfigInf
 	ExpectedError: nil,
 }
 
+var ConfigWithValidVersionAndReaperApplication *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"REAPER","version":"7"}],"libraries":null}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
+var ConfigWithValidVersionAndStudioOneApplication *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"Studio One","version":"6"}],"libraries":null}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
+var ConfigWithValidVersionAndLmmsApplication *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"LMMS","version":"1"}],"libraries":null}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
+var ConfigWithValidVersionAndArdourApplication *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"Ardour","version":"8"}],"libraries":null}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
+var ConfigWithValidVersionAndCanonicalizedAbletonApplication *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"Ableton","version":"11","canonicalize-xml":true}],"libraries":null}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
+var ConfigWithValidVersionAndSplitAbletonApplication *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"Ableton","version":"10","split-xml":true}],"libraries":null}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
 var ConfigWithValidVersionAndNoApplications *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
 	ConfigAsJson: []byte(
 		fmt.Sprintf(
@@ -82,6 +142,36 @@ var ConfigWithAllValidInfoAndPreviousLibraryVersion *MppmConfigInfoAndExpectedEr
 	ExpectedError: nil,
 }
 
+var ConfigWithAllValidInfoAndTaggedLibraryVersion *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"Ableton","version":"10"}],"libraries":[{"location":"/home/testuser/library","most-recent-version":"56789","current-version":"56789","tags":{"v1":"56789"}}]}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
+var ConfigWithAllValidInfoAndTwoLibraries *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"Ableton","version":"10"}],"libraries":[{"location":"/home/testuser/library","most-recent-version":"56789","current-version":"56789"},{"location":"/home/testuser/other-library","most-recent-version":"98765","current-version":"98765"}]}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
+var ConfigWithAllValidInfoAndTwoPreviousLibraryVersions *MppmConfigInfoAndExpectedError = &MppmConfigInfoAndExpectedError{
+	ConfigAsJson: []byte(
+		fmt.Sprintf(
+			`{"version":"%s.0.0","applications":[{"name":"Ableton","version":"10"}],"libraries":[{"location":"/home/testuser/library","most-recent-version":"56789","current-version":"01234"},{"location":"/home/testuser/other-library","most-recent-version":"98765","current-version":"43210"}]}`,
+			config.GetCurrentlyInstalledMajorVersion(),
+		),
+	),
+	ExpectedError: nil,
+}
+
 // ------------------------------------------------------------------------------
 
 // A convenience method that wraps config.MppmConfigFileManager.GetDefaultMppmConfig().AsJson() .
@@ -89,8 +179,8 @@ var ConfigWithAllValidInfoAndPreviousLibraryVersion *MppmConfigInfoAndExpectedEr
 // and simply returns the JSON.
 //
 //This simplifies some test setup functions.
-func GetDefaultMppmConfigAsJson() []byte {
-	defaultMppmConfigAsJson, _ := config.MppmConfigFileManager.GetDefaultMppmConfig().AsJson()
+func GetDefaultMppmConfigAsJson(applicationNames ...string) []byte {
+	defaultMppmConfigAsJson, _ := config.MppmConfigFileManager.GetDefaultMppmConfig(applicationNames...).AsJson()
 	return defaultMppmConfigAsJson
 }
 
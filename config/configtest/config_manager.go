@@ -183,7 +183,7 @@ func (mockMppmConfigManager *MockMppmConfigManager) GetProjectAndGlobalConfigs()
 	return
 }
 
-func (mockMppmConfigManager *MockMppmConfigManager) GetDefaultMppmConfig() (mppmConfig *config.MppmConfigInfo) {
+func (mockMppmConfigManager *MockMppmConfigManager) GetDefaultMppmConfig(applicationNames ...string) (mppmConfig *config.MppmConfigInfo) {
 	return GetDefaultTestMppmConfigInfo()
 }
 
@@ -203,6 +203,6 @@ func (mockMppmConfigManager *MockMppmConfigManager) SaveGlobalConfig() (err erro
 	return mockMppmConfigManager.SaveGlobalConfigError
 }
 
-func (mockMppmConfigManager *MockMppmConfigManager) SaveDefaultProjectConfig() (err error) {
+func (mockMppmConfigManager *MockMppmConfigManager) SaveDefaultProjectConfig(applicationNames ...string) (err error) {
 	return mockMppmConfigManager.SaveDefaultProjectConfigError
 }
@@ -0,0 +1,117 @@
+package configtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+// Returns a builder for the extraction manifest file that mppm saves after extracting or
+// restoring the given files. The file builders are given in pairs of binary files followed
+// by their extracted files, and their contents are used as the recorded file states.
+// The recorded settings are those of a project that doesn't canonicalize or split XML files.
+func GetExtractionManifestFileBuilder(sourceAndExtractedFileBuilders ...*utiltest.MockFileBuilder) *utiltest.MockFileBuilder {
+	return GetExtractionManifestFileBuilderWithFilePatternsConfig(getDefaultFilePatternsConfig(), sourceAndExtractedFileBuilders...)
+}
+
+// Same as GetExtractionManifestFileBuilder, except that the recorded settings are those of the given config.
+func GetExtractionManifestFileBuilderWithFilePatternsConfig(filePatternsConfig *applications.FilePatternsConfig, sourceAndExtractedFileBuilders ...*utiltest.MockFileBuilder) *utiltest.MockFileBuilder {
+
+	manifest := &config.ExtractionManifest{
+		Files: make(map[string]*config.ExtractionManifestEntry),
+	}
+
+	for i := 0; i+1 < len(sourceAndExtractedFileBuilders); i += 2 {
+		sourceFileBuilder := sourceAndExtractedFileBuilders[i]
+		extractedFileBuilder := sourceAndExtractedFileBuilders[i+1]
+		manifest.Files[sourceFileBuilder.FilePath] = &config.ExtractionManifestEntry{
+			Source:    getMockFileState(sourceFileBuilder),
+			Extracted: getMockFileState(extractedFileBuilder),
+			Settings:  config.GetExtractionSettings(sourceFileBuilder.FilePath, filePatternsConfig),
+		}
+	}
+
+	manifestAsJson, _ := json.Marshal(manifest)
+
+	return utiltest.NewMockFileBuilder().
+		SetFilePath(config.GetExtractionManifestFileName()).
+		SetContentsFromBytes(manifestAsJson)
+
+}
+
+// Returns a builder for a manifest recording the given binary file, its extracted file, and the split parts of its extracted file.
+func GetSplitExtractionManifestFileBuilder(sourceFileBuilder *utiltest.MockFileBuilder, extractedFileBuilder *utiltest.MockFileBuilder, extractedPartFileBuilders ...*utiltest.MockFileBuilder) *utiltest.MockFileBuilder {
+
+	entry := &config.ExtractionManifestEntry{
+		Source:         getMockFileState(sourceFileBuilder),
+		Extracted:      getMockFileState(extractedFileBuilder),
+		Settings:       config.GetExtractionSettings(sourceFileBuilder.FilePath, getDefaultFilePatternsConfig().AppendAll(&applications.FilePatternsConfig{SplitXmlFileExtensions: []string{"als"}})),
+		ExtractedParts: make(map[string]*config.FileState),
+	}
+	for _, extractedPartFileBuilder := range extractedPartFileBuilders {
+		entry.ExtractedParts[extractedPartFileBuilder.FilePath] = getMockFileState(extractedPartFileBuilder)
+	}
+
+	manifest := &config.ExtractionManifest{
+		Files: map[string]*config.ExtractionManifestEntry{
+			sourceFileBuilder.FilePath: entry,
+		},
+	}
+
+	manifestAsJson, _ := json.Marshal(manifest)
+
+	return utiltest.NewMockFileBuilder().
+		SetFilePath(config.GetExtractionManifestFileName()).
+		SetContentsFromBytes(manifestAsJson)
+
+}
+
+// Returns a builder for a manifest recording the given zip archive and the files in its unzipped directory.
+func GetUnzippedExtractionManifestFileBuilder(zipFileBuilder *utiltest.MockFileBuilder, unzippedFileBuilders ...*utiltest.MockFileBuilder) *utiltest.MockFileBuilder {
+
+	entry := &config.ExtractionManifestEntry{
+		Source:         getMockFileState(zipFileBuilder),
+		Settings:       config.GetExtractionSettings(zipFileBuilder.FilePath, getDefaultFilePatternsConfig()),
+		ExtractedParts: make(map[string]*config.FileState),
+	}
+	for _, unzippedFileBuilder := range unzippedFileBuilders {
+		entry.ExtractedParts[unzippedFileBuilder.FilePath] = getMockFileState(unzippedFileBuilder)
+	}
+
+	manifest := &config.ExtractionManifest{
+		Files: map[string]*config.ExtractionManifestEntry{
+			zipFileBuilder.FilePath: entry,
+		},
+	}
+
+	manifestAsJson, _ := json.Marshal(manifest)
+
+	return utiltest.NewMockFileBuilder().
+		SetFilePath(config.GetExtractionManifestFileName()).
+		SetContentsFromBytes(manifestAsJson)
+
+}
+
+// Returns a builder for the .gitignore file that keeps the mppm directory out of git.
+func GetMppmDirectoryGitIgnoreFileBuilder() *utiltest.MockFileBuilder {
+	return utiltest.NewMockFileBuilder().
+		SetFilePath(config.MppmDirectoryName + "/.gitignore").
+		SetContentsFromString("*\n")
+}
+
+func getDefaultFilePatternsConfig() *applications.FilePatternsConfig {
+	return applications.GetAllFilePatternsConfig().WithoutXmlCanonicalization().WithoutXmlSplitting()
+}
+
+func getMockFileState(mockFileBuilder *utiltest.MockFileBuilder) *config.FileState {
+	sum := sha256.Sum256(mockFileBuilder.Contents)
+	return &config.FileState{
+		Size:         int64(len(mockFileBuilder.Contents)),
+		ModifiedTime: utiltest.MockFileModifiedTime,
+		Sha256:       hex.EncodeToString(sum[:]),
+	}
+}
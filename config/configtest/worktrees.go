@@ -0,0 +1,40 @@
+package configtest
+
+import (
+	"encoding/json"
+
+	"github.com/stevengt/mppm/config"
+	"github.com/stevengt/mppm/util/utiltest"
+)
+
+// Returns a builder for the file that records the worktrees that the project has checked libraries out into.
+// The worktrees are indexed by the locations of their libraries.
+func GetProjectLibraryWorktreesFileBuilder(worktreeFilePaths map[string]string) *utiltest.MockFileBuilder {
+
+	worktrees := &config.ProjectLibraryWorktrees{
+		FilePaths: worktreeFilePaths,
+	}
+
+	worktreesAsJson, _ := json.Marshal(worktrees)
+
+	return utiltest.NewMockFileBuilder().
+		SetFilePath(config.GetProjectLibraryWorktreesFileName()).
+		SetContentsFromBytes(worktreesAsJson)
+
+}
+
+// Returns a builder for the file in the test user's home folder that records every library worktree, and the
+// projects that use it. The entries are indexed by the locations of the worktrees.
+func GetLibraryWorktreeRegistryFileBuilder(entries map[string]*config.LibraryWorktreeRegistryEntry) *utiltest.MockFileBuilder {
+
+	registry := &config.LibraryWorktreeRegistry{
+		Worktrees: entries,
+	}
+
+	registryAsJson, _ := json.Marshal(registry)
+
+	return utiltest.NewMockFileBuilder().
+		SetFilePath("/home/testuser/.mppm-worktrees/worktrees.json").
+		SetContentsFromBytes(registryAsJson)
+
+}
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/stevengt/mppm/util"
@@ -11,8 +13,17 @@ type LibraryConfig struct {
 	FilePath              string `json:"location"`
 	MostRecentGitCommitId string `json:"most-recent-version"`
 	CurrentGitCommitId    string `json:"current-version"`
+
+	// The commit IDs of the snapshots taken with 'mppm library snapshot --tag', indexed by their tag names.
+	Tags map[string]string `json:"tags,omitempty"`
+
+	// The branch that snapshots are committed to. If it is empty, 'main' or 'master' is used, whichever exists.
+	BranchName string `json:"branch,omitempty"`
 }
 
+// The branch names that are checked for, in order, if a library doesn't specify its branch.
+var DefaultLibraryBranchNames = []string{"main", "master"}
+
 func (libraryConfig *LibraryConfig) Print() {
 	libraryConfigAsStringTemplate := `
 %s
@@ -26,6 +37,10 @@ func (libraryConfig *LibraryConfig) Print() {
 		libraryConfig.CurrentGitCommitId,
 	)
 
+	for _, tag := range libraryConfig.GetSortedTags() {
+		libraryConfigAsString += fmt.Sprintf("\ttag %s=\"%s\"\n", tag, libraryConfig.Tags[tag])
+	}
+
 	util.Println(libraryConfigAsString)
 }
 
@@ -39,3 +54,53 @@ func (libraryConfig *LibraryConfig) UpdateCurrentGitCommitId() (err error) {
 	libraryConfig.CurrentGitCommitId = libraryGitCommitId
 	return
 }
+
+// Returns the name of the branch that the library's snapshots are committed to.
+func (libraryConfig *LibraryConfig) GetBranchName() (branchName string, err error) {
+
+	if libraryConfig.BranchName != "" {
+		branchName = libraryConfig.BranchName
+		return
+	}
+
+	gitManager := util.NewGitManager(libraryConfig.FilePath)
+	for _, defaultBranchName := range DefaultLibraryBranchNames {
+		if _, revParseErr := gitManager.RevParse("--verify", "--quiet", "refs/heads/"+defaultBranchName); revParseErr == nil {
+			branchName = defaultBranchName
+			return
+		}
+	}
+
+	err = errors.New(
+		"Unable to find the branch of the library '" + libraryConfig.FilePath + "'. " +
+			"Set the library's \"branch\" in the global config file to the name of the branch that it is committed to.",
+	)
+	return
+
+}
+
+// Returns the commit ID of the given version of the library, which can either be a commit ID,
+// or the name of a tag recorded by 'mppm library snapshot'.
+func (libraryConfig *LibraryConfig) GetGitCommitId(version string) string {
+	if gitCommitId, ok := libraryConfig.Tags[version]; ok {
+		return gitCommitId
+	}
+	return version
+}
+
+// Records that the given tag refers to the given commit of the library.
+func (libraryConfig *LibraryConfig) AddTag(tag string, gitCommitId string) {
+	if libraryConfig.Tags == nil {
+		libraryConfig.Tags = make(map[string]string)
+	}
+	libraryConfig.Tags[tag] = gitCommitId
+}
+
+func (libraryConfig *LibraryConfig) GetSortedTags() (tags []string) {
+	tags = make([]string, 0, len(libraryConfig.Tags))
+	for tag := range libraryConfig.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return
+}
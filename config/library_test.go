@@ -1,6 +1,7 @@
 package config_test
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,3 +39,71 @@ func TestUpdateCurrentGitCommitId(t *testing.T) {
 	assert.Equal(t, "Git commit = 456", libraryConfig.CurrentGitCommitId)
 
 }
+
+func TestGetGitCommitId(t *testing.T) {
+
+	libraryConfig := &config.LibraryConfig{
+		FilePath:              ".",
+		MostRecentGitCommitId: "789",
+		CurrentGitCommitId:    "123",
+	}
+
+	// Test that commit ids are returned unchanged if the library has no tags.
+	assert.Equal(t, "456", libraryConfig.GetGitCommitId("456"))
+
+	libraryConfig.AddTag("v2", "789")
+	libraryConfig.AddTag("v1", "123")
+
+	// Test that tags are resolved to their commit ids, and that other versions are returned unchanged.
+	assert.Equal(t, "123", libraryConfig.GetGitCommitId("v1"))
+	assert.Equal(t, "456", libraryConfig.GetGitCommitId("456"))
+	assert.Equal(t, []string{"v1", "v2"}, libraryConfig.GetSortedTags())
+
+}
+
+func TestGetBranchName(t *testing.T) {
+
+	libraryConfig := &config.LibraryConfig{
+		FilePath: ".",
+	}
+
+	// Test that 'main' is used if it exists.
+	utiltest.NewMockGitManagerCreatorBuilder().
+		Build().
+		Init()
+
+	branchName, err := libraryConfig.GetBranchName()
+	assert.Nil(t, err)
+	assert.Equal(t, "main", branchName)
+
+	// Test that 'master' is used if 'main' doesn't exist.
+	utiltest.NewMockGitManagerCreatorBuilder().
+		SetRevParseErrors(
+			map[string]error{
+				"--verify --quiet refs/heads/main": errors.New("exit status 1"),
+			},
+		).
+		Build().
+		Init()
+
+	branchName, err = libraryConfig.GetBranchName()
+	assert.Nil(t, err)
+	assert.Equal(t, "master", branchName)
+
+	// Test that an error is raised if neither branch exists.
+	utiltest.NewMockGitManagerCreatorBuilder().
+		SetUseDefaultRevParseError(true).
+		Build().
+		Init()
+
+	_, err = libraryConfig.GetBranchName()
+	assert.NotNil(t, err)
+
+	// Test that the branch in the config file is used without checking the git repository.
+	libraryConfig.BranchName = "trunk"
+
+	branchName, err = libraryConfig.GetBranchName()
+	assert.Nil(t, err)
+	assert.Equal(t, "trunk", branchName)
+
+}
@@ -0,0 +1,390 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stevengt/mppm/config/applications"
+	"github.com/stevengt/mppm/util"
+)
+
+// The directory, relative to the project root, where mppm keeps files that are
+// specific to a local copy of the project, and that should not be committed to git.
+var MppmDirectoryName = ".mppm"
+
+// Returns the name of the file that records which files were already extracted or restored.
+func GetExtractionManifestFileName() string {
+	return util.JoinFilePath(MppmDirectoryName, "manifest.json")
+}
+
+// Returns the name of the directory where binary files are backed up before 'mppm project restore'
+// overwrites them, for binary files that were last modified at the given time.
+func GetBackupDirectoryName(modifiedTime time.Time) string {
+	return util.JoinFilePath(MppmDirectoryName, "backups", modifiedTime.UTC().Format("20060102-150405"))
+}
+
+// Writes a .gitignore file to the mppm directory if it doesn't exist yet, so that
+// nothing in the directory is committed to git.
+func WriteMppmDirectoryGitIgnoreFile() (err error) {
+	gitIgnoreFileName := util.JoinFilePath(MppmDirectoryName, ".gitignore")
+	if !util.DoesFileExist(gitIgnoreFileName) {
+		err = util.WriteFile(gitIgnoreFileName, []byte("*\n"))
+	}
+	return
+}
+
+// ------------------------------------------------------------------------------
+
+// Returns the current settings that the given binary file is extracted with.
+func GetExtractionSettings(sourceFileName string, filePatternsConfig *applications.FilePatternsConfig) (settings *ExtractionSettings) {
+
+	settings = &ExtractionSettings{
+		CanonicalizeXml: filePatternsConfig.IsCanonicalizedXmlFile(sourceFileName),
+		SplitXml:        filePatternsConfig.IsSplitXmlFile(sourceFileName),
+	}
+
+	for _, fileExtension := range filePatternsConfig.CompressedXmlFileExtensions {
+		if strings.HasSuffix(sourceFileName, "."+fileExtension) {
+			settings.Codec = filePatternsConfig.GetCompressedXmlFileCodec(fileExtension).Name()
+		}
+	}
+
+	for _, fileExtension := range filePatternsConfig.PluginStateChunkFileExtensions {
+		if strings.HasSuffix(strings.ToLower(sourceFileName), "."+strings.ToLower(fileExtension)) {
+			settings.SplitPluginState = true
+		}
+	}
+
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+// Records the size, modification time, and content hash of each binary file and its
+// extracted file at the time of the last extraction or restoration, so that files
+// which haven't changed since then can be skipped.
+type ExtractionManifest struct {
+	Files map[string]*ExtractionManifestEntry `json:"files"` // Indexed by the name of the binary file.
+
+	wasModified bool
+	mutex       sync.Mutex // Allows files to be checked and updated by concurrent tasks.
+}
+
+type ExtractionManifestEntry struct {
+	Source *FileState `json:"source"`
+
+	// Nil if the binary file was extracted into a directory instead of a single file, such as
+	// an unzipped archive. The files in the directory are recorded in ExtractedParts instead.
+	Extracted *FileState `json:"extracted"`
+
+	// The settings that the binary file was extracted with. If they are different from the current
+	// settings, such as after enabling XML canonicalization, then the file is extracted again.
+	Settings *ExtractionSettings `json:"settings,omitempty"`
+
+	// Any other files that the binary file was extracted into, such as the tracks of a split Live Set,
+	// indexed by file name.
+	ExtractedParts map[string]*FileState `json:"extracted-parts,omitempty"`
+}
+
+type ExtractionSettings struct {
+	Codec            string `json:"codec,omitempty"`              // The name of the codec of a compressed XML file.
+	CanonicalizeXml  bool   `json:"canonicalize-xml,omitempty"`   // See applications.FilePatternsConfig.IsCanonicalizedXmlFile.
+	SplitXml         bool   `json:"split-xml,omitempty"`          // See applications.FilePatternsConfig.IsSplitXmlFile.
+	SplitPluginState bool   `json:"split-plugin-state,omitempty"` // True for plain-text project files whose plugin states are split.
+}
+
+type FileState struct {
+	Size         int64     `json:"size"`
+	ModifiedTime time.Time `json:"modified-time"`
+	Sha256       string    `json:"sha256"`
+}
+
+// Loads the manifest from the project, or returns an empty manifest if it doesn't exist yet.
+func LoadExtractionManifest() (manifest *ExtractionManifest, err error) {
+
+	manifest = &ExtractionManifest{
+		Files: make(map[string]*ExtractionManifestEntry),
+	}
+
+	manifestFileName := GetExtractionManifestFileName()
+	if !util.DoesFileExist(manifestFileName) {
+		return
+	}
+
+	contents, err := util.ReadFile(manifestFileName)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(contents, manifest)
+	if err != nil {
+		return
+	}
+
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]*ExtractionManifestEntry)
+	}
+
+	return
+
+}
+
+// Saves the manifest to the project if any entries were updated since it was loaded.
+func (manifest *ExtractionManifest) Save() (err error) {
+
+	if !manifest.wasModified {
+		return
+	}
+
+	// Keep the mppm directory out of git, since the recorded file states only apply to this copy of the project.
+	err = WriteMppmDirectoryGitIgnoreFile()
+	if err != nil {
+		return
+	}
+
+	manifestAsJson, err := json.Marshal(manifest)
+	if err != nil {
+		return
+	}
+
+	err = util.WriteFile(GetExtractionManifestFileName(), manifestAsJson)
+	if err != nil {
+		return
+	}
+
+	manifest.wasModified = false
+	return
+
+}
+
+// Returns true if neither the given binary file nor its extracted files have changed
+// since they were last recorded with Update, and they were recorded with the given settings.
+// The extracted file name is empty if the binary file was extracted into a directory of parts.
+//
+// The sizes and modification times of the files are compared first. If they are different,
+// then the content hashes are compared instead, so that files that were only touched are still skipped.
+func (manifest *ExtractionManifest) IsUpToDate(sourceFileName string, extractedFileName string, settings *ExtractionSettings, extractedPartFileNames ...string) (isUpToDate bool, err error) {
+
+	manifest.mutex.Lock()
+	entry, ok := manifest.Files[sourceFileName]
+	manifest.mutex.Unlock()
+	if !ok || entry.Source == nil || (entry.Extracted == nil) != (extractedFileName == "") {
+		return
+	}
+
+	if entry.Settings == nil || settings == nil || *entry.Settings != *settings {
+		return
+	}
+
+	if !util.DoesFileExist(sourceFileName) {
+		return
+	}
+
+	sourceFileState, isSourceUnchanged, err := entry.Source.compare(sourceFileName)
+	if err != nil || !isSourceUnchanged {
+		return
+	}
+	wasTouched := !sourceFileState.ModifiedTime.Equal(entry.Source.ModifiedTime)
+
+	var extractedFileState *FileState
+	if extractedFileName != "" {
+
+		if !util.DoesFileExist(extractedFileName) {
+			return
+		}
+
+		var isExtractedUnchanged bool
+		extractedFileState, isExtractedUnchanged, err = entry.Extracted.compare(extractedFileName)
+		if err != nil || !isExtractedUnchanged {
+			return
+		}
+		wasTouched = wasTouched || !extractedFileState.ModifiedTime.Equal(entry.Extracted.ModifiedTime)
+
+	}
+
+	// Parts that were added or removed since the last extraction are changes too.
+	if len(extractedPartFileNames) != len(entry.ExtractedParts) {
+		return
+	}
+
+	extractedPartFileStates := make(map[string]*FileState)
+	for _, extractedPartFileName := range extractedPartFileNames {
+
+		recordedPartFileState, ok := entry.ExtractedParts[extractedPartFileName]
+		if !ok || recordedPartFileState == nil {
+			return
+		}
+
+		var extractedPartFileState *FileState
+		var isExtractedPartUnchanged bool
+		extractedPartFileState, isExtractedPartUnchanged, err = recordedPartFileState.compare(extractedPartFileName)
+		if err != nil || !isExtractedPartUnchanged {
+			return
+		}
+
+		extractedPartFileStates[extractedPartFileName] = extractedPartFileState
+		wasTouched = wasTouched || !extractedPartFileState.ModifiedTime.Equal(recordedPartFileState.ModifiedTime)
+
+	}
+
+	// Record the new modification times so the hashes don't need to be compared again next time.
+	if wasTouched {
+		manifest.mutex.Lock()
+		manifest.Files[sourceFileName] = newExtractionManifestEntry(sourceFileState, extractedFileState, extractedPartFileStates, settings)
+		manifest.wasModified = true
+		manifest.mutex.Unlock()
+	}
+
+	isUpToDate = true
+	return
+
+}
+
+// Returns true if the given binary file has changed since it was last recorded with Update,
+// or if it was never recorded.
+func (manifest *ExtractionManifest) HasSourceChanged(sourceFileName string) (hasChanged bool, err error) {
+
+	manifest.mutex.Lock()
+	entry, ok := manifest.Files[sourceFileName]
+	manifest.mutex.Unlock()
+	if !ok || entry.Source == nil {
+		hasChanged = true
+		return
+	}
+
+	_, isUnchanged, err := entry.Source.compare(sourceFileName)
+	if err != nil {
+		return
+	}
+
+	hasChanged = !isUnchanged
+	return
+
+}
+
+// Returns the settings that the given binary file was last recorded with, or nil if it was never recorded.
+func (manifest *ExtractionManifest) GetSettings(sourceFileName string) (settings *ExtractionSettings) {
+	manifest.mutex.Lock()
+	defer manifest.mutex.Unlock()
+	if entry, ok := manifest.Files[sourceFileName]; ok {
+		settings = entry.Settings
+	}
+	return
+}
+
+// Records the current states of the given binary file and its extracted files, and the settings they were extracted with.
+// The extracted file name is empty if the binary file was extracted into a directory of parts.
+func (manifest *ExtractionManifest) Update(sourceFileName string, extractedFileName string, settings *ExtractionSettings, extractedPartFileNames ...string) (err error) {
+
+	sourceFileState, err := getFileState(sourceFileName)
+	if err != nil {
+		return
+	}
+
+	var extractedFileState *FileState
+	if extractedFileName != "" {
+		extractedFileState, err = getFileState(extractedFileName)
+		if err != nil {
+			return
+		}
+	}
+
+	extractedPartFileStates := make(map[string]*FileState)
+	for _, extractedPartFileName := range extractedPartFileNames {
+		extractedPartFileStates[extractedPartFileName], err = getFileState(extractedPartFileName)
+		if err != nil {
+			return
+		}
+	}
+
+	manifest.mutex.Lock()
+	defer manifest.mutex.Unlock()
+
+	manifest.Files[sourceFileName] = newExtractionManifestEntry(sourceFileState, extractedFileState, extractedPartFileStates, settings)
+	manifest.wasModified = true
+
+	return
+
+}
+
+func newExtractionManifestEntry(sourceFileState *FileState, extractedFileState *FileState, extractedPartFileStates map[string]*FileState, settings *ExtractionSettings) (entry *ExtractionManifestEntry) {
+	entry = &ExtractionManifestEntry{
+		Source:    sourceFileState,
+		Extracted: extractedFileState,
+		Settings:  settings,
+	}
+	if len(extractedPartFileStates) > 0 {
+		entry.ExtractedParts = extractedPartFileStates
+	}
+	return
+}
+
+// Returns the current state of the given file, and whether its contents are the same as the recorded state.
+func (recordedFileState *FileState) compare(fileName string) (currentFileState *FileState, isUnchanged bool, err error) {
+
+	fileInfo, err := util.StatFile(fileName)
+	if err != nil {
+		return
+	}
+
+	currentFileState = &FileState{
+		Size:         fileInfo.Size(),
+		ModifiedTime: fileInfo.ModTime().UTC(),
+		Sha256:       recordedFileState.Sha256,
+	}
+
+	if currentFileState.Size != recordedFileState.Size {
+		return
+	}
+
+	if currentFileState.ModifiedTime.Equal(recordedFileState.ModifiedTime) {
+		isUnchanged = true
+		return
+	}
+
+	currentFileState.Sha256, err = getFileSha256(fileName)
+	if err != nil {
+		return
+	}
+
+	isUnchanged = currentFileState.Sha256 == recordedFileState.Sha256
+	return
+
+}
+
+func getFileState(fileName string) (fileState *FileState, err error) {
+
+	fileInfo, err := util.StatFile(fileName)
+	if err != nil {
+		return
+	}
+
+	sha256, err := getFileSha256(fileName)
+	if err != nil {
+		return
+	}
+
+	fileState = &FileState{
+		Size:         fileInfo.Size(),
+		ModifiedTime: fileInfo.ModTime().UTC(),
+		Sha256:       sha256,
+	}
+
+	return
+
+}
+
+func getFileSha256(fileName string) (sha256Hex string, err error) {
+	contents, err := util.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(contents)
+	sha256Hex = hex.EncodeToString(sum[:])
+	return
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/stevengt/mppm/util"
+)
+
+// Returns the name of the file that records which libraries a local copy of the project has checked out into worktrees.
+func GetProjectLibraryWorktreesFileName() string {
+	return util.JoinFilePath(MppmDirectoryName, "worktrees.json")
+}
+
+// ------------------------------------------------------------------------------
+
+// Records the worktrees that a local copy of the project has checked libraries out into with
+// 'mppm library checkout --project --worktree'. Worktrees are in the home folder, so they are only
+// recorded in the mppm directory, rather than in the project config file.
+type ProjectLibraryWorktrees struct {
+	FilePaths map[string]string `json:"worktrees"` // The location of each worktree, indexed by the location of its library.
+}
+
+// Loads the worktrees of the project in the current directory, or returns no worktrees if none are recorded.
+func LoadProjectLibraryWorktrees() (worktrees *ProjectLibraryWorktrees, err error) {
+	worktrees, err = loadProjectLibraryWorktreesFromFile(GetProjectLibraryWorktreesFileName())
+	return
+}
+
+// Loads the worktrees of the project in the given directory, or returns no worktrees if none are recorded.
+func LoadProjectLibraryWorktreesFromDirectory(projectDirectoryName string) (worktrees *ProjectLibraryWorktrees, err error) {
+	worktrees, err = loadProjectLibraryWorktreesFromFile(util.JoinFilePath(projectDirectoryName, GetProjectLibraryWorktreesFileName()))
+	return
+}
+
+func loadProjectLibraryWorktreesFromFile(fileName string) (worktrees *ProjectLibraryWorktrees, err error) {
+
+	worktrees = &ProjectLibraryWorktrees{
+		FilePaths: make(map[string]string),
+	}
+
+	if !util.DoesFileExist(fileName) {
+		return
+	}
+
+	contents, err := util.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(contents, worktrees)
+	if err != nil {
+		return
+	}
+
+	if worktrees.FilePaths == nil {
+		worktrees.FilePaths = make(map[string]string)
+	}
+
+	return
+
+}
+
+// Saves the worktrees to the project in the current directory.
+func (worktrees *ProjectLibraryWorktrees) Save() (err error) {
+
+	err = WriteMppmDirectoryGitIgnoreFile()
+	if err != nil {
+		return
+	}
+
+	worktreesAsJson, err := json.Marshal(worktrees)
+	if err != nil {
+		return
+	}
+
+	err = util.WriteFile(GetProjectLibraryWorktreesFileName(), worktreesAsJson)
+	return
+
+}
+
+// Returns the worktree that the given library is checked out into, or an empty string if it isn't.
+func (worktrees *ProjectLibraryWorktrees) Get(libraryFilePath string) string {
+	return worktrees.FilePaths[libraryFilePath]
+}
+
+// Records the worktree that the given library is checked out into, or removes it if the worktree is an empty string.
+func (worktrees *ProjectLibraryWorktrees) Set(libraryFilePath string, worktreeFilePath string) {
+	if worktreeFilePath == "" {
+		delete(worktrees.FilePaths, libraryFilePath)
+	} else {
+		worktrees.FilePaths[libraryFilePath] = worktreeFilePath
+	}
+}
+
+// Returns a copy of the worktrees, which can be changed without changing these ones.
+func (worktrees *ProjectLibraryWorktrees) Copy() *ProjectLibraryWorktrees {
+	worktreesCopy := &ProjectLibraryWorktrees{
+		FilePaths: make(map[string]string),
+	}
+	for libraryFilePath, worktreeFilePath := range worktrees.FilePaths {
+		worktreesCopy.FilePaths[libraryFilePath] = worktreeFilePath
+	}
+	return worktreesCopy
+}
+
+// ------------------------------------------------------------------------------
+
+// Records every worktree that mppm has checked a library out into, and the projects that use it,
+// so that worktrees which are no longer used by any project can be removed by 'mppm library prune'.
+type LibraryWorktreeRegistry struct {
+	Worktrees map[string]*LibraryWorktreeRegistryEntry `json:"worktrees"` // Indexed by the location of the worktree.
+
+	fileName string
+}
+
+type LibraryWorktreeRegistryEntry struct {
+	LibraryFilePath       string   `json:"library"`
+	ProjectDirectoryNames []string `json:"projects"` // The absolute locations of the projects that have used the worktree.
+}
+
+// Loads the registry from the given file, or returns an empty registry if it doesn't exist yet.
+func LoadLibraryWorktreeRegistry(fileName string) (registry *LibraryWorktreeRegistry, err error) {
+
+	registry = &LibraryWorktreeRegistry{
+		Worktrees: make(map[string]*LibraryWorktreeRegistryEntry),
+		fileName:  fileName,
+	}
+
+	if !util.DoesFileExist(fileName) {
+		return
+	}
+
+	contents, err := util.ReadFile(fileName)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(contents, registry)
+	if err != nil {
+		return
+	}
+
+	if registry.Worktrees == nil {
+		registry.Worktrees = make(map[string]*LibraryWorktreeRegistryEntry)
+	}
+
+	return
+
+}
+
+// Saves the registry to the file it was loaded from.
+func (registry *LibraryWorktreeRegistry) Save() (err error) {
+
+	registryAsJson, err := json.Marshal(registry)
+	if err != nil {
+		return
+	}
+
+	err = util.WriteFile(registry.fileName, registryAsJson)
+	return
+
+}
+
+// Records that the given project uses the given worktree of the library.
+func (registry *LibraryWorktreeRegistry) Add(worktreeFilePath string, libraryFilePath string, projectDirectoryName string) {
+
+	entry, ok := registry.Worktrees[worktreeFilePath]
+	if !ok {
+		entry = &LibraryWorktreeRegistryEntry{
+			LibraryFilePath:       libraryFilePath,
+			ProjectDirectoryNames: make([]string, 0),
+		}
+		registry.Worktrees[worktreeFilePath] = entry
+	}
+
+	for _, existingProjectDirectoryName := range entry.ProjectDirectoryNames {
+		if existingProjectDirectoryName == projectDirectoryName {
+			return
+		}
+	}
+
+	entry.ProjectDirectoryNames = append(entry.ProjectDirectoryNames, projectDirectoryName)
+	sort.Strings(entry.ProjectDirectoryNames)
+
+}
+
+// Returns the locations of all worktrees in the registry, sorted.
+func (registry *LibraryWorktreeRegistry) GetSortedWorktreeFilePaths() []string {
+	worktreeFilePaths := make([]string, 0, len(registry.Worktrees))
+	for worktreeFilePath := range registry.Worktrees {
+		worktreeFilePaths = append(worktreeFilePaths, worktreeFilePath)
+	}
+	sort.Strings(worktreeFilePaths)
+	return worktreeFilePaths
+}
@@ -0,0 +1,146 @@
+package reaper
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Names of the blocks in a REAPER project file that contain plugin instances,
+// whose state is stored as lines of base64-encoded data.
+var PluginBlockNames = []string{
+	"VST",
+	"AU",
+	"CLAP",
+	"DX",
+	"LV2",
+}
+
+// The keyword of the line that replaces each plugin state chunk in a split project file.
+var ChunkReferenceKeyword = "MPPM_CHUNK"
+
+var base64LineRegexp = regexp.MustCompile(`^[A-Za-z0-9+/]+={0,2}$`)
+
+// ------------------------------------------------------------------------------
+
+// Replaces each run of base64-encoded plugin state lines in a REAPER project file with a
+// single reference line, so that diffs of the project file only show readable changes.
+//
+// Returns the split project file, and the contents of each chunk indexed by its ID,
+// which is the SHA-1 hash of its contents. The original file can be reassembled with JoinPluginStateChunks.
+func SplitPluginStateChunks(contents []byte) (splitContents []byte, chunks map[string][]byte) {
+
+	chunks = make(map[string][]byte)
+
+	lines := strings.Split(string(contents), "\n")
+	splitLines := make([]string, 0, len(lines))
+
+	pluginBlockDepth := 0 // The depth of the innermost plugin block, or 0 if not in a plugin block.
+	depth := 0
+	chunkLines := make([]string, 0)
+
+	flushChunkLines := func() {
+		if len(chunkLines) == 0 {
+			return
+		}
+		chunk := []byte(strings.Join(chunkLines, "\n"))
+		chunkId := getChunkId(chunk)
+		chunks[chunkId] = chunk
+		indentation := chunkLines[0][:len(chunkLines[0])-len(strings.TrimLeft(chunkLines[0], " \t"))]
+		splitLines = append(splitLines, indentation+ChunkReferenceKeyword+" "+chunkId)
+		chunkLines = make([]string, 0)
+	}
+
+	for _, line := range lines {
+
+		trimmedLine := strings.TrimSpace(line)
+
+		if pluginBlockDepth > 0 && depth == pluginBlockDepth && base64LineRegexp.MatchString(trimmedLine) {
+			chunkLines = append(chunkLines, line)
+			continue
+		}
+		flushChunkLines()
+		splitLines = append(splitLines, line)
+
+		if strings.HasPrefix(trimmedLine, "<") {
+			depth++
+			if pluginBlockDepth == 0 && isPluginBlockStart(trimmedLine) {
+				pluginBlockDepth = depth
+			}
+		} else if trimmedLine == ">" {
+			if depth == pluginBlockDepth {
+				pluginBlockDepth = 0
+			}
+			if depth > 0 {
+				depth--
+			}
+		}
+
+	}
+	flushChunkLines()
+
+	splitContents = []byte(strings.Join(splitLines, "\n"))
+	return
+
+}
+
+// Replaces each chunk reference line in a split REAPER project file with the contents
+// of the chunk, as returned by readChunk.
+func JoinPluginStateChunks(splitContents []byte, readChunk func(chunkId string) ([]byte, error)) (contents []byte, err error) {
+
+	lines := strings.Split(string(splitContents), "\n")
+	joinedLines := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		if chunkId, isReference := getReferencedChunkId(line); isReference {
+			var chunk []byte
+			chunk, err = readChunk(chunkId)
+			if err != nil {
+				return
+			}
+			joinedLines = append(joinedLines, string(chunk))
+		} else {
+			joinedLines = append(joinedLines, line)
+		}
+	}
+
+	contents = []byte(strings.Join(joinedLines, "\n"))
+	return
+
+}
+
+// Returns true if the given REAPER project file contains any chunk reference lines.
+func HasPluginStateChunkReferences(contents []byte) bool {
+	for _, line := range strings.Split(string(contents), "\n") {
+		if _, isReference := getReferencedChunkId(line); isReference {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------------------------------------------------------------------
+
+func getChunkId(chunk []byte) string {
+	hash := sha1.Sum(chunk)
+	return hex.EncodeToString(hash[:])
+}
+
+func getReferencedChunkId(line string) (chunkId string, isReference bool) {
+	fields := strings.Fields(line)
+	if len(fields) == 2 && fields[0] == ChunkReferenceKeyword {
+		chunkId, isReference = fields[1], true
+	}
+	return
+}
+
+func isPluginBlockStart(trimmedLine string) bool {
+	blockName := strings.TrimPrefix(strings.Fields(trimmedLine)[0], "<")
+	for _, pluginBlockName := range PluginBlockNames {
+		if blockName == pluginBlockName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package reaper_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stevengt/mppm/reaper"
+	"github.com/stevengt/mppm/reaper/reapertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitPluginStateChunks(t *testing.T) {
+
+	testCases := []*SplitPluginStateChunksTestCase{
+
+		&SplitPluginStateChunksTestCase{
+			description:           "Test that base64-encoded plugin states are replaced with chunk references.",
+			contents:              reapertest.OriginalProjectRpp,
+			expectedSplitContents: reapertest.SplitProjectRpp,
+			expectedChunks: map[string][]byte{
+				reapertest.ChunkId: []byte(reapertest.Chunk),
+			},
+		},
+
+		&SplitPluginStateChunksTestCase{
+			description:           "Test that Windows line endings are preserved in chunks.",
+			contents:              "<VST \"VST: ReaEQ (Cockos)\" reaeq.dll 0 \"\"\r\n  AQAAAAEAAAAAAAAA\r\n>\r\n",
+			expectedSplitContents: "<VST \"VST: ReaEQ (Cockos)\" reaeq.dll 0 \"\"\r\n  MPPM_CHUNK 5a56af5597130ae4fba510a6f807feec29f21680\n>\r\n",
+			expectedChunks: map[string][]byte{
+				"5a56af5597130ae4fba510a6f807feec29f21680": []byte("  AQAAAAEAAAAAAAAA\r"),
+			},
+		},
+
+		&SplitPluginStateChunksTestCase{
+			description:           "Test that files without plugin states are unchanged.",
+			contents:              "<REAPER_PROJECT 0.1\n  TEMPO 120 4 4\n>\n",
+			expectedSplitContents: "<REAPER_PROJECT 0.1\n  TEMPO 120 4 4\n>\n",
+			expectedChunks:        map[string][]byte{},
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestJoinPluginStateChunks(t *testing.T) {
+
+	readChunk := func(chunkId string) ([]byte, error) {
+		if chunkId == reapertest.ChunkId {
+			return []byte(reapertest.Chunk), nil
+		}
+		return nil, errors.New("Unable to open file " + chunkId)
+	}
+
+	// Test that chunk references are replaced with the original plugin states.
+	contents, err := reaper.JoinPluginStateChunks([]byte(reapertest.SplitProjectRpp), readChunk)
+	assert.Nil(t, err)
+	assert.Exactly(t, reapertest.OriginalProjectRpp, string(contents))
+
+	// Test that any error from reading a chunk is properly raised.
+	_, err = reaper.JoinPluginStateChunks([]byte("<VST\n  MPPM_CHUNK 1234\n>\n"), readChunk)
+	assert.EqualError(t, err, "Unable to open file 1234")
+
+}
+
+// ------------------------------------------------------------------------------
+
+type SplitPluginStateChunksTestCase struct {
+	description           string
+	contents              string
+	expectedSplitContents string
+	expectedChunks        map[string][]byte
+}
+
+func (testCase *SplitPluginStateChunksTestCase) Run(t *testing.T) {
+
+	actualSplitContents, actualChunks := reaper.SplitPluginStateChunks([]byte(testCase.contents))
+	assert.Exactlyf(t, testCase.expectedSplitContents, string(actualSplitContents), testCase.description)
+	assert.Exactlyf(t, testCase.expectedChunks, actualChunks, testCase.description)
+
+	joinedContents, err := reaper.JoinPluginStateChunks(
+		actualSplitContents,
+		func(chunkId string) ([]byte, error) { return actualChunks[chunkId], nil },
+	)
+	assert.Nilf(t, err, testCase.description)
+	assert.Exactlyf(t, testCase.contents, string(joinedContents), testCase.description)
+
+}
@@ -0,0 +1,50 @@
+package reapertest
+
+// A minimal REAPER project containing one track with a VST plugin, whose state is stored
+// as base64-encoded lines, and a JS plugin, whose state is stored as plain text.
+var OriginalProjectRpp = `<REAPER_PROJECT 0.1 "7.15/linux-x86_64" 1712345678
+  TEMPO 120 4 4
+  <TRACK {3F2504E0-4F89-11D3-9A0C-0305E82C3301}
+    NAME Bass
+    <FXCHAIN
+      SHOW 0
+      <VST "VST: ReaEQ (Cockos)" reaeq.so 0 "" 1919247729<56535472656571726561657100000000> ""
+        cWVlcu9e7f4AAAAAAgAAAAEAAAAAAAAAAgAAAAAAAAACAAAAAQAAAAAAAAACAAAAAAAAAFQAAAABAAAAAAAAAAEAAAA=
+        AQAAAAEAAAAAAAAA
+        AFByb2dyYW0gMQAQAAAA
+      >
+      FLOATPOS 0 0 0 0
+      <JS loser/3BandEQ ""
+        0 200 0 2000 0 0 - - - - - - - - - - - - -
+      >
+    >
+  >
+>
+`
+
+// The same project as OriginalProjectRpp, after its plugin state chunks have been split.
+var SplitProjectRpp = `<REAPER_PROJECT 0.1 "7.15/linux-x86_64" 1712345678
+  TEMPO 120 4 4
+  <TRACK {3F2504E0-4F89-11D3-9A0C-0305E82C3301}
+    NAME Bass
+    <FXCHAIN
+      SHOW 0
+      <VST "VST: ReaEQ (Cockos)" reaeq.so 0 "" 1919247729<56535472656571726561657100000000> ""
+        MPPM_CHUNK ec3f4502311b324e2aadea22b8d5278f7aa22227
+      >
+      FLOATPOS 0 0 0 0
+      <JS loser/3BandEQ ""
+        0 200 0 2000 0 0 - - - - - - - - - - - - -
+      >
+    >
+  >
+>
+`
+
+// The ID of the only plugin state chunk in OriginalProjectRpp.
+var ChunkId = "ec3f4502311b324e2aadea22b8d5278f7aa22227"
+
+// The contents of the only plugin state chunk in OriginalProjectRpp.
+var Chunk = `        cWVlcu9e7f4AAAAAAgAAAAEAAAAAAAAAAgAAAAAAAAACAAAAAQAAAAAAAAACAAAAAAAAAFQAAAABAAAAAAAAAAEAAAA=
+        AQAAAAEAAAAAAAAA
+        AFByb2dyYW0gMQAQAAAA`
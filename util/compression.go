@@ -0,0 +1,131 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+)
+
+// Compresses and decompresses file contents in a particular format.
+type CompressionCodec interface {
+	Name() string
+	NewReader(compressed io.Reader) (uncompressed io.ReadCloser, err error)
+	NewWriter(compressed io.Writer) (uncompressed io.WriteCloser)
+}
+
+// Reads and writes gzip files, such as Ableton Live Sets.
+var GzipCodec CompressionCodec = &gzipCodec{}
+
+// Reads and writes data compressed by Qt's qCompress(), such as LMMS projects.
+// This is a zlib stream prefixed with the big-endian 4-byte length of the uncompressed data.
+var QCompressCodec CompressionCodec = &qCompressCodec{}
+
+// Returns the given contents compressed in memory using the given codec.
+func CompressBytes(contents []byte, codec CompressionCodec) (compressed []byte, err error) {
+
+	compressedBuffer := new(bytes.Buffer)
+	compressedWriter := codec.NewWriter(compressedBuffer)
+
+	_, err = compressedWriter.Write(contents)
+	closeErr := compressedWriter.Close()
+	if err != nil {
+		return
+	}
+	err = closeErr
+	if err != nil {
+		return
+	}
+
+	compressed = compressedBuffer.Bytes()
+	return
+
+}
+
+// Returns the given contents decompressed in memory using the given codec.
+func DecompressBytes(compressed []byte, codec CompressionCodec) (contents []byte, err error) {
+
+	compressedReader, err := codec.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return
+	}
+	defer compressedReader.Close()
+
+	contents, err = ioutil.ReadAll(compressedReader)
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+type gzipCodec struct{}
+
+func (codec *gzipCodec) Name() string {
+	return "gzip"
+}
+
+func (codec *gzipCodec) NewReader(compressed io.Reader) (uncompressed io.ReadCloser, err error) {
+	return gzip.NewReader(compressed)
+}
+
+func (codec *gzipCodec) NewWriter(compressed io.Writer) (uncompressed io.WriteCloser) {
+	return gzip.NewWriter(compressed)
+}
+
+// ------------------------------------------------------------------------------
+
+type qCompressCodec struct{}
+
+func (codec *qCompressCodec) Name() string {
+	return "qCompress"
+}
+
+func (codec *qCompressCodec) NewReader(compressed io.Reader) (uncompressed io.ReadCloser, err error) {
+	uncompressedLength := make([]byte, 4)
+	_, err = io.ReadFull(compressed, uncompressedLength)
+	if err != nil {
+		return
+	}
+	return zlib.NewReader(compressed)
+}
+
+func (codec *qCompressCodec) NewWriter(compressed io.Writer) (uncompressed io.WriteCloser) {
+	return &qCompressWriter{
+		compressed:   compressed,
+		uncompressed: new(bytes.Buffer),
+	}
+}
+
+// Buffers all uncompressed data until it is closed, since the length
+// of the uncompressed data must be written before the compressed data.
+type qCompressWriter struct {
+	compressed   io.Writer
+	uncompressed *bytes.Buffer
+}
+
+func (writer *qCompressWriter) Write(p []byte) (n int, err error) {
+	return writer.uncompressed.Write(p)
+}
+
+func (writer *qCompressWriter) Close() (err error) {
+
+	uncompressedLength := make([]byte, 4)
+	binary.BigEndian.PutUint32(uncompressedLength, uint32(writer.uncompressed.Len()))
+	_, err = writer.compressed.Write(uncompressedLength)
+	if err != nil {
+		return
+	}
+
+	zlibWriter := zlib.NewWriter(writer.compressed)
+	_, err = io.Copy(zlibWriter, writer.uncompressed)
+	closeErr := zlibWriter.Close()
+	if err != nil {
+		return
+	}
+	err = closeErr
+
+	return
+
+}
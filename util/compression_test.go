@@ -0,0 +1,89 @@
+package util_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stevengt/mppm/util"
+	"github.com/stevengt/mppm/util/utiltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressionCodecs(t *testing.T) {
+
+	testCases := []*CompressionCodecTestCase{
+
+		&CompressionCodecTestCase{
+			description: "Test that gzip-compressed contents can be decompressed.",
+			codec:       util.GzipCodec,
+		},
+
+		&CompressionCodecTestCase{
+			description:         "Test that qCompressed contents can be decompressed, and are prefixed with their uncompressed length.",
+			codec:               util.QCompressCodec,
+			expectedHeaderAsHex: "00000035",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestQCompressCodecNewReader(t *testing.T) {
+
+	// Test that contents compressed by Qt's qCompress() are decompressed.
+	compressedContents := utiltest.GetFakeLmmsProjectFileBuilder().Contents
+	reader, err := util.QCompressCodec.NewReader(bytes.NewReader(compressedContents))
+	assert.Nil(t, err)
+	actualContents, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Exactly(t, utiltest.GetFakeUncompressedLmmsProjectFileBuilder().Contents, actualContents)
+
+	// Test that an error is raised if the contents are too short to have a length prefix.
+	_, err = util.QCompressCodec.NewReader(bytes.NewReader([]byte{0x00, 0x00}))
+	assert.NotNil(t, err)
+
+}
+
+// ------------------------------------------------------------------------------
+
+type CompressionCodecTestCase struct {
+	description         string
+	codec               util.CompressionCodec
+	expectedHeaderAsHex string
+}
+
+func (testCase *CompressionCodecTestCase) Run(t *testing.T) {
+
+	contents := utiltest.GetFakeUncompressedLmmsProjectFileBuilder().Contents
+
+	compressedContents := new(bytes.Buffer)
+	writer := testCase.codec.NewWriter(compressedContents)
+	_, err := writer.Write(contents)
+	assert.Nilf(t, err, testCase.description)
+	assert.Nilf(t, writer.Close(), testCase.description)
+
+	if testCase.expectedHeaderAsHex != "" {
+		actualHeaderAsHex := hex.EncodeToString(compressedContents.Bytes()[:len(testCase.expectedHeaderAsHex)/2])
+		assert.Exactlyf(t, testCase.expectedHeaderAsHex, actualHeaderAsHex, testCase.description)
+	}
+
+	reader, err := testCase.codec.NewReader(compressedContents)
+	assert.Nilf(t, err, testCase.description)
+	actualContents, err := ioutil.ReadAll(reader)
+	assert.Nilf(t, err, testCase.description)
+	assert.Nilf(t, reader.Close(), testCase.description)
+	assert.Exactlyf(t, contents, actualContents, testCase.description)
+
+	// Test that contents compressed in memory are decompressed to the original contents.
+	compressedBytes, err := util.CompressBytes(contents, testCase.codec)
+	assert.Nilf(t, err, testCase.description)
+	actualContents, err = util.DecompressBytes(compressedBytes, testCase.codec)
+	assert.Nilf(t, err, testCase.description)
+	assert.Exactlyf(t, contents, actualContents, testCase.description)
+
+}
@@ -1,11 +1,18 @@
 package util
 
 import (
-	"compress/gzip"
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 )
 
 var FileSystemProxy FileSystemDelegater = &fileSystemProxy{}
@@ -18,6 +25,13 @@ func CreateFile(fileName string) (file io.ReadWriteCloser, err error) {
 	return FileSystemProxy.CreateFile(fileName)
 }
 
+// Creates or replaces the given file with the contents written by writeContents.
+// The contents are written to a temporary file first, and the file is only replaced once all
+// of the contents have been written, so the file is never left partially written.
+func WriteFileAtomically(fileName string, writeContents func(file io.Writer) error) (err error) {
+	return FileSystemProxy.WriteFileAtomically(fileName, writeContents)
+}
+
 func RenameFile(fileName string, newFileName string) (err error) {
 	return FileSystemProxy.RenameFile(fileName, newFileName)
 }
@@ -26,6 +40,10 @@ func RemoveFile(fileName string) (err error) {
 	return FileSystemProxy.RemoveFile(fileName)
 }
 
+func StatFile(fileName string) (fileInfo os.FileInfo, err error) {
+	return FileSystemProxy.StatFile(fileName)
+}
+
 func UserHomeDir() (string, error) {
 	return FileSystemProxy.UserHomeDir()
 }
@@ -38,6 +56,26 @@ func DoesFileExist(filePath string) bool {
 	return FileSystemProxy.DoesFileExist(filePath)
 }
 
+func ReadFile(fileName string) (contents []byte, err error) {
+
+	file, err := FileSystemProxy.OpenFile(fileName)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	contents, err = ioutil.ReadAll(file)
+	return
+}
+
+func WriteFile(fileName string, contents []byte) (err error) {
+	err = FileSystemProxy.WriteFileAtomically(fileName, func(file io.Writer) (err error) {
+		_, err = io.Copy(file, bytes.NewReader(contents))
+		return
+	})
+	return
+}
+
 func CopyFile(sourceFileName string, targetFileName string) (err error) {
 
 	source, err := FileSystemProxy.OpenFile(sourceFileName)
@@ -46,17 +84,39 @@ func CopyFile(sourceFileName string, targetFileName string) (err error) {
 	}
 	defer source.Close()
 
-	destination, err := FileSystemProxy.CreateFile(targetFileName)
+	err = FileSystemProxy.WriteFileAtomically(targetFileName, func(destination io.Writer) (err error) {
+		_, err = io.Copy(destination, source)
+		return
+	})
+	return
+}
+
+// Compresses the given file into a new file with the same name plus ".gz".
+func GzipFile(fileName string) (err error) {
+	err = CompressFile(fileName, fileName+".gz", GzipCodec)
+	return
+}
+
+// Decompresses the given ".gz" file into a new file with the same name minus ".gz",
+// and then removes the compressed file.
+func GunzipFile(compressedFileName string) (err error) {
+
+	uncompressedFileName := strings.TrimSuffix(compressedFileName, ".gz")
+	err = DecompressFile(compressedFileName, uncompressedFileName, GzipCodec)
+	if err != nil {
+		return
+	}
+
+	err = FileSystemProxy.RemoveFile(compressedFileName)
 	if err != nil {
 		return
 	}
-	defer destination.Close()
 
-	_, err = io.Copy(destination, source)
 	return
 }
 
-func GzipFile(fileName string) (err error) {
+// Compresses the contents of the given file into compressedFileName using the given codec.
+func CompressFile(fileName string, compressedFileName string, codec CompressionCodec) (err error) {
 
 	uncompressedFile, err := FileSystemProxy.OpenFile(fileName)
 	if err != nil {
@@ -64,28 +124,51 @@ func GzipFile(fileName string) (err error) {
 	}
 	defer uncompressedFile.Close()
 
-	compressedFileName := fileName + ".gz"
-	compressedFile, err := FileSystemProxy.CreateFile(compressedFileName)
+	err = FileSystemProxy.WriteFileAtomically(compressedFileName, func(compressedFile io.Writer) (err error) {
+
+		compressedWriter := codec.NewWriter(compressedFile)
+
+		// Copy the uncompressedFile contents to the compressedWriter, then immediately Close() the compressedWriter.
+		// This flushes all compressed contents and any footer to the compressedFile without closing
+		// the compressedFile. If compressedWriter.Close() is deferred, the footer might not be written.
+		_, err = io.Copy(compressedWriter, uncompressedFile)
+		closeErr := compressedWriter.Close()
+		if err != nil {
+			return
+		}
+		err = closeErr
+
+		return
+
+	})
+
+	return
+}
+
+// Decompresses the contents of the given file into uncompressedFileName using the given codec.
+func DecompressFile(compressedFileName string, uncompressedFileName string, codec CompressionCodec) (err error) {
+
+	compressedFile, err := FileSystemProxy.OpenFile(compressedFileName)
 	if err != nil {
 		return
 	}
 	defer compressedFile.Close()
 
-	gzipWriter := gzip.NewWriter(compressedFile)
-
-	// Copy the uncompressedFile contents to the gzipWriter, then immediately Close() the gzipWriter.
-	// This flushes all compressed contents and the GZIP footer to the compressedFile without closing
-	// the compressedFile. If gzipWriter.Close() is deferred, the GZIP footer might not be written.
-	_, err = io.Copy(gzipWriter, uncompressedFile)
-	gzipWriter.Close()
+	compressedReader, err := codec.NewReader(compressedFile)
 	if err != nil {
 		return
 	}
+	defer compressedReader.Close()
 
+	err = FileSystemProxy.WriteFileAtomically(uncompressedFileName, func(uncompressedFile io.Writer) (err error) {
+		_, err = io.Copy(uncompressedFile, compressedReader)
+		return
+	})
 	return
 }
 
-func GunzipFile(compressedFileName string) (err error) {
+// Returns the decompressed contents of the given file, using the given codec.
+func ReadCompressedFile(compressedFileName string, codec CompressionCodec) (contents []byte, err error) {
 
 	compressedFile, err := FileSystemProxy.OpenFile(compressedFileName)
 	if err != nil {
@@ -93,30 +176,486 @@ func GunzipFile(compressedFileName string) (err error) {
 	}
 	defer compressedFile.Close()
 
-	gzipReader, err := gzip.NewReader(compressedFile)
+	compressedReader, err := codec.NewReader(compressedFile)
 	if err != nil {
 		return
 	}
-	defer gzipReader.Close()
+	defer compressedReader.Close()
 
-	uncompressedFileName := strings.TrimSuffix(compressedFileName, ".gz")
-	uncompressedFile, err := FileSystemProxy.CreateFile(uncompressedFileName)
+	contents, err = ioutil.ReadAll(compressedReader)
+	return
+}
+
+// The modification time given to every file in archives created by ZipDirectory.
+// This is the earliest time that can be stored in a zip archive.
+var ZipFileModifiedTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Extracts all files in the given zip archive into the given directory.
+func UnzipFile(zipFileName string, directoryName string) (err error) {
+	err = readZipFileEach(zipFileName, func(zippedFileName string, contents []byte) error {
+		return WriteFile(JoinFilePath(directoryName, zippedFileName), contents)
+	})
+	return
+}
+
+// Returns the contents of all files in the given zip archive, indexed by their
+// slash-separated names within the archive.
+func ReadZipFile(zipFileName string) (zippedFileContents map[string][]byte, err error) {
+	zippedFileContents = make(map[string][]byte)
+	err = readZipFileEach(zipFileName, getArchivedFileContentsCollector(zippedFileContents))
+	if err != nil {
+		zippedFileContents = nil
+	}
+	return
+}
+
+// Calls readFile with the slash-separated name and contents of each file in the given zip archive, one at a time.
+func readZipFileEach(zipFileName string, readFile func(zippedFileName string, contents []byte) error) (err error) {
+
+	file, err := FileSystemProxy.OpenFile(zipFileName)
 	if err != nil {
 		return
 	}
-	defer uncompressedFile.Close()
+	defer file.Close()
+
+	// Zip archives are read from the end, so the file must support random access.
+	// Files that don't, such as in unit tests, are read into memory instead.
+	fileReaderAt, ok := file.(io.ReaderAt)
+	var fileSize int64
+	if ok {
+		var fileInfo os.FileInfo
+		fileInfo, err = FileSystemProxy.StatFile(zipFileName)
+		if err != nil {
+			return
+		}
+		fileSize = fileInfo.Size()
+	} else {
+		var contents []byte
+		contents, err = ioutil.ReadAll(file)
+		if err != nil {
+			return
+		}
+		fileReaderAt = bytes.NewReader(contents)
+		fileSize = int64(len(contents))
+	}
 
-	_, err = io.Copy(uncompressedFile, gzipReader)
+	zipReader, err := zip.NewReader(fileReaderAt, fileSize)
 	if err != nil {
 		return
 	}
 
-	err = FileSystemProxy.RemoveFile(compressedFileName)
+	for _, zippedFile := range zipReader.File {
+
+		if strings.HasSuffix(zippedFile.Name, "/") {
+			continue
+		}
+
+		var cleanedName string
+		cleanedName, err = getCleanedArchivedFileName(zipFileName, zippedFile.Name)
+		if err != nil {
+			return
+		}
+
+		var zippedFileReader io.ReadCloser
+		zippedFileReader, err = zippedFile.Open()
+		if err != nil {
+			return
+		}
+
+		var zippedFileContent []byte
+		zippedFileContent, err = ioutil.ReadAll(zippedFileReader)
+		zippedFileReader.Close()
+		if err != nil {
+			return
+		}
+
+		err = readFile(cleanedName, zippedFileContent)
+		if err != nil {
+			return
+		}
+
+	}
+
+	return
+
+}
+
+// Creates a zip archive containing all files in the given directory.
+// The files are sorted by name and given the same modification time,
+// so that zipping the same files always creates the same archive.
+func ZipDirectory(directoryName string, zipFileName string) (err error) {
+
+	zippedFileContents, err := ReadDirectoryAsZipContents(directoryName)
+	if err != nil {
+		return
+	}
+
+	err = WriteZipFile(zipFileName, zippedFileContents)
+	return
+
+}
+
+// Returns the contents of all files in the given directory, indexed by their slash-separated
+// names within the directory, as they would be named in a zip archive of the directory.
+func ReadDirectoryAsZipContents(directoryName string) (zippedFileContents map[string][]byte, err error) {
+
+	fileNames, err := GetAllFileNamesInDirectory(directoryName)
+	if err != nil {
+		return
+	}
+
+	zippedFileContents = make(map[string][]byte)
+	for _, fileName := range fileNames {
+		zippedFileName := filepath.ToSlash(strings.TrimLeft(strings.TrimPrefix(fileName, directoryName), "/"+string(os.PathSeparator)))
+		zippedFileContents[zippedFileName], err = ReadFile(fileName)
+		if err != nil {
+			zippedFileContents = nil
+			return
+		}
+	}
+
+	return
+
+}
+
+// Creates a zip archive containing the given contents, indexed by their slash-separated names within the archive.
+// The files are sorted by name and given the same modification time, so that zipping the same files always
+// creates the same archive.
+func WriteZipFile(zipFileName string, zippedFileContents map[string][]byte) (err error) {
+	err = createZipFile(zipFileName, getSortedArchivedFileContentsWriter(zippedFileContents))
+	return
+}
+
+// Creates a zip archive containing the files added by writeFiles, in the order that they are added.
+func createZipFile(zipFileName string, writeFiles func(writeFile ArchivedFileWriter) error) (err error) {
+
+	err = FileSystemProxy.WriteFileAtomically(zipFileName, func(file io.Writer) (err error) {
+
+		zipWriter := zip.NewWriter(file)
+
+		err = writeFiles(func(zippedFileName string, contents []byte) (err error) {
+
+			zippedFileWriter, err := zipWriter.CreateHeader(
+				&zip.FileHeader{
+					Name:     zippedFileName,
+					Method:   zip.Deflate,
+					Modified: ZipFileModifiedTime,
+				},
+			)
+			if err != nil {
+				return
+			}
+
+			_, err = zippedFileWriter.Write(contents)
+			return
+
+		})
+		if err != nil {
+			return
+		}
+
+		err = zipWriter.Close()
+		return
+
+	})
+
+	return
+
+}
+
+// Calls readFile with the slash-separated name and contents of each file in the given tar archive, one at a time.
+func readTarFileEach(tarFileName string, readFile func(archivedFileName string, contents []byte) error) (err error) {
+
+	file, err := FileSystemProxy.OpenFile(tarFileName)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	tarReader := tar.NewReader(file)
+
+	for {
+
+		var header *tar.Header
+		header, err = tarReader.Next()
+		if err == io.EOF {
+			err = nil
+			return
+		}
+		if err != nil {
+			return
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		var cleanedName string
+		cleanedName, err = getCleanedArchivedFileName(tarFileName, header.Name)
+		if err != nil {
+			return
+		}
+
+		var contents []byte
+		contents, err = ioutil.ReadAll(tarReader)
+		if err != nil {
+			return
+		}
+
+		err = readFile(cleanedName, contents)
+		if err != nil {
+			return
+		}
+
+	}
+
+}
+
+// Creates a tar archive containing the files added by writeFiles, in the order that they are added.
+func createTarFile(tarFileName string, writeFiles func(writeFile ArchivedFileWriter) error) (err error) {
+
+	err = FileSystemProxy.WriteFileAtomically(tarFileName, func(file io.Writer) (err error) {
+
+		tarWriter := tar.NewWriter(file)
+
+		err = writeFiles(func(archivedFileName string, contents []byte) (err error) {
+
+			err = tarWriter.WriteHeader(
+				&tar.Header{
+					Typeflag: tar.TypeReg,
+					Name:     archivedFileName,
+					Mode:     0644,
+					Size:     int64(len(contents)),
+					ModTime:  ZipFileModifiedTime,
+					Format:   tar.FormatPAX,
+				},
+			)
+			if err != nil {
+				return
+			}
+
+			_, err = tarWriter.Write(contents)
+			return
+
+		})
+		if err != nil {
+			return
+		}
+
+		err = tarWriter.Close()
+		return
+
+	})
+
+	return
+
+}
+
+// The file extensions of archives that can be read by ReadArchiveFileEach and created by CreateArchiveFile.
+// Archives compressed with zstd require the zstd command to be installed.
+var ArchiveFileExtensions = []string{".zip", ".tar", ".tar.zst"}
+
+func IsArchiveFileName(fileName string) bool {
+	return getArchiveFileExtension(fileName) != ""
+}
+
+// Calls readFile with the slash-separated name and contents of each file in the given archive, one at a time,
+// so that the contents of all of the files don't need to be held in memory at once.
+// The type of archive is determined by its file extension.
+func ReadArchiveFileEach(archiveFileName string, readFile func(archivedFileName string, contents []byte) error) (err error) {
+
+	switch getArchiveFileExtension(archiveFileName) {
+
+	case ".zip":
+		return readZipFileEach(archiveFileName, readFile)
+
+	case ".tar":
+		return readTarFileEach(archiveFileName, readFile)
+
+	case ".tar.zst":
+		tarFileName := archiveFileName + ".tmp"
+		err = ExecuteShellCommand("zstd", "--decompress", "--quiet", "--force", "-o", tarFileName, archiveFileName)
+		if err != nil {
+			return
+		}
+		defer FileSystemProxy.RemoveFile(tarFileName)
+		return readTarFileEach(tarFileName, readFile)
+
+	default:
+		err = getUnsupportedArchiveFileError(archiveFileName)
+		return
+
+	}
+
+}
+
+// Adds a file with the given slash-separated name and contents to an archive that is being created.
+type ArchivedFileWriter func(archivedFileName string, contents []byte) (err error)
+
+// Creates an archive containing the files added by writeFiles, in the order that they are added. Each file
+// is written to the archive as soon as it is added, so that the contents of all of the files don't need to
+// be held in memory at once. The type of archive is determined by its file extension.
+func CreateArchiveFile(archiveFileName string, writeFiles func(writeFile ArchivedFileWriter) error) (err error) {
+
+	switch getArchiveFileExtension(archiveFileName) {
+
+	case ".zip":
+		return createZipFile(archiveFileName, writeFiles)
+
+	case ".tar":
+		return createTarFile(archiveFileName, writeFiles)
+
+	case ".tar.zst":
+		tarFileName := archiveFileName + ".tmp"
+		err = createTarFile(tarFileName, writeFiles)
+		if err != nil {
+			return
+		}
+		defer FileSystemProxy.RemoveFile(tarFileName)
+		err = ExecuteShellCommand("zstd", "--quiet", "--force", "-o", archiveFileName, tarFileName)
+		return
+
+	default:
+		err = getUnsupportedArchiveFileError(archiveFileName)
+		return
+
+	}
+
+}
+
+func getArchiveFileExtension(archiveFileName string) string {
+	extension := ""
+	for _, archiveFileExtension := range ArchiveFileExtensions {
+		if strings.HasSuffix(strings.ToLower(archiveFileName), archiveFileExtension) && len(archiveFileExtension) > len(extension) {
+			extension = archiveFileExtension
+		}
+	}
+	return extension
+}
+
+func getUnsupportedArchiveFileError(archiveFileName string) error {
+	return errors.New("Unable to read or create '" + archiveFileName + "' because its file type is not supported. Supported file types are: " + strings.Join(ArchiveFileExtensions, ", "))
+}
+
+// Returns the given name of a file within an archive, after checking that it isn't outside of the archive.
+func getCleanedArchivedFileName(archiveFileName string, archivedFileName string) (cleanedName string, err error) {
+	cleanedName = path.Clean(archivedFileName)
+	if !IsValidRelativeFileName(archivedFileName) {
+		err = errors.New("Unable to extract " + archivedFileName + " from " + archiveFileName + " because it is outside of the archive.")
+	}
+	return
+}
+
+// Returns true if the given slash-separated file name is relative, and can't refer to anything outside of the
+// directory that it's relative to. Names containing ".." are rejected, even if they would stay inside the directory.
+func IsValidRelativeFileName(fileName string) bool {
+
+	cleanedName := path.Clean(fileName)
+	if fileName == "" || cleanedName == "." || path.IsAbs(cleanedName) || filepath.IsAbs(fileName) || strings.Contains(fileName, "\\") {
+		return false
+	}
+
+	for _, element := range strings.Split(fileName, "/") {
+		if element == ".." {
+			return false
+		}
+	}
+
+	return true
+
+}
+
+// Returns a function for ReadArchiveFileEach that adds each file's contents to the given map.
+func getArchivedFileContentsCollector(archivedFileContents map[string][]byte) func(archivedFileName string, contents []byte) error {
+	return func(archivedFileName string, contents []byte) error {
+		archivedFileContents[archivedFileName] = contents
+		return nil
+	}
+}
+
+// Returns a function for CreateArchiveFile that adds the given contents to the archive, sorted by name.
+func getSortedArchivedFileContentsWriter(archivedFileContents map[string][]byte) func(writeFile ArchivedFileWriter) error {
+	return func(writeFile ArchivedFileWriter) (err error) {
+		for _, archivedFileName := range getSortedArchivedFileNames(archivedFileContents) {
+			err = writeFile(archivedFileName, archivedFileContents[archivedFileName])
+			if err != nil {
+				return
+			}
+		}
+		return
+	}
+}
+
+func getSortedArchivedFileNames(archivedFileContents map[string][]byte) (archivedFileNames []string) {
+	archivedFileNames = make([]string, 0, len(archivedFileContents))
+	for archivedFileName := range archivedFileContents {
+		archivedFileNames = append(archivedFileNames, archivedFileName)
+	}
+	sort.Strings(archivedFileNames)
+	return
+}
+
+// Returns the names of all files within the given directory and its subdirectories.
+func GetAllFileNamesInDirectory(directoryName string) (fileNames []string, err error) {
+
+	fileNames = make([]string, 0)
+
+	err = FileSystemProxy.WalkFilePath(directoryName, func(fileName string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo != nil && fileInfo.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(fileName, directoryName+"/") || strings.HasPrefix(fileName, directoryName+string(os.PathSeparator)) {
+			fileNames = append(fileNames, fileName)
+		}
+		return nil
+	})
+
 	if err != nil {
+		fileNames = nil
 		return
 	}
 
 	return
+
+}
+
+// Returns the names of all directories whose names end with the given extension.
+func GetAllDirectoryNamesWithExtension(extension string) (directoryNames []string, err error) {
+
+	uniqueDirectoryNames := make(map[string]bool)
+
+	err = FileSystemProxy.WalkFilePath(".", func(fileName string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo != nil && fileInfo.IsDir() && strings.HasSuffix(fileName, "."+extension) {
+			uniqueDirectoryNames[fileName] = true
+		}
+		for i, character := range fileName {
+			if character == '/' || character == os.PathSeparator {
+				if parentDirectoryName := fileName[:i]; strings.HasSuffix(parentDirectoryName, "."+extension) {
+					uniqueDirectoryNames[parentDirectoryName] = true
+				}
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return
+	}
+
+	directoryNames = make([]string, 0, len(uniqueDirectoryNames))
+	for directoryName := range uniqueDirectoryNames {
+		directoryNames = append(directoryNames, directoryName)
+	}
+	sort.Strings(directoryNames)
+
+	return
+
 }
 
 func GetAllFileNamesWithExtension(extension string) (fileNames []string, err error) {
@@ -142,14 +681,47 @@ func GetAllFileNamesWithExtension(extension string) (fileNames []string, err err
 
 }
 
+// Returns the names of all files in the current directory tree that end with any of the given extensions,
+// ignoring case, so that both "Song.RPP" and "song.rpp" match the extension "rpp".
+func GetAllFileNamesWithAnyExtension(extensions ...string) (fileNames []string, err error) {
+
+	fileNames = make([]string, 0)
+
+	err = FileSystemProxy.WalkFilePath(".", func(fileName string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fileInfo != nil && fileInfo.IsDir() {
+			return nil
+		}
+		for _, extension := range extensions {
+			if strings.HasSuffix(strings.ToLower(fileName), "."+strings.ToLower(extension)) {
+				fileNames = append(fileNames, fileName)
+				break
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		fileNames = nil
+		return
+	}
+
+	return
+
+}
+
 // ------------------------------------------------------------------------------
 
 type FileSystemDelegater interface {
 	OpenFile(fileName string) (file io.ReadWriteCloser, err error)
 	CreateFile(fileName string) (file io.ReadWriteCloser, err error)
+	WriteFileAtomically(fileName string, writeContents func(file io.Writer) error) (err error)
 	RenameFile(fileName string, newFileName string) (err error)
 	RemoveFile(fileName string) (err error)
 	WalkFilePath(root string, walkFn filepath.WalkFunc) (err error)
+	StatFile(fileName string) (fileInfo os.FileInfo, err error)
 	UserHomeDir() (string, error)
 	JoinFilePath(elem ...string) string
 	DoesFileExist(filePath string) bool
@@ -164,6 +736,11 @@ func (proxy *fileSystemProxy) OpenFile(fileName string) (file io.ReadWriteCloser
 
 func (proxy *fileSystemProxy) CreateFile(fileName string) (file io.ReadWriteCloser, err error) {
 
+	err = os.MkdirAll(filepath.Dir(fileName), os.ModePerm)
+	if err != nil {
+		return
+	}
+
 	file, err = os.Create(fileName)
 	if err != nil {
 		return
@@ -172,6 +749,73 @@ func (proxy *fileSystemProxy) CreateFile(fileName string) (file io.ReadWriteClos
 	return
 }
 
+func (proxy *fileSystemProxy) WriteFileAtomically(fileName string, writeContents func(file io.Writer) error) (err error) {
+
+	directoryName := filepath.Dir(fileName)
+
+	err = os.MkdirAll(directoryName, os.ModePerm)
+	if err != nil {
+		return
+	}
+
+	// Keep the permissions of the file being replaced. Temporary files are only readable
+	// by their owner, so new files are given the usual permissions instead.
+	var fileMode os.FileMode = 0644
+	if fileInfo, statErr := os.Stat(fileName); statErr == nil {
+		fileMode = fileInfo.Mode().Perm()
+	}
+
+	// The temporary file is created in the same directory, so that it can be renamed
+	// without copying it between file systems.
+	tempFile, err := ioutil.TempFile(directoryName, "."+filepath.Base(fileName)+".tmp-*")
+	if err != nil {
+		return
+	}
+
+	// Remove the temporary file if it didn't replace the file.
+	defer func() {
+		if err != nil {
+			tempFile.Close()
+			os.Remove(tempFile.Name())
+		}
+	}()
+
+	err = writeContents(tempFile)
+	if err != nil {
+		return
+	}
+
+	err = tempFile.Chmod(fileMode)
+	if err != nil {
+		return
+	}
+
+	// Make sure the contents are on disk before the file is replaced.
+	err = tempFile.Sync()
+	if err != nil {
+		return
+	}
+
+	err = tempFile.Close()
+	if err != nil {
+		return
+	}
+
+	err = os.Rename(tempFile.Name(), fileName)
+	if err != nil {
+		return
+	}
+
+	// Make sure the rename itself is on disk. Not all platforms can sync directories, so errors are ignored.
+	if directory, openErr := os.Open(directoryName); openErr == nil {
+		directory.Sync()
+		directory.Close()
+	}
+
+	return
+
+}
+
 func (proxy *fileSystemProxy) RenameFile(fileName string, newFileName string) (err error) {
 
 	err = os.Rename(fileName, newFileName)
@@ -192,6 +836,11 @@ func (proxy *fileSystemProxy) WalkFilePath(root string, walkFn filepath.WalkFunc
 	return
 }
 
+func (proxy *fileSystemProxy) StatFile(fileName string) (fileInfo os.FileInfo, err error) {
+	fileInfo, err = os.Stat(fileName)
+	return
+}
+
 func (proxy *fileSystemProxy) UserHomeDir() (string, error) {
 	return os.UserHomeDir()
 }
@@ -2,6 +2,7 @@ package util_test
 
 import (
 	"errors"
+	"io"
 	"sort"
 	"testing"
 
@@ -162,6 +163,88 @@ func TestCopyFile(t *testing.T) {
 
 }
 
+func TestWriteFileAtomically(t *testing.T) {
+
+	testCases := []*WriteFileAtomicallyTestCase{
+
+		&WriteFileAtomicallyTestCase{
+			description: "Test if an existing file is replaced with all of the written contents.",
+			fileName:    utiltest.GetPlainTextFileBuilder().FilePath,
+			writeContents: func(file io.Writer) (err error) {
+				_, err = file.Write([]byte("new contents"))
+				return
+			},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.GetPlainTextFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.GetPlainTextFileBuilder().
+						SetContentsFromString("new contents").
+						SetWasClosed(true),
+				),
+		},
+
+		&WriteFileAtomicallyTestCase{
+			description:   "Test that an existing file is not changed if there is an error while writing its contents.",
+			fileName:      utiltest.GetPlainTextFileBuilder().FilePath,
+			expectedError: errors.New("The disk is full."),
+			writeContents: func(file io.Writer) (err error) {
+				file.Write([]byte("partial contents"))
+				return errors.New("The disk is full.")
+			},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.GetPlainTextFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.GetPlainTextFileBuilder(),
+				),
+		},
+
+		&WriteFileAtomicallyTestCase{
+			description:   "Test that a new file is not created if there is an error while writing its contents.",
+			fileName:      "new-file",
+			expectedError: errors.New("The disk is full."),
+			writeContents: func(file io.Writer) (err error) {
+				file.Write([]byte("partial contents"))
+				return errors.New("The disk is full.")
+			},
+			mockExecutionEnvironmentBuilder:          utiltest.NewMockExecutionEnvironmentBuilder(),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder(),
+		},
+
+		&WriteFileAtomicallyTestCase{
+			description:   "Test if error is correctly raised when unable to create a file.",
+			fileName:      "new-file",
+			expectedError: utiltest.DefaultCreateFileError,
+			writeContents: func(file io.Writer) (err error) {
+				_, err = file.Write([]byte("new contents"))
+				return
+			},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetUseDefaultCreateFileError(true),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
 func TestGzipFile(t *testing.T) {
 
 	testCases := []*GzipFileTestCase{
@@ -392,6 +475,164 @@ func TestGunzipFile(t *testing.T) {
 
 }
 
+func TestUnzipFile(t *testing.T) {
+
+	testCases := []*UnzipFileTestCase{
+
+		&UnzipFileTestCase{
+			description:   "Test that all files in the archive are extracted into the directory.",
+			fileName:      utiltest.GetFakeStudioOneSongFileBuilder().FilePath,
+			directoryName: "fake-studio-one-song.song.unzipped",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.GetFakeStudioOneSongFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.GetFakeStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder().
+						SetWasClosed(true),
+				),
+		},
+
+		&UnzipFileTestCase{
+			description:   "Test that files outside of the archive's directory are not extracted.",
+			fileName:      "unsafe.zip",
+			directoryName: "unsafe.zip.unzipped",
+			expectedError: errors.New("Unable to extract ../unsafe.txt from unsafe.zip because it is outside of the archive."),
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.NewMockFileBuilder().
+								SetFilePath("unsafe.zip").
+								SetContentsFromBytes(utiltest.GetZipFileContents(map[string]string{"../unsafe.txt": "unsafe"})),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.NewMockFileBuilder().
+						SetFilePath("unsafe.zip").
+						SetContentsFromBytes(utiltest.GetZipFileContents(map[string]string{"../unsafe.txt": "unsafe"})).
+						SetWasClosed(true),
+				),
+		},
+
+		&UnzipFileTestCase{
+			description:                              "Test if error is properly raised if the archive does not exist.",
+			fileName:                                 "does-not-exist",
+			directoryName:                            "does-not-exist.unzipped",
+			expectedError:                            errors.New("Unable to open file does-not-exist"),
+			mockExecutionEnvironmentBuilder:          utiltest.NewMockExecutionEnvironmentBuilder(),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestZipDirectory(t *testing.T) {
+
+	testCases := []*ZipDirectoryTestCase{
+
+		&ZipDirectoryTestCase{
+			description:   "Test that all files in the directory are zipped, in order, with a fixed modification time.",
+			directoryName: "fake-studio-one-song.song.unzipped",
+			fileName:      utiltest.GetFakeStudioOneSongFileBuilder().FilePath,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+							utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+							utiltest.GetPlainTextFileBuilder(),
+						),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder().
+						SetWasClosed(true),
+					utiltest.GetPlainTextFileBuilder(),
+					utiltest.GetFakeStudioOneSongFileBuilder().
+						SetWasClosed(true),
+				),
+		},
+
+		&ZipDirectoryTestCase{
+			description:   "Test that errors from filepath.Walk() are properly raised.",
+			directoryName: "fake-studio-one-song.song.unzipped",
+			fileName:      utiltest.GetFakeStudioOneSongFileBuilder().FilePath,
+			expectedError: utiltest.DefaultWalkFilePathError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockFileSystemDelegaterBuilder(
+					utiltest.NewMockFileSystemDelegaterBuilder().
+						SetMockFileBuilders(
+							utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+						).
+						SetUseDefaultWalkFilePathError(true),
+				),
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetMockFileBuilders(
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestIsValidRelativeFileName(t *testing.T) {
+
+	validFileNames := []string{"song.als", "Drums/kick.wav", "./Drums/kick.wav", "Drums..old/kick.wav"}
+	for _, fileName := range validFileNames {
+		assert.True(t, util.IsValidRelativeFileName(fileName), fileName)
+	}
+
+	invalidFileNames := []string{"", ".", "/etc/passwd", "..", "../song.als", "Drums/../../song.als", "Drums/../kick.wav", "Drums\\kick.wav"}
+	for _, fileName := range invalidFileNames {
+		assert.False(t, util.IsValidRelativeFileName(fileName), fileName)
+	}
+
+}
+
+func TestGetAllDirectoryNamesWithExtension(t *testing.T) {
+
+	_ = utiltest.NewMockExecutionEnvironmentBuilder().
+		SetMockFileSystemDelegaterBuilder(
+			utiltest.NewMockFileSystemDelegaterBuilder().
+				SetMockFileBuilders(
+					utiltest.GetFakeUnzippedStudioOneSongFileBuilder(),
+					utiltest.GetFakeUnzippedStudioOneMetaInfoFileBuilder(),
+					utiltest.GetPlainTextFileBuilder().
+						SetFilePath("path/to/other.song.unzipped/metainfo.xml"),
+					utiltest.GetPlainTextFileBuilder().
+						SetFilePath("not-a-directory.song.unzipped"),
+					utiltest.GetPlainTextFileBuilder(),
+				),
+		).
+		BuildAndInit()
+
+	// Test that only directories with the correct extension are returned, in order.
+	actualDirectoryNames, actualError := util.GetAllDirectoryNamesWithExtension("song.unzipped")
+	assert.Nil(t, actualError)
+	assert.Exactly(t, []string{"fake-studio-one-song.song.unzipped", "path/to/other.song.unzipped"}, actualDirectoryNames)
+
+}
+
 func TestGetAllFileNamesWithExtension(t *testing.T) {
 
 	testCases := []*GetAllFileNamesWithExtensionTestCase{
@@ -445,6 +686,32 @@ func TestGetAllFileNamesWithExtension(t *testing.T) {
 
 }
 
+func TestGetAllFileNamesWithAnyExtension(t *testing.T) {
+
+	utiltest.NewMockExecutionEnvironmentBuilder().
+		SetMockFileSystemDelegaterBuilder(
+			utiltest.NewMockFileSystemDelegaterBuilder().
+				SetMockFileBuilders(
+					utiltest.GetPlainTextFileBuilder().
+						SetFilePath("Song.RPP"),
+					utiltest.GetPlainTextFileBuilder().
+						SetFilePath("/path/to/song.rpp"),
+					utiltest.GetPlainTextFileBuilder().
+						SetFilePath("song.mmpz"),
+					utiltest.GetPlainTextFileBuilder().
+						SetFilePath("file1.txt"),
+				),
+		).
+		BuildAndInit()
+
+	// Test that extensions are matched ignoring case, and that each file is only returned once.
+	actualFileNames, actualError := util.GetAllFileNamesWithAnyExtension("rpp", "RPP", "mmpz")
+	sort.Strings(actualFileNames)
+	assert.Exactly(t, []string{"/path/to/song.rpp", "Song.RPP", "song.mmpz"}, actualFileNames)
+	assert.Nil(t, actualError)
+
+}
+
 // ------------------------------------------------------------------------------
 
 type CopyFileTestCase struct {
@@ -470,6 +737,29 @@ func (testCase *CopyFileTestCase) Run(t *testing.T) {
 
 // ------------------------------------------------------------------------------
 
+type WriteFileAtomicallyTestCase struct {
+	description                              string
+	fileName                                 string
+	writeContents                            func(file io.Writer) error
+	expectedError                            error
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *WriteFileAtomicallyTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	actualError := util.WriteFileAtomically(testCase.fileName, testCase.writeContents)
+	assert.Exactly(t, testCase.expectedError, actualError)
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
+
+// ------------------------------------------------------------------------------
+
 type GzipFileTestCase struct {
 	description                              string
 	fileName                                 string
@@ -538,3 +828,49 @@ func (testCase *GetAllFileNamesWithExtensionTestCase) Run(t *testing.T) {
 	assert.Exactly(t, testCase.expectedFileNames, actualFileNames)
 
 }
+
+// ------------------------------------------------------------------------------
+
+type UnzipFileTestCase struct {
+	description                              string
+	fileName                                 string
+	directoryName                            string
+	expectedError                            error
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *UnzipFileTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	actualError := util.UnzipFile(testCase.fileName, testCase.directoryName)
+	assert.Exactly(t, testCase.expectedError, actualError)
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
+
+// ------------------------------------------------------------------------------
+
+type ZipDirectoryTestCase struct {
+	description                              string
+	directoryName                            string
+	fileName                                 string
+	expectedError                            error
+	mockExecutionEnvironmentBuilder          *utiltest.MockExecutionEnvironmentBuilder
+	expectedExecutionEnvironmentStateBuilder *utiltest.MockExecutionEnvironmentStateBuilder
+}
+
+func (testCase *ZipDirectoryTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := testCase.mockExecutionEnvironmentBuilder.BuildAndInit()
+
+	actualError := util.ZipDirectory(testCase.directoryName, testCase.fileName)
+	assert.Exactly(t, testCase.expectedError, actualError)
+
+	expectedExecutionEnvironmentState := testCase.expectedExecutionEnvironmentStateBuilder.Build()
+	mockExecutionEnvironment.GetCurrentState().AssertEquals(t, expectedExecutionEnvironmentState, testCase.description)
+
+}
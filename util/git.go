@@ -1,5 +1,7 @@
 package util
 
+import "strings"
+
 var GitManagerFactory GitManagerCreator = NewGitShellCommandProxyCreator()
 
 func NewGitManager(repoFilePath string) GitManager {
@@ -31,7 +33,18 @@ type GitManager interface {
 	Add(args ...string) (err error)
 	Commit(args ...string) (err error)
 	Checkout(args ...string) (err error)
+	Stash(args ...string) (err error)
+	Worktree(args ...string) (err error)
+	Tag(args ...string) (err error)
 	RevParse(args ...string) (stdout string, err error)
+	CatFileContents(args ...string) (contents []byte, err error)
+	CatFileBatch(objectNames []string, args ...string) (stdout []byte, err error)
+	Diff(args ...string) (stdout string, err error)
+	DiffTree(args ...string) (stdout string, err error)
+	Log(args ...string) (stdout string, err error)
+	LsFiles(args ...string) (stdout string, err error)
+	Status(args ...string) (stdout string, err error)
+	Config(args ...string) (err error)
 	LfsInstall() (err error)
 	LfsTrack(args ...string) (err error)
 	AddAllAndCommit(commitMessage string) (err error)
@@ -61,11 +74,87 @@ func (proxy *gitShellCommandProxy) Checkout(args ...string) (err error) {
 	return
 }
 
+func (proxy *gitShellCommandProxy) Stash(args ...string) (err error) {
+	err = proxy.executeGitShellCommand("stash", args...)
+	return
+}
+
+func (proxy *gitShellCommandProxy) Worktree(args ...string) (err error) {
+	err = proxy.executeGitShellCommand("worktree", args...)
+	return
+}
+
+func (proxy *gitShellCommandProxy) Tag(args ...string) (err error) {
+	err = proxy.executeGitShellCommand("tag", args...)
+	return
+}
+
 func (proxy *gitShellCommandProxy) RevParse(args ...string) (stdout string, err error) {
 	stdout, err = proxy.executeGitShellCommandAndReturnOutput("rev-parse", args...)
 	return
 }
 
+// Runs 'git cat-file' and returns only stdout, as bytes, so that the contents of binary files,
+// such as audio samples, aren't corrupted by any messages that git writes to stderr.
+func (proxy *gitShellCommandProxy) CatFileContents(args ...string) (contents []byte, err error) {
+	commandArgs := append(
+		[]string{
+			"-C",
+			proxy.RepositoryDirectoryPath,
+			"cat-file",
+		},
+		args...,
+	)
+	contents, err = ExecuteShellCommandAndReturnStdout("git", commandArgs...)
+	return
+}
+
+// Runs 'git cat-file' with the given object names written to stdin, one per line, such as with '--batch-check',
+// so that any number of objects are read by a single git process.
+func (proxy *gitShellCommandProxy) CatFileBatch(objectNames []string, args ...string) (stdout []byte, err error) {
+	commandArgs := append(
+		[]string{
+			"-C",
+			proxy.RepositoryDirectoryPath,
+			"cat-file",
+		},
+		args...,
+	)
+	stdin := []byte(strings.Join(objectNames, "\n") + "\n")
+	stdout, err = ExecuteShellCommandWithStdinAndReturnStdout(stdin, "git", commandArgs...)
+	return
+}
+
+func (proxy *gitShellCommandProxy) Diff(args ...string) (stdout string, err error) {
+	stdout, err = proxy.executeGitShellCommandAndReturnOutput("diff", args...)
+	return
+}
+
+func (proxy *gitShellCommandProxy) DiffTree(args ...string) (stdout string, err error) {
+	stdout, err = proxy.executeGitShellCommandAndReturnOutput("diff-tree", args...)
+	return
+}
+
+func (proxy *gitShellCommandProxy) Log(args ...string) (stdout string, err error) {
+	stdout, err = proxy.executeGitShellCommandAndReturnOutput("log", args...)
+	return
+}
+
+func (proxy *gitShellCommandProxy) LsFiles(args ...string) (stdout string, err error) {
+	stdout, err = proxy.executeGitShellCommandAndReturnOutput("ls-files", args...)
+	return
+}
+
+func (proxy *gitShellCommandProxy) Status(args ...string) (stdout string, err error) {
+	stdout, err = proxy.executeGitShellCommandAndReturnOutput("status", args...)
+	return
+}
+
+func (proxy *gitShellCommandProxy) Config(args ...string) (err error) {
+	err = proxy.executeGitShellCommand("config", args...)
+	return
+}
+
 func (proxy *gitShellCommandProxy) LfsInstall() (err error) {
 	err = proxy.executeGitShellCommand("lfs", "install")
 	return
@@ -16,6 +16,14 @@ const (
 	commit
 	checkout
 	revParse
+	diff
+	lsFiles
+	catFileContents
+	catFileBatch
+	status
+	stash
+	worktree
+	config
 	lfsInstall
 	lfsTrack
 	addAllAndCommit
@@ -352,6 +360,515 @@ func TestRevParse(t *testing.T) {
 
 }
 
+func TestDiff(t *testing.T) {
+
+	testCases := []*GitManagerTestCase{
+
+		&GitManagerTestCase{
+			description:            "Test that the correct 'git diff' shell command is invoked.",
+			gitManagerRepoFilePath: ".",
+			methodType:             diff,
+			gitManagerMethodArgs:   []string{"--name-only", "HEAD~1", "HEAD"},
+			expectedStdout:         "song.als.xml",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Stdout: "song.als.xml",
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . diff --name-only HEAD~1 HEAD",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Stdout: "song.als.xml",
+					},
+				),
+		},
+
+		&GitManagerTestCase{
+			description:            "Test that any error from running 'git diff' is correctly raised.",
+			gitManagerRepoFilePath: ".",
+			methodType:             diff,
+			gitManagerMethodArgs:   []string{"--name-only", "HEAD~1", "HEAD"},
+			expectedError:          utiltest.DefaultDiffError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Err: utiltest.DefaultDiffError,
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . diff --name-only HEAD~1 HEAD",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Err: utiltest.DefaultDiffError,
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestLsFiles(t *testing.T) {
+
+	testCases := []*GitManagerTestCase{
+
+		&GitManagerTestCase{
+			description:            "Test that the correct 'git ls-files' shell command is invoked.",
+			gitManagerRepoFilePath: ".",
+			methodType:             lsFiles,
+			gitManagerMethodArgs:   []string{"--others", "--exclude-standard"},
+			expectedStdout:         "Samples/kick.wav",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Stdout: "Samples/kick.wav",
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . ls-files --others --exclude-standard",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Stdout: "Samples/kick.wav",
+					},
+				),
+		},
+
+		&GitManagerTestCase{
+			description:            "Test that any error from running 'git ls-files' is correctly raised.",
+			gitManagerRepoFilePath: ".",
+			methodType:             lsFiles,
+			gitManagerMethodArgs:   []string{"--others", "--exclude-standard"},
+			expectedError:          utiltest.DefaultLsFilesError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Err: utiltest.DefaultLsFilesError,
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . ls-files --others --exclude-standard",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Err: utiltest.DefaultLsFilesError,
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestCatFileContents(t *testing.T) {
+
+	testCases := []*GitManagerTestCase{
+
+		&GitManagerTestCase{
+			description:            "Test that the correct 'git cat-file' shell command is invoked, and that its stdout is returned.",
+			gitManagerRepoFilePath: "/home/testuser/library",
+			methodType:             catFileContents,
+			gitManagerMethodArgs:   []string{"--filters", "HEAD:Drums/kick.wav"},
+			expectedStdout:         "kick",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Stdout: "kick",
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C /home/testuser/library cat-file --filters HEAD:Drums/kick.wav",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Stdout: "kick",
+					},
+				),
+		},
+
+		&GitManagerTestCase{
+			description:            "Test that any error from running 'git cat-file' is correctly raised.",
+			gitManagerRepoFilePath: "/home/testuser/library",
+			methodType:             catFileContents,
+			gitManagerMethodArgs:   []string{"--filters", "HEAD:Drums/kick.wav"},
+			expectedError:          utiltest.DefaultCatFileError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Err: utiltest.DefaultCatFileError,
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C /home/testuser/library cat-file --filters HEAD:Drums/kick.wav",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Err: utiltest.DefaultCatFileError,
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestCatFileBatch(t *testing.T) {
+
+	testCases := []*GitManagerTestCase{
+
+		&GitManagerTestCase{
+			description:            "Test that the correct 'git cat-file' shell command is invoked with the object names written to stdin, and that its stdout is returned.",
+			gitManagerRepoFilePath: "/home/testuser/library",
+			methodType:             catFileBatch,
+			gitManagerMethodArgs:   []string{"aaaaa", "bbbbb"},
+			expectedStdout:         "aaaaa blob 130\nbbbbb blob 2000000\n",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Stdout: "aaaaa blob 130\nbbbbb blob 2000000\n",
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C /home/testuser/library cat-file --batch-check aaaaa bbbbb",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Stdout: "aaaaa blob 130\nbbbbb blob 2000000\n",
+					},
+				),
+		},
+
+		&GitManagerTestCase{
+			description:            "Test that any error from running 'git cat-file' with objects written to stdin is correctly raised.",
+			gitManagerRepoFilePath: "/home/testuser/library",
+			methodType:             catFileBatch,
+			gitManagerMethodArgs:   []string{"aaaaa"},
+			expectedError:          utiltest.DefaultCatFileError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Err: utiltest.DefaultCatFileError,
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C /home/testuser/library cat-file --batch-check aaaaa",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Err: utiltest.DefaultCatFileError,
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestStatus(t *testing.T) {
+
+	testCases := []*GitManagerTestCase{
+
+		&GitManagerTestCase{
+			description:            "Test that the correct 'git status' shell command is invoked.",
+			gitManagerRepoFilePath: ".",
+			methodType:             status,
+			gitManagerMethodArgs:   []string{"--porcelain"},
+			expectedStdout:         "?? kick.wav",
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Stdout: "?? kick.wav",
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . status --porcelain",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Stdout: "?? kick.wav",
+					},
+				),
+		},
+
+		&GitManagerTestCase{
+			description:            "Test that any error from running 'git status' is correctly raised.",
+			gitManagerRepoFilePath: ".",
+			methodType:             status,
+			gitManagerMethodArgs:   []string{"--porcelain"},
+			expectedError:          utiltest.DefaultStatusError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Err: utiltest.DefaultStatusError,
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . status --porcelain",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Err: utiltest.DefaultStatusError,
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestStash(t *testing.T) {
+
+	testCases := []*GitManagerTestCase{
+
+		&GitManagerTestCase{
+			description:            "Test that the correct 'git stash' shell command is invoked.",
+			gitManagerRepoFilePath: ".",
+			methodType:             stash,
+			gitManagerMethodArgs:   []string{"push", "--include-untracked"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . stash push --include-untracked",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{},
+				),
+		},
+
+		&GitManagerTestCase{
+			description:            "Test that any error from running 'git stash' is correctly raised.",
+			gitManagerRepoFilePath: ".",
+			methodType:             stash,
+			gitManagerMethodArgs:   []string{"push", "--include-untracked"},
+			expectedError:          utiltest.DefaultStashError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Err: utiltest.DefaultStashError,
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . stash push --include-untracked",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Err: utiltest.DefaultStashError,
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestWorktree(t *testing.T) {
+
+	testCases := []*GitManagerTestCase{
+
+		&GitManagerTestCase{
+			description:            "Test that the correct 'git worktree' shell command is invoked.",
+			gitManagerRepoFilePath: ".",
+			methodType:             worktree,
+			gitManagerMethodArgs:   []string{"add", "--detach", "../worktree", "HEAD~1"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . worktree add --detach ../worktree HEAD~1",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{},
+				),
+		},
+
+		&GitManagerTestCase{
+			description:            "Test that any error from running 'git worktree' is correctly raised.",
+			gitManagerRepoFilePath: ".",
+			methodType:             worktree,
+			gitManagerMethodArgs:   []string{"add", "--detach", "../worktree", "HEAD~1"},
+			expectedError:          utiltest.DefaultWorktreeError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Err: utiltest.DefaultWorktreeError,
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . worktree add --detach ../worktree HEAD~1",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Err: utiltest.DefaultWorktreeError,
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestConfig(t *testing.T) {
+
+	testCases := []*GitManagerTestCase{
+
+		&GitManagerTestCase{
+			description:            "Test that the correct 'git config' shell command is invoked.",
+			gitManagerRepoFilePath: ".",
+			methodType:             config,
+			gitManagerMethodArgs:   []string{"merge.mppm.driver", "mppm merge-driver"},
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Stdout: "",
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . config merge.mppm.driver mppm merge-driver",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Stdout: "",
+					},
+				),
+		},
+
+		&GitManagerTestCase{
+			description:            "Test that any error from running 'git config' is correctly raised.",
+			gitManagerRepoFilePath: ".",
+			methodType:             config,
+			gitManagerMethodArgs:   []string{"merge.mppm.driver", "mppm merge-driver"},
+			expectedError:          utiltest.DefaultConfigError,
+			mockExecutionEnvironmentBuilder: utiltest.NewMockExecutionEnvironmentBuilder().
+				SetMockShellCommandDelegaterBuilder(
+					utiltest.NewMockShellCommandDelegaterBuilder().
+						SetOutputSequence(
+							&utiltest.MockShellCommandOutput{
+								Err: utiltest.DefaultConfigError,
+							},
+						),
+				),
+
+			expectedExecutionEnvironmentStateBuilder: utiltest.NewMockExecutionEnvironmentStateBuilder().
+				SetShellCommandDelegaterInputHistory(
+					"git -C . config merge.mppm.driver mppm merge-driver",
+				).
+				SetShellCommandDelegaterOutputHistory(
+					&utiltest.MockShellCommandOutput{
+						Err: utiltest.DefaultConfigError,
+					},
+				),
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
 func TestLfsInstall(t *testing.T) {
 
 	testCases := []*GitManagerTestCase{
@@ -519,6 +1036,26 @@ func (testCase *GitManagerTestCase) Run(t *testing.T) {
 		actualError = gitManager.Checkout(testCase.gitManagerMethodArgs...)
 	case revParse:
 		actualStdout, actualError = gitManager.RevParse(testCase.gitManagerMethodArgs...)
+	case diff:
+		actualStdout, actualError = gitManager.Diff(testCase.gitManagerMethodArgs...)
+	case lsFiles:
+		actualStdout, actualError = gitManager.LsFiles(testCase.gitManagerMethodArgs...)
+	case catFileContents:
+		var actualContents []byte
+		actualContents, actualError = gitManager.CatFileContents(testCase.gitManagerMethodArgs...)
+		actualStdout = string(actualContents)
+	case catFileBatch:
+		var actualContents []byte
+		actualContents, actualError = gitManager.CatFileBatch(testCase.gitManagerMethodArgs, "--batch-check")
+		actualStdout = string(actualContents)
+	case status:
+		actualStdout, actualError = gitManager.Status(testCase.gitManagerMethodArgs...)
+	case stash:
+		actualError = gitManager.Stash(testCase.gitManagerMethodArgs...)
+	case worktree:
+		actualError = gitManager.Worktree(testCase.gitManagerMethodArgs...)
+	case config:
+		actualError = gitManager.Config(testCase.gitManagerMethodArgs...)
 	case lfsInstall:
 		actualError = gitManager.LfsInstall()
 	case lfsTrack:
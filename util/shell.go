@@ -1,7 +1,10 @@
 package util
 
 import (
+	"bytes"
+	"errors"
 	"os/exec"
+	"strings"
 )
 
 var ShellProxy ShellCommandDelegater = &shellProxy{}
@@ -14,11 +17,21 @@ func ExecuteShellCommandAndReturnOutput(commandName string, args ...string) (std
 	return ShellProxy.ExecuteShellCommandAndReturnOutput(commandName, args...)
 }
 
+func ExecuteShellCommandAndReturnStdout(commandName string, args ...string) (stdout []byte, err error) {
+	return ShellProxy.ExecuteShellCommandAndReturnStdout(commandName, args...)
+}
+
+func ExecuteShellCommandWithStdinAndReturnStdout(stdin []byte, commandName string, args ...string) (stdout []byte, err error) {
+	return ShellProxy.ExecuteShellCommandWithStdinAndReturnStdout(stdin, commandName, args...)
+}
+
 // ------------------------------------------------------------------------------
 
 type ShellCommandDelegater interface {
 	ExecuteShellCommand(commandName string, args ...string) (err error)
 	ExecuteShellCommandAndReturnOutput(commandName string, args ...string) (stdout string, err error)
+	ExecuteShellCommandAndReturnStdout(commandName string, args ...string) (stdout []byte, err error)
+	ExecuteShellCommandWithStdinAndReturnStdout(stdin []byte, commandName string, args ...string) (stdout []byte, err error)
 }
 
 type shellProxy struct{}
@@ -38,3 +51,24 @@ func (proxy *shellProxy) ExecuteShellCommandAndReturnOutput(commandName string,
 	}
 	return
 }
+
+// Unlike ExecuteShellCommandAndReturnOutput, only stdout is returned, so that binary output isn't
+// corrupted by any messages that the command writes to stderr. Those messages are returned as the error instead.
+func (proxy *shellProxy) ExecuteShellCommandAndReturnStdout(commandName string, args ...string) (stdout []byte, err error) {
+	stdout, err = exec.Command(commandName, args...).Output()
+	if exitError, ok := err.(*exec.ExitError); ok && len(exitError.Stderr) > 0 {
+		err = errors.New(strings.TrimSpace(string(exitError.Stderr)))
+	}
+	return
+}
+
+// Same as ExecuteShellCommandAndReturnStdout, but writes the given input to the command's stdin.
+func (proxy *shellProxy) ExecuteShellCommandWithStdinAndReturnStdout(stdin []byte, commandName string, args ...string) (stdout []byte, err error) {
+	command := exec.Command(commandName, args...)
+	command.Stdin = bytes.NewReader(stdin)
+	stdout, err = command.Output()
+	if exitError, ok := err.(*exec.ExitError); ok && len(exitError.Stderr) > 0 {
+		err = errors.New(strings.TrimSpace(string(exitError.Stderr)))
+	}
+	return
+}
@@ -0,0 +1,121 @@
+package util
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+)
+
+// A unit of work that can be run concurrently with other tasks by RunTasks.
+type Task struct {
+	Name string // Usually the name of the file that the task processes. Used in error messages.
+
+	// Printed with the number of completed tasks after the task succeeds.
+	// If empty, no progress is printed for the task.
+	ProgressMessage string
+
+	// Performs the task. Anything printed to the given printer is printed to util.Logger
+	// after all previous tasks have finished, so that the output is always in the same order.
+	Run func(printer WritePrinter) (err error)
+}
+
+// Runs the given tasks using up to the given number of concurrent jobs.
+// If the number of jobs is less than 1, then the number of CPUs is used instead.
+//
+// All tasks are run, even if some of them fail. If any tasks fail, then
+// a TaskErrors is returned containing the errors from every failed task.
+func RunTasks(numberOfJobs int, tasks []*Task) (err error) {
+
+	if numberOfJobs < 1 {
+		numberOfJobs = runtime.NumCPU()
+	}
+
+	outputs := make([]*bytes.Buffer, len(tasks))
+	taskErrors := make([]error, len(tasks))
+	isTaskDone := make([]chan bool, len(tasks))
+	for i := range tasks {
+		outputs[i] = new(bytes.Buffer)
+		isTaskDone[i] = make(chan bool)
+	}
+
+	taskIndexes := make(chan int)
+	go func() {
+		for i := range tasks {
+			taskIndexes <- i
+		}
+		close(taskIndexes)
+	}()
+
+	for job := 0; job < numberOfJobs && job < len(tasks); job++ {
+		go func() {
+			for i := range taskIndexes {
+				printer := &writePrinter{
+					logger: log.New(outputs[i], "", 0),
+				}
+				taskErrors[i] = tasks[i].Run(printer)
+				close(isTaskDone[i])
+			}
+		}()
+	}
+
+	failedTasks := make(TaskErrors, 0)
+
+	// Print the results in order as soon as each task and all previous tasks are done.
+	for i, task := range tasks {
+
+		<-isTaskDone[i]
+
+		if outputs[i].Len() > 0 {
+			Print(outputs[i].String())
+		}
+
+		if taskErrors[i] != nil {
+			failedTasks = append(
+				failedTasks,
+				&TaskError{
+					TaskName: task.Name,
+					Err:      taskErrors[i],
+				},
+			)
+		} else if task.ProgressMessage != "" {
+			Println(fmt.Sprintf("[%d/%d] %s", i+1, len(tasks), task.ProgressMessage))
+		}
+
+	}
+
+	if len(failedTasks) > 0 {
+		err = failedTasks
+	}
+
+	return
+
+}
+
+// ------------------------------------------------------------------------------
+
+// The error from a single task run by RunTasks.
+type TaskError struct {
+	TaskName string
+	Err      error
+}
+
+func (taskError *TaskError) Error() string {
+	return "There was a problem with " + taskError.TaskName + ": " + taskError.Err.Error()
+}
+
+func (taskError *TaskError) Unwrap() error {
+	return taskError.Err
+}
+
+// The errors from every task that failed, in the same order as the tasks were given to RunTasks.
+type TaskErrors []*TaskError
+
+func (taskErrors TaskErrors) Error() string {
+	errorMessages := make([]string, 0, len(taskErrors))
+	for _, taskError := range taskErrors {
+		errorMessages = append(errorMessages, taskError.Error())
+	}
+	return strings.Join(errorMessages, "\n")
+}
@@ -0,0 +1,112 @@
+package util_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stevengt/mppm/util"
+	"github.com/stevengt/mppm/util/utiltest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunTasks(t *testing.T) {
+
+	testCases := []*RunTasksTestCase{
+
+		&RunTasksTestCase{
+			description:    "Test that the output of all tasks is printed in order, followed by their progress.",
+			numberOfJobs:   4,
+			numberOfTasks:  4,
+			expectedOutput: "task-0\n[1/4] Finished task-0\ntask-1\n[2/4] Finished task-1\ntask-2\n[3/4] Finished task-2\ntask-3\n[4/4] Finished task-3\n",
+		},
+
+		&RunTasksTestCase{
+			description:    "Test that the number of CPUs is used if the number of jobs is less than 1.",
+			numberOfJobs:   0,
+			numberOfTasks:  2,
+			expectedOutput: "task-0\n[1/2] Finished task-0\ntask-1\n[2/2] Finished task-1\n",
+		},
+
+		&RunTasksTestCase{
+			description:    "Test that all tasks are run if some of them fail, and that the errors from every failed task are returned.",
+			numberOfJobs:   2,
+			numberOfTasks:  4,
+			failedTasks:    map[int]bool{1: true, 3: true},
+			expectedOutput: "task-0\n[1/4] Finished task-0\ntask-1\ntask-2\n[3/4] Finished task-2\ntask-3\n",
+			expectedError: util.TaskErrors{
+				&util.TaskError{TaskName: "task-1", Err: errors.New("task-1 failed")},
+				&util.TaskError{TaskName: "task-3", Err: errors.New("task-3 failed")},
+			},
+		},
+
+		&RunTasksTestCase{
+			description:    "Test that nothing is printed if there are no tasks.",
+			numberOfJobs:   2,
+			numberOfTasks:  0,
+			expectedOutput: "",
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase.Run(t)
+	}
+
+}
+
+func TestTaskErrors(t *testing.T) {
+	taskErrors := util.TaskErrors{
+		&util.TaskError{TaskName: "song.als", Err: utiltest.DefaultCreateFileError},
+		&util.TaskError{TaskName: "clip.alc", Err: utiltest.DefaultRenameFileError},
+	}
+	assert.Exactly(
+		t,
+		"There was a problem with song.als: There was a problem creating the file.\nThere was a problem with clip.alc: There was a problem renaming the file.",
+		taskErrors.Error(),
+	)
+	assert.True(t, errors.Is(taskErrors[0], utiltest.DefaultCreateFileError))
+}
+
+type RunTasksTestCase struct {
+	description    string
+	numberOfJobs   int
+	numberOfTasks  int
+	failedTasks    map[int]bool
+	expectedOutput string
+	expectedError  error
+}
+
+func (testCase *RunTasksTestCase) Run(t *testing.T) {
+
+	mockExecutionEnvironment := utiltest.NewMockExecutionEnvironmentBuilder().BuildAndInit()
+
+	tasks := make([]*util.Task, 0, testCase.numberOfTasks)
+	for i := 0; i < testCase.numberOfTasks; i++ {
+		taskName := fmt.Sprintf("task-%d", i)
+		isFailedTask := testCase.failedTasks[i]
+		// Earlier tasks take longer, so they finish after later tasks when run concurrently.
+		delay := time.Duration(testCase.numberOfTasks-i) * time.Millisecond
+		tasks = append(
+			tasks,
+			&util.Task{
+				Name:            taskName,
+				ProgressMessage: "Finished " + taskName,
+				Run: func(printer util.WritePrinter) (err error) {
+					time.Sleep(delay)
+					printer.Println(taskName)
+					if isFailedTask {
+						err = errors.New(taskName + " failed")
+					}
+					return
+				},
+			},
+		)
+	}
+
+	err := util.RunTasks(testCase.numberOfJobs, tasks)
+
+	assert.Exactly(t, testCase.expectedError, err, testCase.description)
+	assert.Exactly(t, testCase.expectedOutput, mockExecutionEnvironment.MockWritePrinter.GetOutputContentsAsString(), testCase.description)
+
+}
@@ -1,14 +1,25 @@
 package utiltest
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"io"
+	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/stevengt/mppm/ableton/abletontest"
 	"github.com/stevengt/mppm/util"
 )
 
@@ -26,6 +37,9 @@ var DefaultWalkFilePathError error = errors.New("There was a problem walking the
 
 var DefaultUserHomeDirError error = errors.New("There was a problem getting the user's home directory.")
 
+// The modification time reported for every mock file.
+var MockFileModifiedTime = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
 // ------------------------------------------------------------------------------
 
 func GetPlainTextFileBuilder() *MockFileBuilder {
@@ -64,6 +78,42 @@ func GetFakeUncompressedAbletonLiveSetFileBuilder() *MockFileBuilder {
 		SetContentsFromString("<Ableton><LiveSet></LiveSet></Ableton>")
 }
 
+func GetFakeAbletonLive11SetFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-ableton-live-11-set.als").
+		SetContentsFromHexString("1f8b0800000000000203b3714cca492dc9cf53f0cdcccb2f0a4b2d2acecccfb3553234d433883731365652702e4a4d2cc92fb2558229f4c92c4b5500ca1beb9928d9d98078c1a9257636fa081654a51d002160edf75c000000")
+}
+
+func GetFakeUncompressedAbletonLive11SetFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-ableton-live-11-set.als.xml").
+		SetContentsFromString("<Ableton MinorVersion=\"11.0_433\" Creator=\"Ableton Live 11.3.4\"><LiveSet></LiveSet></Ableton>")
+}
+
+func GetFakeSplitAbletonLiveSetFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-split-ableton-live-set.als").
+		SetContentsFromBytes(GetGzippedContents(abletontest.OriginalLiveSetXml))
+}
+
+func GetFakeSplitAbletonLiveSetGlobalSettingsFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-split-ableton-live-set.als.xml").
+		SetContentsFromString(abletontest.SplitOriginalLiveSetXml)
+}
+
+func GetFakeSplitAbletonLiveSetMidiTrackFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-split-ableton-live-set.als.parts/tracks/1-MIDI.xml").
+		SetContentsFromString(abletontest.SplitOriginalLiveSetMidiTrackXml)
+}
+
+func GetFakeSplitAbletonLiveSetMasterTrackFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-split-ableton-live-set.als.parts/master.xml").
+		SetContentsFromString(abletontest.SplitOriginalLiveSetMasterTrackXml)
+}
+
 func GetFakeAbletonLiveClipFileBuilder() *MockFileBuilder {
 	return NewMockFileBuilder().
 		SetFilePath("fake-ableton-live-clip.alc").
@@ -76,6 +126,122 @@ func GetFakeUncompressedAbletonLiveClipFileBuilder() *MockFileBuilder {
 		SetContentsFromString("<Ableton><LiveSet><Tracks><MidiTrack><DeviceChain><MainSequencer><ClipSlotList><ClipSlot></ClipSlot></ClipSlotList></MainSequencer></DeviceChain></MidiTrack></Tracks></LiveSet></Ableton>")
 }
 
+func GetFakeStudioOneSongFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-studio-one-song.song").
+		SetContentsFromBytes(
+			GetZipFileContents(
+				map[string]string{
+					"Song/song.xml": "<Song />",
+					"metainfo.xml":  "<MetaInfo />",
+				},
+			),
+		)
+}
+
+func GetFakeUnzippedStudioOneSongFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-studio-one-song.song.unzipped/Song/song.xml").
+		SetContentsFromString("<Song />")
+}
+
+func GetFakeUnzippedStudioOneMetaInfoFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-studio-one-song.song.unzipped/metainfo.xml").
+		SetContentsFromString("<MetaInfo />")
+}
+
+func GetFakeLmmsProjectFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-lmms-project.mmpz").
+		SetContentsFromHexString("00000035789cb3b1afc8cd51284b2d2acecccfb35532d43350b2b7e3b2c9c9cd2dd62d28cacf4a4d2e419555d0b7e30200d4a91070")
+}
+
+func GetFakeUncompressedLmmsProjectFileBuilder() *MockFileBuilder {
+	return NewMockFileBuilder().
+		SetFilePath("fake-lmms-project.mmpz.xml").
+		SetContentsFromString("<?xml version=\"1.0\"?>\n<lmms-project version=\"1.0\" />\n")
+}
+
+// Returns the given contents compressed with gzip, using the same gzip implementation as util.GzipCodec.
+func GetGzippedContents(contents string) []byte {
+	compressedContents := new(bytes.Buffer)
+	gzipWriter := gzip.NewWriter(compressedContents)
+	gzipWriter.Write([]byte(contents))
+	gzipWriter.Close()
+	return compressedContents.Bytes()
+}
+
+// Returns the given contents compressed the same way as Qt's qCompress(),
+// using the same zlib implementation as util.QCompressCodec.
+func GetQCompressedContents(contents string) []byte {
+	compressedContents := new(bytes.Buffer)
+	binary.Write(compressedContents, binary.BigEndian, uint32(len(contents)))
+	zlibWriter := zlib.NewWriter(compressedContents)
+	zlibWriter.Write([]byte(contents))
+	zlibWriter.Close()
+	return compressedContents.Bytes()
+}
+
+// Returns the contents of a zip archive containing the given files,
+// formatted the same way as archives created by util.ZipDirectory().
+func GetZipFileContents(fileNamesAndContents map[string]string) []byte {
+
+	fileNames := make([]string, 0, len(fileNamesAndContents))
+	for fileName := range fileNamesAndContents {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	zipContents := new(bytes.Buffer)
+	zipWriter := zip.NewWriter(zipContents)
+	for _, fileName := range fileNames {
+		zippedFileWriter, _ := zipWriter.CreateHeader(
+			&zip.FileHeader{
+				Name:     fileName,
+				Method:   zip.Deflate,
+				Modified: util.ZipFileModifiedTime,
+			},
+		)
+		zippedFileWriter.Write([]byte(fileNamesAndContents[fileName]))
+	}
+	zipWriter.Close()
+
+	return zipContents.Bytes()
+
+}
+
+// Returns the contents of a tar archive containing the given files,
+// formatted the same way as archives created by util.CreateArchiveFile().
+func GetTarFileContents(fileNamesAndContents map[string]string) []byte {
+
+	fileNames := make([]string, 0, len(fileNamesAndContents))
+	for fileName := range fileNamesAndContents {
+		fileNames = append(fileNames, fileName)
+	}
+	sort.Strings(fileNames)
+
+	tarContents := new(bytes.Buffer)
+	tarWriter := tar.NewWriter(tarContents)
+	for _, fileName := range fileNames {
+		tarWriter.WriteHeader(
+			&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     fileName,
+				Mode:     0644,
+				Size:     int64(len(fileNamesAndContents[fileName])),
+				ModTime:  util.ZipFileModifiedTime,
+				Format:   tar.FormatPAX,
+			},
+		)
+		tarWriter.Write([]byte(fileNamesAndContents[fileName]))
+	}
+	tarWriter.Close()
+
+	return tarContents.Bytes()
+
+}
+
 // ------------------------------------------------------------------------------
 
 func GetTestFileNamesAndContents() map[string][]byte {
@@ -227,6 +393,8 @@ type MockFileSystemDelegater struct {
 	RemoveFileError   error
 	WalkFilePathError error
 	UserHomeDirError  error
+
+	mutex sync.Mutex // Allows files to be opened, created, etc. by concurrent tasks.
 }
 
 func NewMockFileSystemDelegater() *MockFileSystemDelegater {
@@ -248,15 +416,17 @@ func (mockFileSystemDelegater *MockFileSystemDelegater) InitFiles(fileNamesAndCo
 }
 
 func (mockFileSystemDelegater *MockFileSystemDelegater) GetMockFileAndContentsIfFileExistsElseReturnNil(fileName string) (file *MockFile, contents []byte) {
-	if mockFileSystemDelegater.DoesFileExist(fileName) {
-		file = mockFileSystemDelegater.Files[fileName]
-		contents = file.Contents
-		return
+	mockFileSystemDelegater.mutex.Lock()
+	defer mockFileSystemDelegater.mutex.Unlock()
+	if file, doesFileExist := mockFileSystemDelegater.Files[fileName]; doesFileExist {
+		return file, file.Contents
 	}
 	return nil, nil
 }
 
 func (mockFileSystemDelegater *MockFileSystemDelegater) OpenFile(fileName string) (file io.ReadWriteCloser, err error) {
+	mockFileSystemDelegater.mutex.Lock()
+	defer mockFileSystemDelegater.mutex.Unlock()
 	if err = mockFileSystemDelegater.OpenFileError; err == nil {
 		var doesFileExist bool
 		file, doesFileExist = mockFileSystemDelegater.Files[fileName]
@@ -268,9 +438,11 @@ func (mockFileSystemDelegater *MockFileSystemDelegater) OpenFile(fileName string
 }
 
 func (mockFileSystemDelegater *MockFileSystemDelegater) CreateFile(fileName string) (file io.ReadWriteCloser, err error) {
+	mockFileSystemDelegater.mutex.Lock()
+	defer mockFileSystemDelegater.mutex.Unlock()
 	if err = mockFileSystemDelegater.CreateFileError; err == nil {
 		if mockFileSystemDelegater.Files[fileName] != nil {
-			if err = mockFileSystemDelegater.RemoveFile(fileName); err != nil {
+			if err = mockFileSystemDelegater.removeFile(fileName); err != nil {
 				return
 			}
 		}
@@ -281,7 +453,39 @@ func (mockFileSystemDelegater *MockFileSystemDelegater) CreateFile(fileName stri
 	return
 }
 
+// Like the real file system, the file is only created or replaced if all of its contents are written.
+func (mockFileSystemDelegater *MockFileSystemDelegater) WriteFileAtomically(fileName string, writeContents func(file io.Writer) error) (err error) {
+
+	mockFileSystemDelegater.mutex.Lock()
+	err = mockFileSystemDelegater.CreateFileError
+	mockFileSystemDelegater.mutex.Unlock()
+	if err != nil {
+		return
+	}
+
+	file := NewMockFileFromBytes(fileName, make([]byte, 0))
+	err = writeContents(file)
+	file.Close()
+	if err != nil {
+		return
+	}
+
+	mockFileSystemDelegater.mutex.Lock()
+	defer mockFileSystemDelegater.mutex.Unlock()
+	if mockFileSystemDelegater.Files[fileName] != nil {
+		if err = mockFileSystemDelegater.removeFile(fileName); err != nil {
+			return
+		}
+	}
+	mockFileSystemDelegater.Files[fileName] = file
+
+	return
+
+}
+
 func (mockFileSystemDelegater *MockFileSystemDelegater) RenameFile(fileName string, newFileName string) (err error) {
+	mockFileSystemDelegater.mutex.Lock()
+	defer mockFileSystemDelegater.mutex.Unlock()
 	if err = mockFileSystemDelegater.RenameFileError; err == nil {
 		mockFileSystemDelegater.Files[newFileName] = mockFileSystemDelegater.Files[fileName]
 		delete(mockFileSystemDelegater.Files, fileName)
@@ -290,8 +494,20 @@ func (mockFileSystemDelegater *MockFileSystemDelegater) RenameFile(fileName stri
 }
 
 func (mockFileSystemDelegater *MockFileSystemDelegater) RemoveFile(fileName string) (err error) {
+	mockFileSystemDelegater.mutex.Lock()
+	defer mockFileSystemDelegater.mutex.Unlock()
+	return mockFileSystemDelegater.removeFile(fileName)
+}
+
+func (mockFileSystemDelegater *MockFileSystemDelegater) removeFile(fileName string) (err error) {
 	if err = mockFileSystemDelegater.RemoveFileError; err == nil {
 		delete(mockFileSystemDelegater.Files, fileName)
+		// Like os.RemoveAll(), also remove everything inside of the file if it is a directory.
+		for existingFileName := range mockFileSystemDelegater.Files {
+			if strings.HasPrefix(existingFileName, fileName+"/") {
+				delete(mockFileSystemDelegater.Files, existingFileName)
+			}
+		}
 	}
 	return
 }
@@ -307,6 +523,21 @@ func (mockFileSystemDelegater *MockFileSystemDelegater) WalkFilePath(root string
 	return
 }
 
+func (mockFileSystemDelegater *MockFileSystemDelegater) StatFile(fileName string) (fileInfo os.FileInfo, err error) {
+	mockFileSystemDelegater.mutex.Lock()
+	defer mockFileSystemDelegater.mutex.Unlock()
+	mockFile, doesFileExist := mockFileSystemDelegater.Files[fileName]
+	if !doesFileExist {
+		err = errors.New("Unable to stat file " + fileName)
+		return
+	}
+	fileInfo = &MockFileInfo{
+		name: path.Base(fileName),
+		size: int64(len(mockFile.Contents)),
+	}
+	return
+}
+
 func (mockFileSystemDelegater *MockFileSystemDelegater) UserHomeDir() (string, error) {
 	err := mockFileSystemDelegater.UserHomeDirError
 	if err == nil {
@@ -315,14 +546,57 @@ func (mockFileSystemDelegater *MockFileSystemDelegater) UserHomeDir() (string, e
 	return "", err
 }
 
+// Joins and cleans the given path elements, like filepath.Join, but always uses slashes.
 func (mockFileSystemDelegater *MockFileSystemDelegater) JoinFilePath(elem ...string) string {
-	return strings.Join(elem, "/")
+	return path.Join(elem...)
 }
 
+// Returns true if the given file exists, or if it is a directory containing any files.
 func (mockFileSystemDelegater *MockFileSystemDelegater) DoesFileExist(filePath string) bool {
-	var doesFileExist bool
-	_, doesFileExist = mockFileSystemDelegater.Files[filePath]
-	return doesFileExist
+	mockFileSystemDelegater.mutex.Lock()
+	defer mockFileSystemDelegater.mutex.Unlock()
+	if _, doesFileExist := mockFileSystemDelegater.Files[filePath]; doesFileExist {
+		return true
+	}
+	for fileName := range mockFileSystemDelegater.Files {
+		if strings.HasPrefix(fileName, filePath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------------------------------------------------------------------
+
+// Implements os.FileInfo for mock files, which are never directories
+// and all have the same modification time.
+type MockFileInfo struct {
+	name string
+	size int64
+}
+
+func (fileInfo *MockFileInfo) Name() string {
+	return fileInfo.name
+}
+
+func (fileInfo *MockFileInfo) Size() int64 {
+	return fileInfo.size
+}
+
+func (fileInfo *MockFileInfo) Mode() os.FileMode {
+	return 0644
+}
+
+func (fileInfo *MockFileInfo) ModTime() time.Time {
+	return MockFileModifiedTime
+}
+
+func (fileInfo *MockFileInfo) IsDir() bool {
+	return false
+}
+
+func (fileInfo *MockFileInfo) Sys() interface{} {
+	return nil
 }
 
 // ------------------------------------------------------------------------------
@@ -1,7 +1,10 @@
 package utiltest
 
 import (
+	"bytes"
 	"errors"
+	"strconv"
+	"strings"
 
 	"github.com/stevengt/mppm/util"
 )
@@ -16,8 +19,28 @@ var DefaultCommitError error = errors.New("There was a problem committing items
 
 var DefaultCheckoutError error = errors.New("There was a problem checking out the git repository.")
 
+var DefaultStashError error = errors.New("There was a problem stashing changes in the git repository.")
+
+var DefaultWorktreeError error = errors.New("There was a problem managing the worktrees of the git repository.")
+
+var DefaultTagError error = errors.New("There was a problem tagging a commit in the git repository.")
+
 var DefaultRevParseError error = errors.New("Not a git repository.")
 
+var DefaultCatFileError error = errors.New("There was a problem reading an object from the git repository.")
+
+var DefaultDiffError error = errors.New("There was a problem comparing revisions of the git repository.")
+
+var DefaultDiffTreeError error = errors.New("There was a problem comparing trees in the git repository.")
+
+var DefaultLogError error = errors.New("There was a problem showing the commit logs of the git repository.")
+
+var DefaultLsFilesError error = errors.New("There was a problem listing files in the git repository.")
+
+var DefaultStatusError error = errors.New("There was a problem showing the status of the git repository.")
+
+var DefaultConfigError error = errors.New("There was a problem updating the git repository's config.")
+
 var DefaultLfsInstallError error = errors.New("There was a problem while setting up git lfs.")
 
 var DefaultLfsTrackError error = errors.New("There was a problem trying to track files with git lfs.")
@@ -36,11 +59,31 @@ func GetMockGitManagerCreatorFromBuilderOrNil(mockGitManagerCreatorBuilder *Mock
 
 type MockGitManagerCreatorBuilder struct {
 	RevParseStdout            string
+	RevParseStdouts           map[string]string // Map of 'git rev-parse' args, joined by spaces, to their mocked stdout, instead of RevParseStdout.
+	RevParseErrors            map[string]error  // Map of 'git rev-parse' args, joined by spaces, to their mocked error.
+	CatFileStdouts            map[string]string // Map of 'git cat-file' args, joined by spaces, to their mocked stdout.
+	DiffStdout                string
+	DiffTreeStdouts           map[string]string // Map of 'git diff-tree' args, joined by spaces, to their mocked stdout.
+	LogStdout                 string
+	LsFilesStdout             string
+	StatusStdout              string
+	CheckoutErrors            map[string]error // Map of 'git checkout' args, joined by spaces, to their mocked error.
+	StashErrors               []error          // Mocked errors of successive 'git stash' calls, before falling back to the default.
 	UseDefaultInitError       bool
 	UseDefaultAddError        bool
 	UseDefaultCommitError     bool
 	UseDefaultCheckoutError   bool
+	UseDefaultStashError      bool
+	UseDefaultWorktreeError   bool
+	UseDefaultTagError        bool
 	UseDefaultRevParseError   bool
+	UseDefaultCatFileError    bool
+	UseDefaultDiffError       bool
+	UseDefaultDiffTreeError   bool
+	UseDefaultLogError        bool
+	UseDefaultLsFilesError    bool
+	UseDefaultStatusError     bool
+	UseDefaultConfigError     bool
 	UseDefaultLfsInstallError bool
 	UseDefaultLfsTrackError   bool
 }
@@ -54,6 +97,51 @@ func (builder *MockGitManagerCreatorBuilder) SetRevParseStdout(revParseStdout st
 	return builder
 }
 
+func (builder *MockGitManagerCreatorBuilder) SetRevParseStdouts(revParseStdouts map[string]string) *MockGitManagerCreatorBuilder {
+	builder.RevParseStdouts = revParseStdouts
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetRevParseErrors(revParseErrors map[string]error) *MockGitManagerCreatorBuilder {
+	builder.RevParseErrors = revParseErrors
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetCatFileStdouts(catFileStdouts map[string]string) *MockGitManagerCreatorBuilder {
+	builder.CatFileStdouts = catFileStdouts
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetDiffStdout(diffStdout string) *MockGitManagerCreatorBuilder {
+	builder.DiffStdout = diffStdout
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetDiffTreeStdouts(diffTreeStdouts map[string]string) *MockGitManagerCreatorBuilder {
+	builder.DiffTreeStdouts = diffTreeStdouts
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetLogStdout(logStdout string) *MockGitManagerCreatorBuilder {
+	builder.LogStdout = logStdout
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetLsFilesStdout(lsFilesStdout string) *MockGitManagerCreatorBuilder {
+	builder.LsFilesStdout = lsFilesStdout
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetStatusStdout(statusStdout string) *MockGitManagerCreatorBuilder {
+	builder.StatusStdout = statusStdout
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetCheckoutErrors(checkoutErrors map[string]error) *MockGitManagerCreatorBuilder {
+	builder.CheckoutErrors = checkoutErrors
+	return builder
+}
+
 func (builder *MockGitManagerCreatorBuilder) SetUseDefaultInitError(useDefaultInitError bool) *MockGitManagerCreatorBuilder {
 	builder.UseDefaultInitError = useDefaultInitError
 	return builder
@@ -74,11 +162,66 @@ func (builder *MockGitManagerCreatorBuilder) SetUseDefaultCheckoutError(useDefau
 	return builder
 }
 
+func (builder *MockGitManagerCreatorBuilder) SetStashErrors(stashErrors []error) *MockGitManagerCreatorBuilder {
+	builder.StashErrors = stashErrors
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultStashError(useDefaultStashError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultStashError = useDefaultStashError
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultWorktreeError(useDefaultWorktreeError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultWorktreeError = useDefaultWorktreeError
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultTagError(useDefaultTagError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultTagError = useDefaultTagError
+	return builder
+}
+
 func (builder *MockGitManagerCreatorBuilder) SetUseDefaultRevParseError(useDefaultRevParseError bool) *MockGitManagerCreatorBuilder {
 	builder.UseDefaultRevParseError = useDefaultRevParseError
 	return builder
 }
 
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultCatFileError(useDefaultCatFileError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultCatFileError = useDefaultCatFileError
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultDiffError(useDefaultDiffError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultDiffError = useDefaultDiffError
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultDiffTreeError(useDefaultDiffTreeError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultDiffTreeError = useDefaultDiffTreeError
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultLogError(useDefaultLogError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultLogError = useDefaultLogError
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultLsFilesError(useDefaultLsFilesError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultLsFilesError = useDefaultLsFilesError
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultStatusError(useDefaultStatusError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultStatusError = useDefaultStatusError
+	return builder
+}
+
+func (builder *MockGitManagerCreatorBuilder) SetUseDefaultConfigError(useDefaultConfigError bool) *MockGitManagerCreatorBuilder {
+	builder.UseDefaultConfigError = useDefaultConfigError
+	return builder
+}
+
 func (builder *MockGitManagerCreatorBuilder) SetUseDefaultLfsInstallError(useDefaultLfsInstallError bool) *MockGitManagerCreatorBuilder {
 	builder.UseDefaultLfsInstallError = useDefaultLfsInstallError
 	return builder
@@ -92,8 +235,18 @@ func (builder *MockGitManagerCreatorBuilder) SetUseDefaultLfsTrackError(useDefau
 func (builder *MockGitManagerCreatorBuilder) Build() *MockGitManagerCreator {
 
 	mockGitManager := &MockGitManager{
-		InputHistory:   make([][]string, 0),
-		RevParseStdout: builder.RevParseStdout,
+		InputHistory:    make([][]string, 0),
+		RevParseStdout:  builder.RevParseStdout,
+		RevParseStdouts: builder.RevParseStdouts,
+		RevParseErrors:  builder.RevParseErrors,
+		CatFileStdouts:  builder.CatFileStdouts,
+		DiffStdout:      builder.DiffStdout,
+		DiffTreeStdouts: builder.DiffTreeStdouts,
+		LogStdout:       builder.LogStdout,
+		LsFilesStdout:   builder.LsFilesStdout,
+		StatusStdout:    builder.StatusStdout,
+		CheckoutErrors:  builder.CheckoutErrors,
+		StashErrors:     builder.StashErrors,
 	}
 
 	if builder.UseDefaultInitError {
@@ -112,10 +265,50 @@ func (builder *MockGitManagerCreatorBuilder) Build() *MockGitManagerCreator {
 		mockGitManager.CheckoutError = DefaultCheckoutError
 	}
 
+	if builder.UseDefaultStashError {
+		mockGitManager.StashError = DefaultStashError
+	}
+
+	if builder.UseDefaultWorktreeError {
+		mockGitManager.WorktreeError = DefaultWorktreeError
+	}
+
+	if builder.UseDefaultTagError {
+		mockGitManager.TagError = DefaultTagError
+	}
+
 	if builder.UseDefaultRevParseError {
 		mockGitManager.RevParseError = DefaultRevParseError
 	}
 
+	if builder.UseDefaultCatFileError {
+		mockGitManager.CatFileError = DefaultCatFileError
+	}
+
+	if builder.UseDefaultDiffError {
+		mockGitManager.DiffError = DefaultDiffError
+	}
+
+	if builder.UseDefaultDiffTreeError {
+		mockGitManager.DiffTreeError = DefaultDiffTreeError
+	}
+
+	if builder.UseDefaultLogError {
+		mockGitManager.LogError = DefaultLogError
+	}
+
+	if builder.UseDefaultLsFilesError {
+		mockGitManager.LsFilesError = DefaultLsFilesError
+	}
+
+	if builder.UseDefaultStatusError {
+		mockGitManager.StatusError = DefaultStatusError
+	}
+
+	if builder.UseDefaultConfigError {
+		mockGitManager.ConfigError = DefaultConfigError
+	}
+
 	if builder.UseDefaultLfsInstallError {
 		mockGitManager.LfsInstallError = DefaultLfsInstallError
 	}
@@ -156,8 +349,28 @@ type MockGitManager struct {
 	AddError        error
 	CommitError     error
 	CheckoutError   error
+	CheckoutErrors  map[string]error // Map of 'git checkout' args, joined by spaces, to their mocked error.
+	StashError      error
+	StashErrors     []error // Mocked errors of successive 'git stash' calls, instead of StashError.
+	WorktreeError   error
+	TagError        error
 	RevParseStdout  string
+	RevParseStdouts map[string]string // Map of 'git rev-parse' args, joined by spaces, to their mocked stdout, instead of RevParseStdout.
 	RevParseError   error
+	RevParseErrors  map[string]error  // Map of 'git rev-parse' args, joined by spaces, to their mocked error.
+	CatFileStdouts  map[string]string // Map of 'git cat-file' args, joined by spaces, to their mocked stdout.
+	CatFileError    error
+	DiffStdout      string
+	DiffError       error
+	DiffTreeStdouts map[string]string // Map of 'git diff-tree' args, joined by spaces, to their mocked stdout.
+	DiffTreeError   error
+	LogStdout       string
+	LogError        error
+	LsFilesStdout   string
+	LsFilesError    error
+	StatusStdout    string
+	StatusError     error
+	ConfigError     error
 	LfsInstallError error
 	LfsTrackError   error
 }
@@ -179,14 +392,117 @@ func (mockGitManager *MockGitManager) Commit(args ...string) (err error) {
 
 func (mockGitManager *MockGitManager) Checkout(args ...string) (err error) {
 	mockGitManager.appendToInputHistory("checkout", args...)
+	if err, ok := mockGitManager.CheckoutErrors[strings.Join(args, " ")]; ok {
+		return err
+	}
 	return mockGitManager.CheckoutError
 }
 
+func (mockGitManager *MockGitManager) Stash(args ...string) (err error) {
+	mockGitManager.appendToInputHistory("stash", args...)
+	if len(mockGitManager.StashErrors) > 0 {
+		err = mockGitManager.StashErrors[0]
+		mockGitManager.StashErrors = mockGitManager.StashErrors[1:]
+		return
+	}
+	return mockGitManager.StashError
+}
+
+func (mockGitManager *MockGitManager) Worktree(args ...string) (err error) {
+	mockGitManager.appendToInputHistory("worktree", args...)
+	return mockGitManager.WorktreeError
+}
+
+func (mockGitManager *MockGitManager) Tag(args ...string) (err error) {
+	mockGitManager.appendToInputHistory("tag", args...)
+	return mockGitManager.TagError
+}
+
 func (mockGitManager *MockGitManager) RevParse(args ...string) (stdout string, err error) {
 	mockGitManager.appendToInputHistory("rev-parse", args...)
+	if err, ok := mockGitManager.RevParseErrors[strings.Join(args, " ")]; ok {
+		return "", err
+	}
+	if stdout, ok := mockGitManager.RevParseStdouts[strings.Join(args, " ")]; ok {
+		return stdout, mockGitManager.RevParseError
+	}
 	return mockGitManager.RevParseStdout, mockGitManager.RevParseError
 }
 
+func (mockGitManager *MockGitManager) CatFileContents(args ...string) (contents []byte, err error) {
+	mockGitManager.appendToInputHistory("cat-file", args...)
+	if err = mockGitManager.CatFileError; err != nil {
+		return
+	}
+	stdout, ok := mockGitManager.CatFileStdouts[strings.Join(args, " ")]
+	if !ok {
+		err = errors.New("fatal: Not a valid object name " + args[len(args)-1])
+	}
+	contents = []byte(stdout)
+	return
+}
+
+// Builds the output of 'git cat-file --batch-check' or 'git cat-file --batch' from the mocked output
+// of 'git cat-file -s' and 'git cat-file -p' for each object. The object names are recorded after the args.
+func (mockGitManager *MockGitManager) CatFileBatch(objectNames []string, args ...string) (stdout []byte, err error) {
+	mockGitManager.appendToInputHistory("cat-file", append(append([]string{}, args...), objectNames...)...)
+	if err = mockGitManager.CatFileError; err != nil {
+		return
+	}
+	isBatch := len(args) > 0 && args[0] == "--batch"
+	var buffer bytes.Buffer
+	for _, objectName := range objectNames {
+		size, ok := mockGitManager.CatFileStdouts["-s "+objectName]
+		if !ok {
+			buffer.WriteString(objectName + " missing\n")
+		} else if !isBatch {
+			buffer.WriteString(objectName + " blob " + strings.TrimSpace(size) + "\n")
+		} else {
+			contents := mockGitManager.CatFileStdouts["-p "+objectName]
+			buffer.WriteString(objectName + " blob " + strconv.Itoa(len(contents)) + "\n" + contents + "\n")
+		}
+	}
+	stdout = buffer.Bytes()
+	return
+}
+
+func (mockGitManager *MockGitManager) Diff(args ...string) (stdout string, err error) {
+	mockGitManager.appendToInputHistory("diff", args...)
+	return mockGitManager.DiffStdout, mockGitManager.DiffError
+}
+
+func (mockGitManager *MockGitManager) DiffTree(args ...string) (stdout string, err error) {
+	mockGitManager.appendToInputHistory("diff-tree", args...)
+	if err = mockGitManager.DiffTreeError; err != nil {
+		return
+	}
+	stdout, ok := mockGitManager.DiffTreeStdouts[strings.Join(args, " ")]
+	if !ok {
+		err = errors.New("fatal: bad object " + args[len(args)-1])
+	}
+	return
+}
+
+func (mockGitManager *MockGitManager) Log(args ...string) (stdout string, err error) {
+	mockGitManager.appendToInputHistory("log", args...)
+	return mockGitManager.LogStdout, mockGitManager.LogError
+}
+
+func (mockGitManager *MockGitManager) LsFiles(args ...string) (stdout string, err error) {
+	mockGitManager.appendToInputHistory("ls-files", args...)
+	return mockGitManager.LsFilesStdout, mockGitManager.LsFilesError
+}
+
+func (mockGitManager *MockGitManager) Status(args ...string) (stdout string, err error) {
+	mockGitManager.appendToInputHistory("status", args...)
+	return mockGitManager.StatusStdout, mockGitManager.StatusError
+}
+
+func (mockGitManager *MockGitManager) Config(args ...string) (err error) {
+	mockGitManager.appendToInputHistory("config", args...)
+	return mockGitManager.ConfigError
+}
+
 func (mockGitManager *MockGitManager) LfsInstall() (err error) {
 	mockGitManager.appendToInputHistory("lfs", "install")
 	return mockGitManager.LfsInstallError
@@ -84,3 +84,15 @@ func (mockShellCommandDelegater *MockShellCommandDelegater) ExecuteShellCommandA
 
 	return
 }
+
+func (mockShellCommandDelegater *MockShellCommandDelegater) ExecuteShellCommandAndReturnStdout(commandName string, args ...string) (stdout []byte, err error) {
+	stdoutAsString, err := mockShellCommandDelegater.ExecuteShellCommandAndReturnOutput(commandName, args...)
+	stdout = []byte(stdoutAsString)
+	return
+}
+
+// The input written to stdin is recorded after the args, split into lines.
+func (mockShellCommandDelegater *MockShellCommandDelegater) ExecuteShellCommandWithStdinAndReturnStdout(stdin []byte, commandName string, args ...string) (stdout []byte, err error) {
+	stdinLines := strings.Fields(string(stdin))
+	return mockShellCommandDelegater.ExecuteShellCommandAndReturnStdout(commandName, append(append([]string{}, args...), stdinLines...)...)
+}